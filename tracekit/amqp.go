@@ -0,0 +1,109 @@
+package tracekit
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InjectAMQPHeaders starts a PRODUCER span for a message about to be
+// published and injects its context into msg.Headers, so the consumer's span
+// continues the trace. End the returned span once Publish returns.
+//
+//	ctx, span := sdk.InjectAMQPHeaders(ctx, "orders", "order.created", &msg)
+//	err := ch.PublishWithContext(ctx, "orders", "order.created", false, false, msg)
+//	span.End()
+func (s *SDK) InjectAMQPHeaders(ctx context.Context, exchange, routingKey string, msg *amqp.Publishing) (context.Context, trace.Span) {
+	ctx, span := s.tracer.Start(ctx, amqpDestination(exchange, routingKey)+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.destination.name", exchange),
+			attribute.String("messaging.rabbitmq.destination.routing_key", routingKey),
+			attribute.String("messaging.operation", "publish"),
+			attribute.Int("messaging.message.body.size", len(msg.Body)),
+		),
+	)
+
+	// Copy headers so a table shared between publishings isn't mutated
+	headers := make(amqp.Table, len(msg.Headers)+2)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	otel.GetTextMapPropagator().Inject(ctx, amqpHeaderCarrier(headers))
+	msg.Headers = headers
+
+	return ctx, span
+}
+
+// StartAMQPConsumerSpan starts a CONSUMER span for a delivery, continuing the
+// producer's trace from its headers. queue is the queue the delivery was
+// consumed from. The caller must end the span once the delivery is processed.
+//
+//	for d := range deliveries {
+//		ctx, span := sdk.StartAMQPConsumerSpan(d, "orders.created")
+//		handle(ctx, d)
+//		span.End()
+//	}
+func (s *SDK) StartAMQPConsumerSpan(delivery amqp.Delivery, queue string) (context.Context, trace.Span) {
+	ctx := context.Background()
+	if delivery.Headers != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, amqpHeaderCarrier(delivery.Headers))
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("messaging.system", "rabbitmq"),
+		attribute.String("messaging.destination.name", delivery.Exchange),
+		attribute.String("messaging.rabbitmq.destination.routing_key", delivery.RoutingKey),
+		attribute.String("messaging.operation", "process"),
+		attribute.Int("messaging.message.body.size", len(delivery.Body)),
+	}
+	if queue != "" {
+		attrs = append(attrs, attribute.String("messaging.source.name", queue))
+	}
+	if delivery.MessageId != "" {
+		attrs = append(attrs, attribute.String("messaging.message.id", delivery.MessageId))
+	}
+
+	return s.tracer.Start(ctx, amqpDestination(delivery.Exchange, delivery.RoutingKey)+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// amqpDestination names a span after the exchange, or the routing key when
+// publishing through the default exchange
+func amqpDestination(exchange, routingKey string) string {
+	if exchange == "" {
+		return routingKey
+	}
+	return exchange
+}
+
+// amqpHeaderCarrier adapts an AMQP header table to propagation.TextMapCarrier
+type amqpHeaderCarrier amqp.Table
+
+func (c amqpHeaderCarrier) Get(key string) string {
+	switch v := c[key].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
+
+func (c amqpHeaderCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+func (c amqpHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
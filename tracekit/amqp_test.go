@@ -0,0 +1,40 @@
+package tracekit
+
+import (
+	"context"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// TestAMQPContextPropagation verifies the consumer span continues the trace
+// injected into the publishing's headers
+func TestAMQPContextPropagation(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	tp := sdktrace.NewTracerProvider()
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{tracer: tp.Tracer("test")}
+
+	shared := amqp.Table{"x-tenant": "acme"}
+	msg := amqp.Publishing{Headers: shared, Body: []byte("{}")}
+	_, producer := sdk.InjectAMQPHeaders(context.Background(), "orders", "order.created", &msg)
+	producer.End()
+
+	if _, ok := shared["traceparent"]; ok {
+		t.Error("caller's header table was modified")
+	}
+
+	delivery := amqp.Delivery{Headers: msg.Headers, Exchange: "orders", RoutingKey: "order.created", Body: msg.Body}
+	_, consumer := sdk.StartAMQPConsumerSpan(delivery, "orders.created")
+	defer consumer.End()
+
+	if consumer.SpanContext().TraceID() != producer.SpanContext().TraceID() {
+		t.Error("consumer span does not continue the producer trace")
+	}
+}
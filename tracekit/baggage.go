@@ -0,0 +1,36 @@
+package tracekit
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// SetBaggage returns a copy of ctx whose W3C baggage carries key=value, so it
+// is propagated to downstream services by the instrumented HTTP and gRPC
+// clients. Values are percent-encoded on the wire as the W3C baggage spec
+// requires, so they may contain any characters. An invalid key (not a W3C
+// token) is logged and ctx is returned unchanged.
+//
+//	ctx = sdk.SetBaggage(ctx, "tenant.id", tenantID)
+func (s *SDK) SetBaggage(ctx context.Context, key, value string) context.Context {
+	member, err := baggage.NewMemberRaw(key, value)
+	if err != nil {
+		s.logger.warnf("TraceKit: invalid baggage entry %q: %v", key, err)
+		return ctx
+	}
+
+	bag, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		s.logger.warnf("TraceKit: failed to set baggage entry %q: %v", key, err)
+		return ctx
+	}
+	return baggage.ContextWithBaggage(ctx, bag)
+}
+
+// GetBaggage returns the decoded baggage value for key in ctx, or "" if unset
+//
+//	tenantID := sdk.GetBaggage(r.Context(), "tenant.id")
+func (s *SDK) GetBaggage(ctx context.Context, key string) string {
+	return baggage.FromContext(ctx).Member(key).Value()
+}
@@ -0,0 +1,31 @@
+package tracekit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// TestBaggage verifies values round-trip through W3C propagation, including
+// characters that must be escaped, and invalid keys are rejected
+func TestBaggage(t *testing.T) {
+	sdk := &SDK{}
+	ctx := sdk.SetBaggage(context.Background(), "tenant.id", "acme, inc;eu=1")
+	ctx = sdk.SetBaggage(ctx, "bad key", "ignored")
+
+	header := http.Header{}
+	propagation.Baggage{}.Inject(ctx, propagation.HeaderCarrier(header))
+	received := propagation.Baggage{}.Extract(context.Background(), propagation.HeaderCarrier(header))
+
+	if got := sdk.GetBaggage(received, "tenant.id"); got != "acme, inc;eu=1" {
+		t.Errorf("tenant.id = %q; want %q", got, "acme, inc;eu=1")
+	}
+	if got := sdk.GetBaggage(received, "bad key"); got != "" {
+		t.Errorf("bad key = %q; want it rejected", got)
+	}
+	if got := sdk.GetBaggage(context.Background(), "tenant.id"); got != "" {
+		t.Errorf("GetBaggage on empty context = %q; want empty", got)
+	}
+}
@@ -0,0 +1,82 @@
+package tracekit
+
+import (
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Build metadata that can be injected at build time, e.g.:
+//
+//	go build -ldflags "-X github.com/Tracekit-Dev/go-sdk/tracekit.BuildRevision=$(git rev-parse HEAD)"
+//
+// Values set here take precedence over those read from the Go build info.
+var (
+	BuildVersion  string
+	BuildRevision string
+	BuildID       string
+)
+
+// BuildInfo describes the build of the running binary. It is recorded as
+// resource attributes so traces can be correlated with specific deploys.
+type BuildInfo struct {
+	// Version is used as service.version when Config.ServiceVersion is empty
+	Version string
+
+	// Revision is the VCS commit SHA (vcs.revision)
+	Revision string
+
+	// BuildID identifies the build artifact (service.build_id)
+	BuildID string
+
+	// Modified reports whether the working tree had local changes (vcs.modified)
+	Modified bool
+}
+
+// ReadBuildInfo returns BuildInfo populated from the ldflags variables above,
+// falling back to the VCS settings embedded by the Go toolchain
+// (runtime/debug.ReadBuildInfo).
+func ReadBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:  BuildVersion,
+		Revision: BuildRevision,
+		BuildID:  BuildID,
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version
+	}
+
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Revision == "" {
+				info.Revision = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+
+	return info
+}
+
+// resourceAttributes converts build info into resource attributes
+func (b *BuildInfo) resourceAttributes() []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if b.Revision != "" {
+		attrs = append(attrs,
+			attribute.String("vcs.revision", b.Revision),
+			attribute.Bool("vcs.modified", b.Modified),
+		)
+	}
+	if b.BuildID != "" {
+		attrs = append(attrs, attribute.String("service.build_id", b.BuildID))
+	}
+	return attrs
+}
@@ -0,0 +1,46 @@
+package tracekit
+
+import (
+	"context"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceCacheOp runs fn inside a span describing a cache operation, so every
+// cache layer (in-process, Redis, CDN, ...) is recorded the same way. The
+// span carries cache.system, cache.operation and cache.hit, and the
+// cache.requests counter is incremented with the same tags.
+//
+//	err := sdk.TraceCacheOp(ctx, "local-lru", "get", ok, func() error {
+//		return decode(entry, &user)
+//	})
+func (s *SDK) TraceCacheOp(ctx context.Context, cacheName, op string, hit bool, fn func() error) error {
+	_, span := s.tracer.Start(ctx, "cache."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("cache.system", cacheName),
+			attribute.String("cache.operation", op),
+			attribute.Bool("cache.hit", hit),
+		),
+	)
+	defer span.End()
+
+	s.Counter("cache.requests", map[string]string{
+		"cache.system":    cacheName,
+		"cache.operation": op,
+		"cache.hit":       strconv.FormatBool(hit),
+	}).Inc()
+
+	err := fn()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	return nil
+}
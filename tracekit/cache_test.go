@@ -0,0 +1,58 @@
+package tracekit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestTraceCacheOp verifies the cache span attributes, error status and
+// hit/miss counter
+func TestTraceCacheOp(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+
+	buffer := newMetricsBuffer("http://localhost", "test-key", "test-service", 0, 0)
+	sdk := &SDK{
+		tracer:          tp.Tracer("test"),
+		metricsRegistry: &metricsRegistry{counters: map[string]*counter{}, buffer: buffer},
+	}
+
+	if err := sdk.TraceCacheOp(context.Background(), "local-lru", "get", true, func() error { return nil }); err != nil {
+		t.Fatalf("TraceCacheOp() error = %v", err)
+	}
+	errDecode := errors.New("decode failed")
+	if err := sdk.TraceCacheOp(context.Background(), "local-lru", "get", false, func() error { return errDecode }); err != errDecode {
+		t.Fatalf("TraceCacheOp() error = %v; want %v", err, errDecode)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 || spans[0].Name() != "cache.get" {
+		t.Fatalf("got %d spans; want 2 cache.get spans", len(spans))
+	}
+	attrs := map[string]string{}
+	for _, attr := range spans[0].Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs["cache.system"] != "local-lru" || attrs["cache.operation"] != "get" || attrs["cache.hit"] != "true" {
+		t.Errorf("attributes = %v; want cache.system, cache.operation and cache.hit=true", attrs)
+	}
+	if spans[1].Status().Code != codes.Error {
+		t.Errorf("failed op status = %v; want Error", spans[1].Status().Code)
+	}
+
+	hits := map[string]int{}
+	for _, dp := range buffer.data {
+		if dp.name == "cache.requests" {
+			hits[dp.tags["cache.hit"]]++
+		}
+	}
+	if hits["true"] != 1 || hits["false"] != 1 {
+		t.Errorf("cache.requests by hit = %v; want one hit and one miss", hits)
+	}
+}
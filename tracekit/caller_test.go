@@ -0,0 +1,49 @@
+package tracekit_test
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Tracekit-Dev/go-sdk/tracekit"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestWithCallerLocation verifies code.* attributes point at the line that
+// issued the query rather than at SDK internals
+func TestWithCallerLocation(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+	sdk := tracekit.NewTestSDK(tp.Tracer("test"))
+
+	db, err := sql.Open("tracekit-fake", "") // Registered by the package's internal tests
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	tdb := sdk.WrapDB(db, "fake", tracekit.WithCallerLocation())
+	defer tdb.Close()
+
+	_, _, line, _ := runtime.Caller(0)
+	if _, err := tdb.ExecContext(context.Background(), "UPDATE users SET name = ?", "a"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+
+	attrs := map[string]string{}
+	for _, attr := range recorder.Ended()[0].Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if fn := attrs["code.function"]; fn != "github.com/Tracekit-Dev/go-sdk/tracekit_test.TestWithCallerLocation" {
+		t.Errorf("code.function = %q; want TestWithCallerLocation", fn)
+	}
+	if got, want := attrs["code.lineno"], strconv.Itoa(line+1); got != want {
+		t.Errorf("code.lineno = %s; want %s", got, want)
+	}
+	if file := attrs["code.filepath"]; !strings.HasSuffix(file, "caller_test.go") {
+		t.Errorf("code.filepath = %q; want caller_test.go", file)
+	}
+}
@@ -4,18 +4,21 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"reflect"
 	"regexp"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 )
 
 // PIIPattern represents a pattern for detecting and redacting sensitive data
@@ -36,6 +39,40 @@ type CaptureConfig struct {
 
 	// Circuit breaker config -- nil means use defaults (3 failures in 60s, 5min cooldown)
 	CircuitBreaker *CircuitBreakerConfig
+
+	// Maximum breakpoint poll interval while backing off after consecutive
+	// fetch failures (0 = 5 minutes)
+	MaxPollBackoff time.Duration
+
+	// Timeout for a single breakpoint poll request (0 = 10s)
+	PollTimeout time.Duration
+
+	// Maximum size of a captured stack trace in bytes; deeper stacks are
+	// truncated (0 = 32KB)
+	MaxStackBytes int
+
+	// Maximum length of a captured string variable in bytes (0 = 4096)
+	MaxStringLength int
+	// Maximum number of captured slice/array elements or map entries (0 = 100)
+	MaxCollectionLength int
+
+	// Upload snapshots in batches to /sdk/snapshots/capture/batch instead of
+	// one request each; falls back to single uploads if the backend lacks
+	// the batch endpoint (default: false)
+	BatchUploads bool
+	// Snapshots per batch upload (0 = 20)
+	BatchSize int
+	// Maximum delay before buffered snapshots are uploaded (0 = 2s)
+	BatchFlushInterval time.Duration
+
+	// Maximum snapshots captured per second across all breakpoints; captures
+	// over budget are dropped and counted (0 = unlimited)
+	MaxCapturesPerSecond float64
+
+	// Include every goroutine's stack after the capturing goroutine's, for
+	// when the interesting frame is elsewhere. Can be large; consider raising
+	// MaxStackBytes (default: false)
+	StackAllGoroutines bool
 }
 
 // CircuitBreakerConfig allows users to override circuit breaker thresholds.
@@ -55,6 +92,7 @@ type circuitBreaker struct {
 	state             string // "closed" or "open"
 	openedAt          time.Time
 	config            CircuitBreakerConfig
+	logger            *diagLogger
 }
 
 // newCircuitBreaker creates a circuit breaker with the given config (nil = defaults)
@@ -96,7 +134,7 @@ func (cb *circuitBreaker) ShouldAllow() bool {
 	if time.Since(cb.openedAt) >= cooldown {
 		cb.state = "closed"
 		cb.failureTimestamps = nil
-		log.Println("TraceKit: Code monitoring resumed")
+		cb.logger.infof("TraceKit: Code monitoring resumed")
 		return true
 	}
 
@@ -127,7 +165,7 @@ func (cb *circuitBreaker) RecordFailure() bool {
 	if len(cb.failureTimestamps) >= cb.config.MaxFailures && cb.state == "closed" {
 		cb.state = "open"
 		cb.openedAt = now
-		log.Printf("TraceKit: Code monitoring paused (%d capture failures in %ds). Auto-resumes in %d min.",
+		cb.logger.warnf("TraceKit: Code monitoring paused (%d capture failures in %ds). Auto-resumes in %d min.",
 			cb.config.MaxFailures,
 			cb.config.WindowMs/1000,
 			cb.config.CooldownMs/60000)
@@ -146,16 +184,43 @@ type SnapshotClient struct {
 	stopChan    chan struct{}
 	config      CaptureConfig
 
+	// stopCtx is cancelled by Stop so in-flight polls abort promptly
+	stopCtx    context.Context
+	stopCancel context.CancelFunc
+
 	// Pre-compiled PII patterns (built-in + custom), initialized once
 	piiPatterns       []PIIPattern
 	sensitiveNameExpr *regexp.Regexp
 
+	// Shared SDK redaction rules (nil when used standalone)
+	redactor *redactor
+
+	// Diagnostics logger (the SDK's when created by NewSDK)
+	logger *diagLogger
+
 	// Cache of active breakpoints
 	breakpointsCache  map[string]*BreakpointConfig
 	lastFetch         time.Time
 	registrationCache map[string]bool // Track registered locations
 	mu                sync.RWMutex    // Protects caches
 
+	// Breakpoint conditions whose evaluation error has been logged
+	conditionErrors sync.Map
+
+	// Batches snapshot uploads (nil = one request per snapshot)
+	batcher *snapshotBuffer
+
+	// Global capture rate limit (nil = unlimited) and captures it dropped.
+	// Replaced by SetCaptureConfig while captures may be running.
+	limiter         atomic.Pointer[rate.Limiter]
+	droppedCaptures atomic.Int64
+	// counter records SDK metrics (nil when used standalone)
+	counter func(name string, tags map[string]string) Counter
+
+	// Captures per breakpoint not yet reflected in its CaptureCount
+	// (capture key -> *atomic.Int64), so MaxCaptures holds between refreshes
+	localCaptures sync.Map
+
 	// Circuit breaker for snapshot HTTP calls
 	cb            *circuitBreaker
 	pendingEvents []map[string]interface{}
@@ -214,6 +279,8 @@ type Snapshot struct {
 	SpanID         string                 `json:"span_id,omitempty"`
 	RequestContext    map[string]interface{} `json:"request_context,omitempty"`
 	ExpressionResults map[string]interface{} `json:"expression_results,omitempty"`
+	Label             string                 `json:"label,omitempty"`               // Set by Capture
+	CaptureCount      int64                  `json:"local_capture_count,omitempty"` // Captures since the last breakpoint refresh
 	CapturedAt        time.Time              `json:"captured_at"`
 }
 
@@ -238,18 +305,22 @@ func defaultPIIPatterns() []PIIPattern {
 
 // NewSnapshotClient creates a new snapshot client with PII scrubbing enabled by default
 func NewSnapshotClient(apiKey, baseURL, serviceName string) *SnapshotClient {
+	stopCtx, stopCancel := context.WithCancel(context.Background())
 	c := &SnapshotClient{
 		apiKey:             apiKey,
 		baseURL:            baseURL,
 		serviceName:        serviceName,
 		client:             &http.Client{Timeout: 30 * time.Second},
 		stopChan:           make(chan struct{}),
+		stopCtx:            stopCtx,
+		stopCancel:         stopCancel,
 		breakpointsCache:   make(map[string]*BreakpointConfig),
 		registrationCache:  make(map[string]bool),
 		cb:                 newCircuitBreaker(nil),
 		normalPollInterval: 30 * time.Second,
 		killSwitchChan:     make(chan bool, 1),
 	}
+	c.setLogger(newDiagLogger())
 	c.initPIIPatterns()
 	return c
 }
@@ -257,12 +328,19 @@ func NewSnapshotClient(apiKey, baseURL, serviceName string) *SnapshotClient {
 // NewSnapshotClientWithConfig creates a new snapshot client with capture limits config
 func NewSnapshotClientWithConfig(apiKey, baseURL, serviceName string, config CaptureConfig) *SnapshotClient {
 	c := NewSnapshotClient(apiKey, baseURL, serviceName)
-	c.config = config
+	c.SetCaptureConfig(config)
 	c.cb = newCircuitBreaker(config.CircuitBreaker)
+	c.cb.logger = c.logger
 	c.initPIIPatterns() // Re-init to pick up custom patterns from config
 	return c
 }
 
+// setLogger routes the client's diagnostics (and its circuit breaker's) to logger
+func (c *SnapshotClient) setLogger(logger *diagLogger) {
+	c.logger = logger
+	c.cb.logger = logger
+}
+
 // initPIIPatterns compiles and caches all PII patterns (built-in + custom)
 func (c *SnapshotClient) initPIIPatterns() {
 	c.piiPatterns = defaultPIIPatterns()
@@ -280,68 +358,167 @@ func (c *SnapshotClient) initPIIPatterns() {
 // SetCaptureConfig updates the capture limit configuration
 func (c *SnapshotClient) SetCaptureConfig(config CaptureConfig) {
 	c.config = config
+
+	if c.batcher != nil {
+		c.batcher.shutdown()
+		c.batcher = nil
+	}
+	if config.BatchUploads {
+		c.batcher = newSnapshotBuffer(c, config.BatchSize, config.BatchFlushInterval)
+		c.batcher.start()
+	}
+
+	var limiter *rate.Limiter
+	if config.MaxCapturesPerSecond > 0 {
+		burst := int(config.MaxCapturesPerSecond)
+		if burst < 1 {
+			burst = 1
+		}
+		limiter = rate.NewLimiter(rate.Limit(config.MaxCapturesPerSecond), burst)
+	}
+	c.limiter.Store(limiter)
+}
+
+// claimCapture reserves one of bp's captures, then spends global capture
+// budget, so a capture refused by MaxCaptures costs no budget. It returns the
+// local capture count including this one.
+func (c *SnapshotClient) claimCapture(bp *BreakpointConfig) (int64, bool) {
+	captureCount, ok := c.reserveCapture(bp)
+	if !ok {
+		return 0, false
+	}
+	if !c.allowCapture() {
+		c.releaseCapture(bp)
+		return 0, false
+	}
+	return captureCount, true
+}
+
+// allowCapture applies the global capture rate limit, counting drops
+func (c *SnapshotClient) allowCapture() bool {
+	if limiter := c.limiter.Load(); limiter == nil || limiter.Allow() {
+		return true
+	}
+	c.droppedCaptures.Add(1)
+	if c.counter != nil {
+		c.counter("tracekit.snapshots.dropped", map[string]string{"reason": "rate_limit"}).Inc()
+	}
+	return false
+}
+
+// DroppedCaptures returns the number of captures dropped by
+// CaptureConfig.MaxCapturesPerSecond since the client was created
+func (c *SnapshotClient) DroppedCaptures() int64 {
+	return c.droppedCaptures.Load()
+}
+
+// SetPollInterval sets how often active breakpoints are polled (default 30s).
+// Call it before Start.
+func (c *SnapshotClient) SetPollInterval(interval time.Duration) {
+	if interval > 0 {
+		c.normalPollInterval = interval
+	}
 }
 
 // Start begins polling for active breakpoints
 func (c *SnapshotClient) Start() {
 	go c.pollBreakpoints()
-	log.Printf("📸 TraceKit Snapshot Client started for service: %s", c.serviceName)
+	c.logger.infof("📸 TraceKit Snapshot Client started for service: %s", c.serviceName)
 }
 
 // Stop stops the snapshot client
 func (c *SnapshotClient) Stop() {
 	close(c.stopChan)
+	c.stopCancel()
+	if c.batcher != nil {
+		c.batcher.shutdown()
+	}
 	if c.sseCancel != nil {
 		c.sseCancel()
 	}
-	log.Println("📸 TraceKit Snapshot Client stopped")
+	c.logger.infof("📸 TraceKit Snapshot Client stopped")
 }
 
-// pollBreakpoints periodically fetches active breakpoints from the backend
+// pollBreakpoints periodically fetches active breakpoints from the backend.
+// Consecutive fetch failures back off exponentially (capped at
+// CaptureConfig.MaxPollBackoff) and reset on the next successful fetch.
 func (c *SnapshotClient) pollBreakpoints() {
 	ticker := time.NewTicker(c.normalPollInterval)
 	defer ticker.Stop()
 
+	failures := 0
+	currentInterval := c.normalPollInterval
+
 	// Fetch immediately on startup
 	if err := c.fetchActiveBreakpoints(); err != nil {
-		log.Printf("⚠️  Failed to fetch initial breakpoints: %v", err)
+		c.logger.warnf("⚠️  Failed to fetch initial breakpoints: %v", err)
+		failures++
+	}
+
+	// resetTicker applies the interval for the current kill switch / failure state
+	resetTicker := func() {
+		if interval := c.pollInterval(failures); interval != currentInterval {
+			ticker.Reset(interval)
+			currentInterval = interval
+		}
 	}
+	resetTicker()
 
-	wasKilled := false
 	for {
 		select {
 		case <-c.stopChan:
 			return
-		case killed := <-c.killSwitchChan:
+		case <-c.killSwitchChan:
 			// Immediately adjust ticker when kill switch state changes via SSE
-			if killed && !wasKilled {
-				ticker.Reset(60 * time.Second)
-				wasKilled = true
-			} else if !killed && wasKilled {
-				ticker.Reset(c.normalPollInterval)
-				wasKilled = false
-			}
+			resetTicker()
 		case <-ticker.C:
 			// Skip polling when SSE is actively connected (SSE handles updates)
 			if c.sseActive {
 				continue
 			}
 			if err := c.fetchActiveBreakpoints(); err != nil {
-				log.Printf("⚠️  Failed to fetch breakpoints: %v", err)
+				if c.stopCtx.Err() != nil {
+					return // Stopped mid-poll
+				}
+				failures++
+				c.logger.warnf("⚠️  Failed to fetch breakpoints (attempt %d, next poll in %s): %v",
+					failures, c.pollInterval(failures), err)
+			} else if failures > 0 {
+				c.logger.infof("TraceKit: Breakpoint polling recovered after %d failed attempts", failures)
+				failures = 0
 			}
 
-			// Adjust poll interval when kill switch state changes (from poll response)
-			if c.killSwitchActive && !wasKilled {
-				ticker.Reset(60 * time.Second)
-				wasKilled = true
-			} else if !c.killSwitchActive && wasKilled {
-				ticker.Reset(c.normalPollInterval)
-				wasKilled = false
-			}
+			// Adjust poll interval when kill switch or failure state changes
+			resetTicker()
 		}
 	}
 }
 
+// pollInterval returns the breakpoint poll interval: the normal interval
+// (or 60s while kill-switched), doubled for each consecutive failure up to
+// the configured maximum backoff.
+func (c *SnapshotClient) pollInterval(failures int) time.Duration {
+	base := c.normalPollInterval
+	if c.killSwitchActive {
+		base = 60 * time.Second
+	}
+
+	maxBackoff := c.config.MaxPollBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 5 * time.Minute
+	}
+
+	interval := base
+	for i := 0; i < failures && interval < maxBackoff; i++ {
+		interval *= 2
+	}
+	if interval > maxBackoff && maxBackoff > base {
+		interval = maxBackoff
+	}
+
+	return interval
+}
+
 // fetchActiveBreakpoints retrieves active breakpoints from the backend.
 // If there are pending telemetry events, they are included in the request body.
 func (c *SnapshotClient) fetchActiveBreakpoints() error {
@@ -350,6 +527,13 @@ func (c *SnapshotClient) fetchActiveBreakpoints() error {
 	// Drain any pending telemetry events to piggyback on the poll
 	pendingEvents := c.drainPendingEvents()
 
+	timeout := c.config.PollTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(c.stopCtx, timeout)
+	defer cancel()
+
 	var req *http.Request
 	var err error
 
@@ -359,13 +543,13 @@ func (c *SnapshotClient) fetchActiveBreakpoints() error {
 			"events": pendingEvents,
 		}
 		body, _ := json.Marshal(payload)
-		req, err = http.NewRequest("POST", url, bytes.NewBuffer(body))
+		req, err = http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
 		if err != nil {
 			return err
 		}
 		req.Header.Set("Content-Type", "application/json")
 	} else {
-		req, err = http.NewRequest("GET", url, nil)
+		req, err = http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
 			return err
 		}
@@ -396,9 +580,9 @@ func (c *SnapshotClient) fetchActiveBreakpoints() error {
 	// Handle kill switch state (missing field = false for backward compat)
 	newKillState := result.KillSwitch != nil && *result.KillSwitch
 	if newKillState && !c.killSwitchActive {
-		log.Println("TraceKit: Code monitoring disabled by server kill switch. Polling at reduced frequency.")
+		c.logger.infof("TraceKit: Code monitoring disabled by server kill switch. Polling at reduced frequency.")
 	} else if !newKillState && c.killSwitchActive {
-		log.Println("TraceKit: Code monitoring re-enabled by server.")
+		c.logger.infof("TraceKit: Code monitoring re-enabled by server.")
 	}
 	c.killSwitchActive = newKillState
 
@@ -406,7 +590,7 @@ func (c *SnapshotClient) fetchActiveBreakpoints() error {
 	if c.killSwitchActive && c.sseActive && c.sseCancel != nil {
 		c.sseCancel()
 		c.sseActive = false
-		log.Println("TraceKit: SSE connection closed due to kill switch")
+		c.logger.infof("TraceKit: SSE connection closed due to kill switch")
 	}
 
 	// SSE auto-discovery: if sse_endpoint present and not already connected, start SSE
@@ -428,7 +612,7 @@ func (c *SnapshotClient) connectSSE(endpoint string) {
 	// Crash isolation: never let SSE bugs crash the host application
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("TraceKit: recovered from panic in connectSSE: %v", r)
+			c.logger.errorf("TraceKit: recovered from panic in connectSSE: %v", r)
 		}
 		c.sseActive = false
 	}()
@@ -441,7 +625,7 @@ func (c *SnapshotClient) connectSSE(endpoint string) {
 
 	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
-		log.Printf("TraceKit: SSE request creation failed: %v", err)
+		c.logger.warnf("TraceKit: SSE request creation failed: %v", err)
 		return
 	}
 	req.Header.Set("X-API-Key", c.apiKey)
@@ -451,18 +635,18 @@ func (c *SnapshotClient) connectSSE(endpoint string) {
 	sseClient := &http.Client{}
 	resp, err := sseClient.Do(req)
 	if err != nil {
-		log.Printf("TraceKit: SSE connection failed, falling back to polling: %v", err)
+		c.logger.warnf("TraceKit: SSE connection failed, falling back to polling: %v", err)
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("TraceKit: SSE endpoint returned %d, falling back to polling", resp.StatusCode)
+		c.logger.warnf("TraceKit: SSE endpoint returned %d, falling back to polling", resp.StatusCode)
 		return
 	}
 
 	c.sseActive = true
-	log.Println("TraceKit: SSE connection established for real-time breakpoint updates")
+	c.logger.infof("TraceKit: SSE connection established for real-time breakpoint updates")
 
 	scanner := bufio.NewScanner(resp.Body)
 	var eventType string
@@ -489,9 +673,9 @@ func (c *SnapshotClient) connectSSE(endpoint string) {
 	}
 
 	if err := scanner.Err(); err != nil {
-		log.Printf("TraceKit: SSE connection lost: %v, falling back to polling", err)
+		c.logger.warnf("TraceKit: SSE connection lost: %v, falling back to polling", err)
 	} else {
-		log.Println("TraceKit: SSE connection closed, falling back to polling")
+		c.logger.infof("TraceKit: SSE connection closed, falling back to polling")
 	}
 }
 
@@ -499,7 +683,7 @@ func (c *SnapshotClient) connectSSE(endpoint string) {
 func (c *SnapshotClient) handleSSEEvent(eventType string, data string) {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("TraceKit: recovered from panic handling SSE event %s: %v", eventType, r)
+			c.logger.errorf("TraceKit: recovered from panic handling SSE event %s: %v", eventType, r)
 		}
 	}()
 
@@ -510,7 +694,7 @@ func (c *SnapshotClient) handleSSEEvent(eventType string, data string) {
 			KillSwitch  bool               `json:"kill_switch"`
 		}
 		if err := json.Unmarshal([]byte(data), &initData); err != nil {
-			log.Printf("TraceKit: failed to parse SSE init event: %v", err)
+			c.logger.warnf("TraceKit: failed to parse SSE init event: %v", err)
 			return
 		}
 		c.updateBreakpointCache(initData.Breakpoints)
@@ -518,31 +702,23 @@ func (c *SnapshotClient) handleSSEEvent(eventType string, data string) {
 		if c.killSwitchActive && c.sseCancel != nil {
 			c.sseCancel()
 		}
-		log.Printf("TraceKit: SSE init received, %d breakpoints loaded", len(initData.Breakpoints))
+		c.logger.debugf("TraceKit: SSE init received, %d breakpoints loaded", len(initData.Breakpoints))
 
 	case "breakpoint_created", "breakpoint_updated":
 		var bp BreakpointConfig
 		if err := json.Unmarshal([]byte(data), &bp); err != nil {
-			log.Printf("TraceKit: failed to parse SSE %s event: %v", eventType, err)
+			c.logger.warnf("TraceKit: failed to parse SSE %s event: %v", eventType, err)
 			return
 		}
-		c.mu.Lock()
-		// Upsert by label key and line key
-		if bp.Label != "" && bp.FunctionName != "" {
-			labelKey := fmt.Sprintf("%s:%s", bp.FunctionName, bp.Label)
-			c.breakpointsCache[labelKey] = &bp
-		}
-		lineKey := fmt.Sprintf("%s:%d", bp.FilePath, bp.LineNumber)
-		c.breakpointsCache[lineKey] = &bp
-		c.mu.Unlock()
-		log.Printf("TraceKit: SSE breakpoint %s: %s", eventType, bp.ID)
+		c.upsertBreakpoint(&bp)
+		c.logger.debugf("TraceKit: SSE breakpoint %s: %s", eventType, bp.ID)
 
 	case "breakpoint_deleted":
 		var deleteData struct {
 			ID string `json:"id"`
 		}
 		if err := json.Unmarshal([]byte(data), &deleteData); err != nil {
-			log.Printf("TraceKit: failed to parse SSE breakpoint_deleted event: %v", err)
+			c.logger.warnf("TraceKit: failed to parse SSE breakpoint_deleted event: %v", err)
 			return
 		}
 		c.mu.Lock()
@@ -552,14 +728,14 @@ func (c *SnapshotClient) handleSSEEvent(eventType string, data string) {
 			}
 		}
 		c.mu.Unlock()
-		log.Printf("TraceKit: SSE breakpoint deleted: %s", deleteData.ID)
+		c.logger.debugf("TraceKit: SSE breakpoint deleted: %s", deleteData.ID)
 
 	case "kill_switch":
 		var ksData struct {
 			Enabled bool `json:"enabled"`
 		}
 		if err := json.Unmarshal([]byte(data), &ksData); err != nil {
-			log.Printf("TraceKit: failed to parse SSE kill_switch event: %v", err)
+			c.logger.warnf("TraceKit: failed to parse SSE kill_switch event: %v", err)
 			return
 		}
 		c.killSwitchActive = ksData.Enabled
@@ -569,7 +745,7 @@ func (c *SnapshotClient) handleSSEEvent(eventType string, data string) {
 		default:
 		}
 		if ksData.Enabled {
-			log.Println("TraceKit: Kill switch enabled via SSE, closing connection")
+			c.logger.infof("TraceKit: Kill switch enabled via SSE, closing connection")
 			if c.sseCancel != nil {
 				c.sseCancel()
 			}
@@ -579,7 +755,7 @@ func (c *SnapshotClient) handleSSEEvent(eventType string, data string) {
 		// No action needed -- heartbeat keeps connection alive, sdk_count is for dashboard UI
 
 	default:
-		log.Printf("TraceKit: unknown SSE event type: %s", eventType)
+		c.logger.debugf("TraceKit: unknown SSE event type: %s", eventType)
 	}
 }
 
@@ -592,6 +768,7 @@ func (c *SnapshotClient) updateBreakpointCache(breakpoints []BreakpointConfig) {
 
 	for i := range breakpoints {
 		bp := &breakpoints[i]
+		c.reconcileCaptures(c.breakpointsCache[fmt.Sprintf("%s:%d", bp.FilePath, bp.LineNumber)], bp)
 
 		// Primary key: function + label (stable)
 		if bp.Label != "" && bp.FunctionName != "" {
@@ -607,16 +784,187 @@ func (c *SnapshotClient) updateBreakpointCache(breakpoints []BreakpointConfig) {
 	c.breakpointsCache = newCache
 
 	if len(breakpoints) > 0 {
-		log.Printf("📸 Updated breakpoint cache: %d active breakpoints", len(breakpoints))
+		c.logger.debugf("📸 Updated breakpoint cache: %d active breakpoints", len(breakpoints))
+	}
+}
+
+// upsertBreakpoint adds or replaces bp in the cache under its label and line keys
+func (c *SnapshotClient) upsertBreakpoint(bp *BreakpointConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reconcileCaptures(c.breakpointsCache[fmt.Sprintf("%s:%d", bp.FilePath, bp.LineNumber)], bp)
+
+	if bp.Label != "" && bp.FunctionName != "" {
+		labelKey := fmt.Sprintf("%s:%s", bp.FunctionName, bp.Label)
+		c.breakpointsCache[labelKey] = bp
+	}
+	lineKey := fmt.Sprintf("%s:%d", bp.FilePath, bp.LineNumber)
+	c.breakpointsCache[lineKey] = bp
+}
+
+// captureKey identifies a breakpoint for local capture counting
+func captureKey(bp *BreakpointConfig) string {
+	if bp.ID != "" {
+		return bp.ID
+	}
+	return fmt.Sprintf("%s:%d", bp.FilePath, bp.LineNumber)
+}
+
+// localCaptureCounter returns bp's counter of captures since the last refresh
+func (c *SnapshotClient) localCaptureCounter(bp *BreakpointConfig) *atomic.Int64 {
+	counter, _ := c.localCaptures.LoadOrStore(captureKey(bp), new(atomic.Int64))
+	return counter.(*atomic.Int64)
+}
+
+// captureLimitReached reports whether bp has used all of its MaxCaptures,
+// counting local captures the backend hasn't reported yet
+func (c *SnapshotClient) captureLimitReached(bp *BreakpointConfig) bool {
+	if bp.MaxCaptures <= 0 {
+		return false
 	}
+	return int64(bp.CaptureCount)+c.localCaptureCounter(bp).Load() >= int64(bp.MaxCaptures)
 }
 
-// CheckAndCapture checks if there's an active breakpoint at this location and captures a snapshot
+// reserveCapture atomically claims a capture for bp, reporting false once
+// MaxCaptures is reached. It returns the local capture count including this one.
+func (c *SnapshotClient) reserveCapture(bp *BreakpointConfig) (int64, bool) {
+	counter := c.localCaptureCounter(bp)
+	for {
+		n := counter.Load()
+		if bp.MaxCaptures > 0 && int64(bp.CaptureCount)+n >= int64(bp.MaxCaptures) {
+			return n, false
+		}
+		if counter.CompareAndSwap(n, n+1) {
+			return n + 1, true
+		}
+	}
+}
+
+// releaseCapture returns a capture claimed by reserveCapture that was not taken
+func (c *SnapshotClient) releaseCapture(bp *BreakpointConfig) {
+	counter := c.localCaptureCounter(bp)
+	for {
+		n := counter.Load()
+		if n <= 0 || counter.CompareAndSwap(n, n-1) {
+			return
+		}
+	}
+}
+
+// reconcileCaptures adjusts the local capture count when a refreshed bp
+// replaces prev: captures the backend has since counted are removed, and a
+// lower backend count means it was reset, which clears the local count too
+func (c *SnapshotClient) reconcileCaptures(prev, bp *BreakpointConfig) {
+	if prev == nil || captureKey(prev) != captureKey(bp) {
+		return
+	}
+	counter := c.localCaptureCounter(bp)
+	if bp.CaptureCount < prev.CaptureCount {
+		counter.Store(0)
+		return
+	}
+	counted := int64(bp.CaptureCount - prev.CaptureCount)
+	for {
+		n := counter.Load()
+		remaining := n - counted
+		if remaining < 0 {
+			remaining = 0
+		}
+		if counter.CompareAndSwap(n, remaining) {
+			return
+		}
+	}
+}
+
+// RegisterBreakpoint declares a breakpoint from code: it posts config to the
+// backend and, once accepted, adds the stored breakpoint to the local cache
+// so it is active immediately. config needs a FilePath and LineNumber, or a
+// FunctionName and Label; ServiceName defaults to the client's service.
+func (c *SnapshotClient) RegisterBreakpoint(config BreakpointConfig) error {
+	if (config.FilePath == "" || config.LineNumber <= 0) && (config.FunctionName == "" || config.Label == "") {
+		return fmt.Errorf("breakpoint needs a file path and line number, or a function name and label")
+	}
+	if config.ServiceName == "" {
+		config.ServiceName = c.serviceName
+	}
+
+	body, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("marshal breakpoint failed: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/sdk/snapshots/breakpoints", c.baseURL)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", c.apiKey)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	// Prefer the stored breakpoint (it carries the server-assigned ID)
+	stored := config
+	if err := json.NewDecoder(resp.Body).Decode(&stored); err != nil {
+		stored = config
+	}
+	c.upsertBreakpoint(&stored)
+	return nil
+}
+
+// GetBreakpoint returns a copy of the active breakpoint at filePath:line,
+// without capturing anything. It reports false when there is none, or when it
+// has expired, reached its capture limit or monitoring is kill-switched. Use
+// it to skip gathering expensive variables when nothing would be captured.
+func (c *SnapshotClient) GetBreakpoint(filePath string, line int) (*BreakpointConfig, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.killSwitchActive {
+		return nil, false
+	}
+
+	bp, exists := c.breakpointsCache[fmt.Sprintf("%s:%d", filePath, line)]
+	if !exists {
+		return nil, false
+	}
+	if bp.ExpireAt != nil && time.Now().After(*bp.ExpireAt) {
+		return nil, false
+	}
+	if c.captureLimitReached(bp) {
+		return nil, false
+	}
+
+	bpCopy := *bp
+	return &bpCopy, true
+}
+
+// CheckAndCapture checks if there's an active breakpoint at this location and captures a snapshot.
+// The snapshot has no trace/span IDs; use CheckAndCaptureCtx to link it to the active trace.
 func (c *SnapshotClient) CheckAndCapture(filePath string, lineNumber int, variables map[string]interface{}) {
+	c.checkAndCapture(context.Background(), filePath, lineNumber, variables)
+}
+
+// CheckAndCaptureCtx is CheckAndCapture with the trace/span IDs and request
+// context of the active span in ctx attached to the snapshot
+func (c *SnapshotClient) CheckAndCaptureCtx(ctx context.Context, filePath string, lineNumber int, variables map[string]interface{}) {
+	c.checkAndCapture(ctx, filePath, lineNumber, variables)
+}
+
+func (c *SnapshotClient) checkAndCapture(ctx context.Context, filePath string, lineNumber int, variables map[string]interface{}) {
 	// Crash isolation: never let a TraceKit bug crash the host application
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("TraceKit: recovered from panic in CheckAndCapture: %v", r)
+			c.logger.errorf("TraceKit: recovered from panic in CheckAndCapture: %v", r)
 		}
 	}()
 
@@ -638,32 +986,37 @@ func (c *SnapshotClient) CheckAndCapture(filePath string, lineNumber int, variab
 		return
 	}
 
-	// Check if max captures reached
-	if bp.MaxCaptures > 0 && bp.CaptureCount >= bp.MaxCaptures {
+	// Check if max captures reached, counting captures since the last refresh
+	if c.captureLimitReached(bp) {
 		return
 	}
 
-	// Evaluate breakpoint condition locally for sdk-evaluable expressions
-	if bp.Condition != "" && bp.ConditionEval == "sdk-evaluable" {
-		result, err := EvaluateCondition(bp.Condition, variables)
-		if err != nil {
-			if errors.Is(err, ErrUnsupportedExpression) {
-				// Fall through to existing server CheckIn behavior
-				log.Printf("TraceKit: expression classified as sdk-evaluable but failed locally, falling back to server: %v", err)
-			} else {
-				// Other evaluation error, log and fall through to server
-				log.Printf("TraceKit: condition evaluation error, falling back to server: %v", err)
-			}
-		} else if !result {
-			// Condition evaluated to false, skip capture
-			return
-		}
-		// If result is true, proceed with capture
+	// Evaluate breakpoint condition locally; capture only matching states
+	if !c.conditionMatches(bp, variables) {
+		return
+	}
+
+	// Claim one of the breakpoint's captures (concurrent callers may have
+	// used the last), then stay within the global capture budget
+	captureCount, ok := c.claimCapture(bp)
+	if !ok {
+		return
+	}
+
+	// Extract trace/span IDs from OpenTelemetry context
+	traceID := ""
+	spanID := ""
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() && sc.IsSampled() {
+		traceID = sc.TraceID().String()
+		spanID = sc.SpanID().String()
 	}
 
 	// Logpoint mode: capture only expression results, skip locals/stack/request
 	if bp.Mode == "logpoint" {
 		snapshot := buildLogpointSnapshot(bp, c.serviceName, filePath, lineNumber, variables)
+		snapshot.TraceID = traceID
+		snapshot.SpanID = spanID
+		snapshot.CaptureCount = captureCount
 		go c.captureSnapshotWithLimits(snapshot, bp.MaxPayloadBytes)
 		return
 	}
@@ -672,35 +1025,90 @@ func (c *SnapshotClient) CheckAndCapture(filePath string, lineNumber int, variab
 	variables = c.applyCaptureConfigWithOverrides(variables, bp.MaxDepth, bp.MaxPayloadBytes)
 
 	// Capture stack trace with dynamic buffer and per-breakpoint depth
-	stackTrace := captureStackTraceWithDepth(bp.StackDepth)
+	stackTrace := c.captureStackTrace(bp.StackDepth)
 
 	// Scan variables for security issues
 	sanitizedVars, securityFlags := c.scanForSecurityIssues(variables)
 
 	// Create snapshot
 	snapshot := Snapshot{
-		BreakpointID:  bp.ID,
-		ServiceName:   c.serviceName,
-		FilePath:      filePath,
-		LineNumber:    lineNumber,
-		Variables:     sanitizedVars,
-		SecurityFlags: securityFlags,
-		StackTrace:    stackTrace,
-		CapturedAt:    time.Now(),
+		BreakpointID:   bp.ID,
+		ServiceName:    c.serviceName,
+		FilePath:       filePath,
+		LineNumber:     lineNumber,
+		Variables:      sanitizedVars,
+		SecurityFlags:  securityFlags,
+		StackTrace:     stackTrace,
+		TraceID:        traceID,
+		SpanID:         spanID,
+		RequestContext: c.extractRequestContext(ctx),
+		CaptureCount:   captureCount,
+		CapturedAt:     time.Now(),
 	}
 
 	// Send snapshot to backend (non-blocking)
 	go c.captureSnapshotWithLimits(snapshot, bp.MaxPayloadBytes)
 }
 
+// conditionMatches reports whether bp's condition holds for variables.
+// Conditions the server marked "server-only", or that aren't SDK-evaluable
+// when unclassified (e.g. registered with RegisterBreakpoint), are left to
+// the server and match. An invalid condition is logged once per breakpoint
+// and also matches, so a typo never silently disables capture.
+func (c *SnapshotClient) conditionMatches(bp *BreakpointConfig, variables map[string]interface{}) bool {
+	if bp.Condition == "" {
+		return true
+	}
+	switch bp.ConditionEval {
+	case "sdk-evaluable":
+	case "":
+		if !IsSDKEvaluable(bp.Condition) {
+			return true
+		}
+	default:
+		return true
+	}
+
+	result, err := EvaluateCondition(bp.Condition, variables)
+	if err != nil {
+		key := bp.ID + "|" + bp.Condition
+		if _, logged := c.conditionErrors.LoadOrStore(key, true); !logged {
+			if errors.Is(err, ErrUnsupportedExpression) {
+				c.logger.warnf("TraceKit: condition %q can't be evaluated locally, capturing unconditionally: %v", bp.Condition, err)
+			} else {
+				c.logger.warnf("TraceKit: invalid condition %q, capturing unconditionally: %v", bp.Condition, err)
+			}
+		}
+		return true
+	}
+	return result
+}
+
 // CheckAndCaptureWithContext checks and captures with trace context
 // It automatically registers the breakpoint location on first call
 // label: optional stable identifier for the checkpoint
 func (c *SnapshotClient) CheckAndCaptureWithContext(ctx context.Context, label string, variables map[string]interface{}) {
+	c.checkAndCaptureWithContext(ctx, label, nil, variables)
+}
+
+// CheckAndCaptureWithReceiver is CheckAndCaptureWithContext for methods: the
+// exported fields of self (the method receiver) are added to the snapshot as
+// "self.<Field>" variables. Nested structs become nested maps, so CaptureDepth
+// and PII scrubbing apply to them as to any other variable. The receiver is
+// only inspected when a breakpoint is active. Explicit variables win over
+// receiver fields of the same name.
+func (c *SnapshotClient) CheckAndCaptureWithReceiver(ctx context.Context, label string, self interface{}, variables map[string]interface{}) {
+	c.checkAndCaptureWithContext(ctx, label, self, variables)
+}
+
+// checkAndCaptureWithContext implements the CheckAndCaptureWith* methods.
+// It must be called directly from them, which in turn are called from the
+// SDK wrappers, so the application frame is at a fixed depth.
+func (c *SnapshotClient) checkAndCaptureWithContext(ctx context.Context, label string, self interface{}, variables map[string]interface{}) {
 	// Crash isolation: never let a TraceKit bug crash the host application
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("TraceKit: recovered from panic in CheckAndCaptureWithContext: %v", r)
+			c.logger.errorf("TraceKit: recovered from panic in CheckAndCaptureWithContext: %v", r)
 		}
 	}()
 
@@ -717,8 +1125,8 @@ func (c *SnapshotClient) CheckAndCaptureWithContext(ctx context.Context, label s
 	}
 
 	// Get caller information automatically
-	// Skip 2 frames: this function + SDK wrapper (config.go)
-	pc, file, line, ok := runtime.Caller(2)
+	// Skip 3 frames: this function + public method + SDK wrapper (config.go)
+	pc, file, line, ok := runtime.Caller(3)
 	if !ok {
 		return
 	}
@@ -761,26 +1169,18 @@ func (c *SnapshotClient) CheckAndCaptureWithContext(ctx context.Context, label s
 		return
 	}
 
-	// Check if max captures reached
-	if bp.MaxCaptures > 0 && bp.CaptureCount >= bp.MaxCaptures {
+	// Check if max captures reached, counting captures since the last refresh
+	if c.captureLimitReached(bp) {
 		return
 	}
 
-	// Evaluate breakpoint condition locally for sdk-evaluable expressions
-	if bp.Condition != "" && bp.ConditionEval == "sdk-evaluable" {
-		// Build evaluation env from variables and request context
-		evalEnv := variables
-		result, err := EvaluateCondition(bp.Condition, evalEnv)
-		if err != nil {
-			if errors.Is(err, ErrUnsupportedExpression) {
-				log.Printf("TraceKit: expression classified as sdk-evaluable but failed locally, falling back to server: %v", err)
-			} else {
-				log.Printf("TraceKit: condition evaluation error, falling back to server: %v", err)
-			}
-		} else if !result {
-			// Condition evaluated to false, skip capture
-			return
-		}
+	if self != nil {
+		variables = c.withReceiverVariables(variables, self)
+	}
+
+	// Evaluate breakpoint condition locally; capture only matching states
+	if !c.conditionMatches(bp, variables) {
+		return
 	}
 
 	// Extract trace/span IDs from OpenTelemetry context
@@ -796,23 +1196,31 @@ func (c *SnapshotClient) CheckAndCaptureWithContext(ctx context.Context, label s
 	if c.config.CaptureTimeout > 0 {
 		select {
 		case <-ctx.Done():
-			log.Printf("TraceKit: capture timeout exceeded, skipping snapshot")
+			c.logger.warnf("TraceKit: capture timeout exceeded, skipping snapshot")
 			return
 		default:
 		}
 	}
 
+	// Claim one of the breakpoint's captures (concurrent callers may have
+	// used the last), then stay within the global capture budget
+	captureCount, ok := c.claimCapture(bp)
+	if !ok {
+		return
+	}
+
 	// Logpoint mode: capture only expression results, skip locals/stack/request
 	if bp.Mode == "logpoint" {
 		snapshot := buildLogpointSnapshot(bp, c.serviceName, file, line, variables)
 		snapshot.TraceID = traceID
 		snapshot.SpanID = spanID
+		snapshot.CaptureCount = captureCount
 		go c.captureSnapshotWithLimits(snapshot, bp.MaxPayloadBytes)
 		return
 	}
 
 	// Capture stack trace with dynamic buffer and per-breakpoint depth
-	stackTrace := captureStackTraceWithDepth(bp.StackDepth)
+	stackTrace := c.captureStackTrace(bp.StackDepth)
 
 	// Extract HTTP request context if available
 	requestContext := c.extractRequestContext(ctx)
@@ -835,6 +1243,7 @@ func (c *SnapshotClient) CheckAndCaptureWithContext(ctx context.Context, label s
 		TraceID:        traceID,
 		SpanID:         spanID,
 		RequestContext: requestContext,
+		CaptureCount:   captureCount,
 		CapturedAt:     time.Now(),
 	}
 
@@ -842,6 +1251,127 @@ func (c *SnapshotClient) CheckAndCaptureWithContext(ctx context.Context, label s
 	go c.captureSnapshotWithLimits(snapshot, bp.MaxPayloadBytes)
 }
 
+// Capture unconditionally captures a snapshot of variables at the call site,
+// tagged with label, whether or not a breakpoint is registered there. Use it
+// for ad-hoc debugging of rare paths such as an unexpected error branch. The
+// snapshot carries the trace and span IDs from ctx, a stack trace and the
+// request context, and goes through the usual capture limits and PII
+// scrubbing. Nothing is captured while the server kill switch is active.
+func (c *SnapshotClient) Capture(ctx context.Context, label string, variables map[string]interface{}) {
+	c.capture(ctx, label, variables)
+}
+
+// capture implements Capture. Like checkAndCaptureWithContext it must be
+// called directly from Capture, which is called from the SDK wrapper.
+func (c *SnapshotClient) capture(ctx context.Context, label string, variables map[string]interface{}) {
+	// Crash isolation: never let a TraceKit bug crash the host application
+	defer func() {
+		if r := recover(); r != nil {
+			c.logger.errorf("TraceKit: recovered from panic in Capture: %v", r)
+		}
+	}()
+
+	if c.killSwitchActive || !c.allowCapture() {
+		return
+	}
+
+	// Skip 3 frames: this function + public method + SDK wrapper (config.go)
+	_, file, line, ok := runtime.Caller(3)
+	if !ok {
+		return
+	}
+
+	traceID := ""
+	spanID := ""
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() && sc.IsSampled() {
+		traceID = sc.TraceID().String()
+		spanID = sc.SpanID().String()
+	}
+
+	variables = c.applyCaptureConfig(variables)
+	sanitizedVars, securityFlags := c.scanForSecurityIssues(variables)
+
+	snapshot := Snapshot{
+		Label:          label,
+		ServiceName:    c.serviceName,
+		FilePath:       file,
+		LineNumber:     line,
+		Variables:      sanitizedVars,
+		SecurityFlags:  securityFlags,
+		StackTrace:     c.captureStackTrace(nil),
+		TraceID:        traceID,
+		SpanID:         spanID,
+		RequestContext: c.extractRequestContext(ctx),
+		CapturedAt:     time.Now(),
+	}
+
+	// Send snapshot to backend (non-blocking)
+	go c.captureSnapshot(snapshot)
+}
+
+// withReceiverVariables returns a copy of variables with the exported fields
+// of self added as "self.<Field>" (existing keys are kept)
+func (c *SnapshotClient) withReceiverVariables(variables map[string]interface{}, self interface{}) map[string]interface{} {
+	maxDepth := c.config.CaptureDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultReceiverDepth
+	}
+
+	merged := make(map[string]interface{}, len(variables))
+	flat := structToMap(reflect.ValueOf(self), maxDepth)
+	fields, ok := flat.(map[string]interface{})
+	if !ok {
+		merged["self"] = flat
+	}
+	for name, value := range fields {
+		merged["self."+name] = value
+	}
+	for k, v := range variables {
+		merged[k] = v
+	}
+	return merged
+}
+
+// defaultReceiverDepth bounds receiver flattening when CaptureDepth is unset,
+// guarding against pointer cycles
+const defaultReceiverDepth = 10
+
+// structToMap converts a struct (or pointer to one) into a map of its
+// exported fields, recursing into nested structs up to depth levels. Other
+// values, and structs that marshal themselves (e.g. time.Time), are returned
+// as-is.
+func structToMap(v reflect.Value, depth int) interface{} {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() != reflect.Struct {
+		return v.Interface()
+	}
+	switch v.Interface().(type) {
+	case json.Marshaler, encoding.TextMarshaler:
+		return v.Interface()
+	}
+	if depth <= 0 {
+		return map[string]interface{}{"_truncated": true}
+	}
+
+	t := v.Type()
+	fields := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+		fields[t.Field(i).Name] = structToMap(v.Field(i), depth-1)
+	}
+	return fields
+}
+
 // autoRegisterBreakpoint automatically creates or updates a breakpoint
 func (c *SnapshotClient) autoRegisterBreakpoint(file string, line int, funcName string, label string) {
 	// Use label as primary key for registration tracking
@@ -891,27 +1421,28 @@ func (c *SnapshotClient) autoRegisterBreakpoint(file string, line int, funcName
 	}()
 }
 
-// captureSnapshot sends the snapshot to the backend
+// captureSnapshot sends the snapshot to the backend, through the batch
+// buffer when CaptureConfig.BatchUploads is enabled
 func (c *SnapshotClient) captureSnapshot(snapshot Snapshot) {
 	// Crash isolation for async capture goroutine
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("TraceKit: recovered from panic in captureSnapshot: %v", r)
+			c.logger.errorf("TraceKit: recovered from panic in captureSnapshot: %v", r)
 		}
 	}()
 
-	// Circuit breaker check: skip if circuit is open
-	if !c.cb.ShouldAllow() {
+	if c.batcher != nil && c.batcher.add(snapshot) {
 		return
 	}
+	c.sendSnapshot(snapshot)
+}
 
-	url := fmt.Sprintf("%s/sdk/snapshots/capture", c.baseURL)
-
+// encodeSnapshot serializes snapshot, replacing its variables with a
+// truncation marker when it exceeds CaptureConfig.MaxPayload
+func (c *SnapshotClient) encodeSnapshot(snapshot Snapshot) ([]byte, error) {
 	body, err := c.safeSerialize(snapshot)
 	if err != nil {
-		// Serialization error -- do NOT count as HTTP failure
-		log.Printf("TraceKit: failed to marshal snapshot: %v", err)
-		return
+		return nil, fmt.Errorf("failed to marshal snapshot: %w", err)
 	}
 
 	// Apply max payload limit if configured
@@ -924,14 +1455,31 @@ func (c *SnapshotClient) captureSnapshot(snapshot Snapshot) {
 		}
 		body, err = json.Marshal(snapshot)
 		if err != nil {
-			log.Printf("TraceKit: failed to marshal truncated snapshot: %v", err)
-			return
+			return nil, fmt.Errorf("failed to marshal truncated snapshot: %w", err)
 		}
 	}
+	return body, nil
+}
+
+// sendSnapshot posts a single snapshot to the backend
+func (c *SnapshotClient) sendSnapshot(snapshot Snapshot) {
+	// Circuit breaker check: skip if circuit is open
+	if !c.cb.ShouldAllow() {
+		return
+	}
+
+	url := fmt.Sprintf("%s/sdk/snapshots/capture", c.baseURL)
+
+	body, err := c.encodeSnapshot(snapshot)
+	if err != nil {
+		// Serialization error -- do NOT count as HTTP failure
+		c.logger.errorf("TraceKit: %v", err)
+		return
+	}
 
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
 	if err != nil {
-		log.Printf("⚠️  Failed to create snapshot request: %v", err)
+		c.logger.warnf("⚠️  Failed to create snapshot request: %v", err)
 		return
 	}
 
@@ -941,7 +1489,7 @@ func (c *SnapshotClient) captureSnapshot(snapshot Snapshot) {
 	resp, err := c.client.Do(req)
 	if err != nil {
 		// Network/connection error -- count as HTTP failure for circuit breaker
-		log.Printf("⚠️  Failed to send snapshot: %v", err)
+		c.logger.warnf("⚠️  Failed to send snapshot: %v", err)
 		if tripped := c.cb.RecordFailure(); tripped {
 			c.queueCircuitBreakerEvent()
 		}
@@ -951,7 +1499,7 @@ func (c *SnapshotClient) captureSnapshot(snapshot Snapshot) {
 
 	if resp.StatusCode >= 500 {
 		// Server error -- count as HTTP failure for circuit breaker
-		log.Printf("⚠️  Failed to capture snapshot: status %d", resp.StatusCode)
+		c.logger.warnf("⚠️  Failed to capture snapshot: status %d", resp.StatusCode)
 		if tripped := c.cb.RecordFailure(); tripped {
 			c.queueCircuitBreakerEvent()
 		}
@@ -960,11 +1508,11 @@ func (c *SnapshotClient) captureSnapshot(snapshot Snapshot) {
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		// Client error (4xx) -- do NOT count as circuit breaker failure
-		log.Printf("⚠️  Failed to capture snapshot: status %d", resp.StatusCode)
+		c.logger.warnf("⚠️  Failed to capture snapshot: status %d", resp.StatusCode)
 		return
 	}
 
-	log.Printf("📸 Snapshot captured: %s:%d", snapshot.FilePath, snapshot.LineNumber)
+	c.logger.debugf("📸 Snapshot captured: %s:%d", snapshot.FilePath, snapshot.LineNumber)
 }
 
 // captureSnapshotWithLimits applies per-breakpoint payload limits before sending.
@@ -1033,6 +1581,17 @@ func (c *SnapshotClient) scanForSecurityIssues(variables map[string]interface{})
 	sanitized := make(map[string]interface{})
 
 	for name, value := range variables {
+		// Check the SDK-wide redaction denylist first
+		if c.redactor.isDenied(name) {
+			securityFlags = append(securityFlags, SecurityFlag{
+				Type:     "redacted_by_config",
+				Severity: "medium",
+				Variable: name,
+			})
+			sanitized[name] = c.redactor.marker
+			continue
+		}
+
 		// Check variable name for sensitive keywords (word-boundary matching)
 		if c.sensitiveNameExpr.MatchString(name) {
 			securityFlags = append(securityFlags, SecurityFlag{
@@ -1066,6 +1625,9 @@ func (c *SnapshotClient) scanForSecurityIssues(variables map[string]interface{})
 		}
 
 		if !flagged {
+			if str, ok := value.(string); ok {
+				value = c.redactor.redact(name, str)
+			}
 			sanitized[name] = value
 		}
 	}
@@ -1077,7 +1639,7 @@ func (c *SnapshotClient) scanForSecurityIssues(variables map[string]interface{})
 func (c *SnapshotClient) safeSerialize(v interface{}) (result []byte, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("TraceKit: recovered from panic during serialization: %v", r)
+			c.logger.errorf("TraceKit: recovered from panic during serialization: %v", r)
 			// Fallback: serialize a minimal representation
 			result = []byte(fmt.Sprintf(`{"_error":"serialization panic: %v"}`, r))
 			err = nil
@@ -1086,9 +1648,10 @@ func (c *SnapshotClient) safeSerialize(v interface{}) (result []byte, err error)
 	return json.Marshal(v)
 }
 
-// applyCaptureConfig applies opt-in capture limits to variables.
-// Returns the original variables unchanged if no limits are configured (default).
+// applyCaptureConfig makes variables JSON-safe (see variableSanitizer) and
+// applies opt-in capture limits.
 func (c *SnapshotClient) applyCaptureConfig(variables map[string]interface{}) map[string]interface{} {
+	variables = c.sanitizeVariables(variables, c.config.CaptureDepth)
 	if c.config.CaptureDepth <= 0 {
 		return variables // No depth limit (default)
 	}
@@ -1143,14 +1706,38 @@ func (c *SnapshotClient) limitDepthSlice(data []interface{}, currentDepth int) i
 	return result
 }
 
-// captureStackTraceWithDepth captures a stack trace using a dynamically-growing buffer.
-// It starts at 8KB and doubles up to 32KB. If maxDepth is non-nil, it limits the
-// number of captured frames (each frame = function line + file:line line).
+// defaultMaxStackBytes caps stack trace capture when CaptureConfig.MaxStackBytes is unset
+const defaultMaxStackBytes = 32 * 1024 // 32KB safety cap (T-161-04 mitigation)
+
+// captureStackTrace captures a stack trace with the client's stack settings
+func (c *SnapshotClient) captureStackTrace(maxDepth *int) string {
+	return captureStackTraceWithOptions(maxDepth, c.config.MaxStackBytes, c.config.StackAllGoroutines)
+}
+
+// captureStackTraceWithDepth captures the current goroutine's stack trace
+// with the default size cap. If maxDepth is non-nil, it limits the number of
+// captured frames (each frame = function line + file:line line).
 func captureStackTraceWithDepth(maxDepth *int) string {
-	const maxBuf = 32 * 1024 // 32KB safety cap (T-161-04 mitigation)
-	buf := make([]byte, 8*1024)
+	return captureStackTraceWithOptions(maxDepth, 0, false)
+}
+
+// captureStackTraceWithOptions captures a stack trace using a dynamically-growing
+// buffer. It starts at 8KB and doubles up to maxBytes (0 = 32KB). With
+// allGoroutines, every goroutine's stack is included after the current one;
+// maxDepth then limits only the current goroutine's frames.
+func captureStackTraceWithOptions(maxDepth *int, maxBytes int, allGoroutines bool) string {
+	maxBuf := maxBytes
+	if maxBuf <= 0 {
+		maxBuf = defaultMaxStackBytes
+	}
+	size := 8 * 1024
+	if size > maxBuf {
+		size = maxBuf
+	}
+
+	buf := make([]byte, size)
 	for {
-		n := runtime.Stack(buf, false)
+		n := runtime.Stack(buf, allGoroutines)
 		if n < len(buf) {
 			buf = buf[:n]
 			break
@@ -1173,6 +1760,9 @@ func captureStackTraceWithDepth(maxDepth *int) string {
 		return stackTrace
 	}
 
+	// runtime.Stack separates goroutines with a blank line; the current one is first
+	current, others, hasOthers := strings.Cut(stackTrace, "\n\n")
+
 	// Limit frame count. runtime.Stack format:
 	// Line 0: "goroutine N [running]:"
 	// Line 1: function name
@@ -1180,7 +1770,7 @@ func captureStackTraceWithDepth(maxDepth *int) string {
 	// Line 3: function name
 	// Line 4: file:line +offset
 	// ... (2 lines per frame)
-	lines := strings.Split(stackTrace, "\n")
+	lines := strings.Split(current, "\n")
 	if len(lines) < 2 {
 		return stackTrace
 	}
@@ -1191,11 +1781,16 @@ func captureStackTraceWithDepth(maxDepth *int) string {
 		return stackTrace
 	}
 
-	return strings.Join(lines[:maxLines], "\n")
+	limited := strings.Join(lines[:maxLines], "\n")
+	if hasOthers {
+		limited += "\n\n" + others
+	}
+	return limited
 }
 
-// applyCaptureConfigWithOverrides applies capture depth limits, preferring per-breakpoint
-// overrides over SDK-level config. bpMaxDepth overrides c.config.CaptureDepth.
+// applyCaptureConfigWithOverrides makes variables JSON-safe and applies capture depth
+// limits, preferring per-breakpoint overrides over SDK-level config. bpMaxDepth
+// overrides c.config.CaptureDepth.
 func (c *SnapshotClient) applyCaptureConfigWithOverrides(variables map[string]interface{}, bpMaxDepth *int, bpMaxPayloadBytes *int) map[string]interface{} {
 	depthLimit := 0
 
@@ -1205,6 +1800,7 @@ func (c *SnapshotClient) applyCaptureConfigWithOverrides(variables map[string]in
 		depthLimit = c.config.CaptureDepth
 	}
 
+	variables = c.sanitizeVariables(variables, depthLimit)
 	if depthLimit <= 0 {
 		return variables // No depth limit
 	}
@@ -1,10 +1,20 @@
 package tracekit
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 func intPtr(n int) *int { return &n }
@@ -157,6 +167,30 @@ func TestDynamicStackBuffer(t *testing.T) {
 	}
 }
 
+// TestStackTraceOptions verifies the configurable size cap and that all
+// goroutines are included on request
+func TestStackTraceOptions(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	go func() { <-release }()
+
+	deep := deepCallStack(30, func() string {
+		return captureStackTraceWithOptions(nil, 2048, false)
+	})
+	if len(deep) > 2048 {
+		t.Errorf("stack trace is %d bytes; want at most 2048", len(deep))
+	}
+
+	all := captureStackTraceWithOptions(intPtr(1), 0, true)
+	if n := strings.Count(all, "goroutine "); n < 2 {
+		t.Errorf("stack trace has %d goroutines; want all of them", n)
+	}
+	current, _, _ := strings.Cut(all, "\n\n")
+	if lines := strings.Count(current, "\n") + 1; lines != 3 {
+		t.Errorf("current goroutine has %d lines; want header and one frame", lines)
+	}
+}
+
 // TestStackDepthLimit verifies per-breakpoint StackDepth limits frames
 func TestStackDepthLimit(t *testing.T) {
 	// Capture with depth limit of 5
@@ -377,3 +411,355 @@ func TestLogpointCaptureFlow(t *testing.T) {
 		t.Errorf("expected LineNumber=99, got %d", snapshot.LineNumber)
 	}
 }
+
+// TestPollIntervalBackoff verifies poll interval doubles on failures and is capped
+func TestPollIntervalBackoff(t *testing.T) {
+	client := NewSnapshotClientWithConfig("test-key", "http://localhost", "test-service", CaptureConfig{
+		MaxPollBackoff: 2 * time.Minute,
+	})
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 0, want: 30 * time.Second},
+		{failures: 1, want: 60 * time.Second},
+		{failures: 2, want: 2 * time.Minute},
+		{failures: 10, want: 2 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := client.pollInterval(tt.failures); got != tt.want {
+			t.Errorf("pollInterval(%d) = %s; want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+// TestPollStopAndInterval verifies Stop aborts an in-flight poll and
+// SetPollInterval replaces the default interval
+func TestPollStopAndInterval(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	client := NewSnapshotClient("test-key", server.URL, "test-service")
+	client.SetPollInterval(5 * time.Second)
+	if got := client.pollInterval(0); got != 5*time.Second {
+		t.Errorf("pollInterval(0) = %s; want 5s", got)
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- client.fetchActiveBreakpoints() }()
+	time.Sleep(50 * time.Millisecond)
+	client.Stop()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("fetchActiveBreakpoints() after Stop = %v; want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("in-flight poll not cancelled by Stop")
+	}
+}
+
+// TestGetBreakpoint verifies lookups report only active breakpoints
+func TestGetBreakpoint(t *testing.T) {
+	client := NewSnapshotClient("test-key", "http://localhost", "test-service")
+	past := time.Now().Add(-time.Minute)
+	client.updateBreakpointCache([]BreakpointConfig{
+		{ID: "active", FilePath: "handler.go", LineNumber: 10, Enabled: true},
+		{ID: "expired", FilePath: "handler.go", LineNumber: 20, Enabled: true, ExpireAt: &past},
+		{ID: "exhausted", FilePath: "handler.go", LineNumber: 30, Enabled: true, MaxCaptures: 1, CaptureCount: 1},
+	})
+
+	tests := []struct {
+		line   int
+		wantID string
+	}{
+		{line: 10, wantID: "active"},
+		{line: 20},
+		{line: 30},
+		{line: 40},
+	}
+	for _, tt := range tests {
+		bp, ok := client.GetBreakpoint("handler.go", tt.line)
+		if ok != (tt.wantID != "") {
+			t.Errorf("line %d: found = %v; want %v", tt.line, ok, tt.wantID != "")
+			continue
+		}
+		if ok && bp.ID != tt.wantID {
+			t.Errorf("line %d: ID = %q; want %q", tt.line, bp.ID, tt.wantID)
+		}
+	}
+}
+
+// TestRegisterBreakpoint verifies registered breakpoints are posted to the
+// backend and become active locally with the server-assigned ID
+func TestRegisterBreakpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sdk/snapshots/breakpoints" {
+			http.NotFound(w, r)
+			return
+		}
+		var bp BreakpointConfig
+		json.NewDecoder(r.Body).Decode(&bp)
+		bp.ID = "bp-registered"
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(bp)
+	}))
+	defer server.Close()
+
+	client := NewSnapshotClient("test-key", server.URL, "test-service")
+	if err := client.RegisterBreakpoint(BreakpointConfig{FilePath: "billing.go", LineNumber: 88, Enabled: true}); err != nil {
+		t.Fatalf("RegisterBreakpoint() error = %v", err)
+	}
+
+	bp, ok := client.GetBreakpoint("billing.go", 88)
+	if !ok {
+		t.Fatal("registered breakpoint is not active")
+	}
+	if bp.ID != "bp-registered" || bp.ServiceName != "test-service" {
+		t.Errorf("breakpoint = {ID: %q, ServiceName: %q}; want server ID and client service", bp.ID, bp.ServiceName)
+	}
+
+	if err := client.RegisterBreakpoint(BreakpointConfig{}); err == nil {
+		t.Error("expected an error for a breakpoint without a location")
+	}
+}
+
+// TestWithReceiverVariables verifies exported receiver fields are flattened
+// into the snapshot variables without overriding explicit ones
+func TestWithReceiverVariables(t *testing.T) {
+	type limits struct{ MaxRetries int }
+	type service struct {
+		Name     string
+		Limits   limits
+		Started  time.Time
+		internal string
+	}
+	started := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	svc := &service{Name: "billing", Limits: limits{MaxRetries: 3}, Started: started, internal: "hidden"}
+
+	client := NewSnapshotClient("test-key", "http://localhost", "test-service")
+	vars := client.withReceiverVariables(map[string]interface{}{"self.Name": "explicit"}, svc)
+
+	if vars["self.Name"] != "explicit" {
+		t.Errorf("self.Name = %v; want explicit variable to win", vars["self.Name"])
+	}
+	if nested, ok := vars["self.Limits"].(map[string]interface{}); !ok || nested["MaxRetries"] != 3 {
+		t.Errorf("self.Limits = %v; want map with MaxRetries 3", vars["self.Limits"])
+	}
+	if vars["self.Started"] != started {
+		t.Errorf("self.Started = %v; want time value kept as-is", vars["self.Started"])
+	}
+	if _, ok := vars["self.internal"]; ok {
+		t.Error("unexported field was captured")
+	}
+}
+
+// TestCapture verifies Capture sends a labelled snapshot of the call site
+// without a registered breakpoint
+func TestCapture(t *testing.T) {
+	received := make(chan Snapshot, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sdk/snapshots/capture" {
+			http.NotFound(w, r)
+			return
+		}
+		var snapshot Snapshot
+		json.NewDecoder(r.Body).Decode(&snapshot)
+		received <- snapshot
+	}))
+	defer server.Close()
+
+	sdk := &SDK{snapshotClient: NewSnapshotClient("test-key", server.URL, "test-service")}
+
+	_, _, line, _ := runtime.Caller(0)
+	sdk.Capture(context.Background(), "ledger-mismatch", map[string]interface{}{"order_id": "o-1"})
+
+	select {
+	case snapshot := <-received:
+		if snapshot.Label != "ledger-mismatch" {
+			t.Errorf("Label = %q; want %q", snapshot.Label, "ledger-mismatch")
+		}
+		if !strings.HasSuffix(snapshot.FilePath, "client_test.go") || snapshot.LineNumber != line+1 {
+			t.Errorf("location = %s:%d; want client_test.go:%d", snapshot.FilePath, snapshot.LineNumber, line+1)
+		}
+		if snapshot.Variables["order_id"] != "o-1" {
+			t.Errorf("order_id = %v; want o-1", snapshot.Variables["order_id"])
+		}
+		if snapshot.StackTrace == "" {
+			t.Error("snapshot has no stack trace")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("snapshot not sent")
+	}
+}
+
+// TestCheckAndCaptureCtx verifies file/line captures carry the trace and span IDs from ctx
+func TestCheckAndCaptureCtx(t *testing.T) {
+	received := make(chan Snapshot, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var snapshot Snapshot
+		json.NewDecoder(r.Body).Decode(&snapshot)
+		received <- snapshot
+	}))
+	defer server.Close()
+
+	client := NewSnapshotClient("test-key", server.URL, "test-service")
+	client.updateBreakpointCache([]BreakpointConfig{
+		{ID: "bp-1", FilePath: "orders.go", LineNumber: 42, Enabled: true},
+	})
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	client.CheckAndCaptureCtx(ctx, "orders.go", 42, map[string]interface{}{"order_id": "o-1"})
+
+	select {
+	case snapshot := <-received:
+		if snapshot.TraceID != sc.TraceID().String() || snapshot.SpanID != sc.SpanID().String() {
+			t.Errorf("IDs = %s/%s; want %s/%s", snapshot.TraceID, snapshot.SpanID, sc.TraceID(), sc.SpanID())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("snapshot not sent")
+	}
+}
+
+// TestConditionMatches verifies breakpoint conditions gate capture, with
+// server-side and invalid conditions defaulting to capture
+func TestConditionMatches(t *testing.T) {
+	client := NewSnapshotClient("test-key", "http://localhost", "test-service")
+	vars := map[string]interface{}{"amount": 1500, "status": "failed"}
+
+	tests := []struct {
+		condition string
+		eval      string
+		want      bool
+	}{
+		{condition: "", want: true},
+		{condition: "amount > 1000", want: true},
+		{condition: "amount > 2000", want: false},
+		{condition: `status == "failed" && amount > 1000`, want: true},
+		{condition: `status == "ok" || amount < 100`, want: false},
+		{condition: "amount > 2000", eval: "sdk-evaluable", want: false},
+		{condition: "amount > 2000", eval: "server-only", want: true},
+		{condition: "len(status) > 100", want: true},
+		{condition: "amount >", want: true},
+	}
+
+	for _, tt := range tests {
+		bp := &BreakpointConfig{ID: "bp-1", Condition: tt.condition, ConditionEval: tt.eval}
+		if got := client.conditionMatches(bp, vars); got != tt.want {
+			t.Errorf("conditionMatches(%q, %q) = %v; want %v", tt.condition, tt.eval, got, tt.want)
+		}
+	}
+}
+
+// TestMaxCapturesBetweenRefreshes verifies MaxCaptures holds under concurrent
+// captures before the backend reports them, and a backend reset re-arms it
+func TestMaxCapturesBetweenRefreshes(t *testing.T) {
+	client := NewSnapshotClient("test-key", "http://localhost", "test-service")
+	bp := BreakpointConfig{ID: "bp-limited", FilePath: "orders.go", LineNumber: 12, Enabled: true, MaxCaptures: 5}
+	client.updateBreakpointCache([]BreakpointConfig{bp})
+	cached := client.breakpointsCache["orders.go:12"]
+
+	var wg sync.WaitGroup
+	var captured atomic.Int64
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := client.reserveCapture(cached); ok {
+				captured.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := captured.Load(); got != 5 {
+		t.Fatalf("reserved %d captures; want 5", got)
+	}
+	if _, ok := client.GetBreakpoint("orders.go", 12); ok {
+		t.Error("GetBreakpoint reports an exhausted breakpoint as active")
+	}
+
+	// The backend has counted 3 of the 5 captures: 2 remain local
+	bp.CaptureCount = 3
+	client.updateBreakpointCache([]BreakpointConfig{bp})
+	if got := client.localCaptureCounter(&bp).Load(); got != 2 {
+		t.Errorf("local captures after refresh = %d; want 2", got)
+	}
+
+	// The backend reset the breakpoint's count
+	bp.CaptureCount = 0
+	client.updateBreakpointCache([]BreakpointConfig{bp})
+	if _, ok := client.GetBreakpoint("orders.go", 12); !ok {
+		t.Error("breakpoint not active after backend reset")
+	}
+}
+
+// TestCaptureRateLimit verifies captures over the per-second budget are
+// dropped and counted
+func TestCaptureRateLimit(t *testing.T) {
+	client := NewSnapshotClientWithConfig("test-key", "http://localhost", "test-service",
+		CaptureConfig{MaxCapturesPerSecond: 5})
+
+	allowed := 0
+	for i := 0; i < 20; i++ {
+		if client.allowCapture() {
+			allowed++
+		}
+	}
+
+	if allowed != 5 {
+		t.Errorf("allowed %d captures; want the burst of 5", allowed)
+	}
+	if got := client.DroppedCaptures(); got != 15 {
+		t.Errorf("DroppedCaptures() = %d; want 15", got)
+	}
+
+	unlimited := NewSnapshotClient("test-key", "http://localhost", "test-service")
+	for i := 0; i < 20; i++ {
+		if !unlimited.allowCapture() {
+			t.Fatal("capture dropped without a rate limit")
+		}
+	}
+}
+
+// TestClaimCaptureOrder verifies captures refused by MaxCaptures spend no
+// global rate budget and rate-limited captures give their reservation back
+func TestClaimCaptureOrder(t *testing.T) {
+	client := NewSnapshotClientWithConfig("test-key", "http://localhost", "test-service",
+		CaptureConfig{MaxCapturesPerSecond: 2})
+	limited := &BreakpointConfig{ID: "bp-once", FilePath: "orders.go", LineNumber: 12, MaxCaptures: 1}
+	other := &BreakpointConfig{ID: "bp-other", FilePath: "orders.go", LineNumber: 40}
+
+	for i := 0; i < 5; i++ {
+		client.claimCapture(limited)
+	}
+	if got := client.DroppedCaptures(); got != 0 {
+		t.Errorf("DroppedCaptures() = %d; want 0 for MaxCaptures refusals", got)
+	}
+	if _, ok := client.claimCapture(other); !ok {
+		t.Error("budget spent by captures refused by MaxCaptures")
+	}
+
+	// The budget is now used up; the refused capture must not count
+	if _, ok := client.claimCapture(other); ok {
+		t.Fatal("capture allowed over the rate limit")
+	}
+	if got := client.localCaptureCounter(other).Load(); got != 1 {
+		t.Errorf("local captures = %d; want 1 after a rate-limited claim", got)
+	}
+}
@@ -0,0 +1,137 @@
+package tracekit
+
+import (
+	"database/sql"
+	"runtime"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// defaultCollectInterval is used when a collector is started with interval <= 0
+const defaultCollectInterval = 15 * time.Second
+
+// startCollector calls collect every interval until Shutdown or until the
+// returned function is called
+func (s *SDK) startCollector(interval time.Duration, collect func()) func() {
+	if interval <= 0 {
+		interval = defaultCollectInterval
+	}
+	stop := s.collectorsStopChan()
+	cancel := make(chan struct{})
+	var cancelOnce sync.Once
+
+	s.collectors.Add(1)
+	go func() {
+		defer s.collectors.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		collect()
+		for {
+			select {
+			case <-ticker.C:
+				collect()
+			case <-stop:
+				return
+			case <-cancel:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		cancelOnce.Do(func() { close(cancel) })
+	}
+}
+
+// collectorsStopChan returns the channel closed by stopCollectors
+func (s *SDK) collectorsStopChan() chan struct{} {
+	s.collectorsInit.Do(func() {
+		s.collectorsStop = make(chan struct{})
+	})
+	return s.collectorsStop
+}
+
+// stopCollectors stops all collectors and waits for them to return
+func (s *SDK) stopCollectors() {
+	stop := s.collectorsStopChan()
+	s.collectorsOnce.Do(func() {
+		close(stop)
+	})
+	s.collectors.Wait()
+}
+
+// CollectDBStats records tdb's connection-pool statistics every interval
+// (default 15s) as gauges tagged with db.system: db.pool.open_connections,
+// db.pool.in_use, db.pool.idle, db.pool.wait_count (cumulative) and
+// db.pool.wait_duration (cumulative, in seconds). It runs until Shutdown or
+// until the returned function is called.
+//
+//	stop := sdk.CollectDBStats(tdb, 10*time.Second)
+//	defer stop()
+func (s *SDK) CollectDBStats(tdb *TracedDB, interval time.Duration) func() {
+	tags := map[string]string{"db.system": tdb.dbSystem}
+	if tdb.peerName != "" {
+		tags["net.peer.name"] = tdb.peerName
+	}
+	return s.collectDBStats(tdb.db, tags, interval)
+}
+
+// CollectGormDBStats is CollectDBStats for a GORM connection. It fails if
+// the GORM connection pool isn't a *sql.DB.
+func (s *SDK) CollectGormDBStats(db *gorm.DB, interval time.Duration) (func(), error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	return s.collectDBStats(sqlDB, map[string]string{"db.system": getDatabaseSystem(db)}, interval), nil
+}
+
+func (s *SDK) collectDBStats(db *sql.DB, tags map[string]string, interval time.Duration) func() {
+	return s.startCollector(interval, func() {
+		s.recordDBStats(db.Stats(), tags)
+	})
+}
+
+// recordDBStats sets the pool gauges from stats
+func (s *SDK) recordDBStats(stats sql.DBStats, tags map[string]string) {
+	s.Gauge("db.pool.open_connections", tags).Set(float64(stats.OpenConnections))
+	s.Gauge("db.pool.in_use", tags).Set(float64(stats.InUse))
+	s.Gauge("db.pool.idle", tags).Set(float64(stats.Idle))
+	s.Gauge("db.pool.wait_count", tags).Set(float64(stats.WaitCount))
+	s.Gauge("db.pool.wait_duration", tags).Set(stats.WaitDuration.Seconds())
+}
+
+// StartRuntimeMetrics records Go runtime health every interval (default 15s)
+// as gauges: runtime.go.goroutines, runtime.go.mem.heap_alloc,
+// runtime.go.mem.heap_inuse, runtime.go.mem.heap_objects, runtime.go.mem.sys
+// (bytes/objects), runtime.go.gc.count, runtime.go.gc.pause (latest pause,
+// seconds) and runtime.go.gc.pause_total (seconds). It runs until Shutdown or
+// until the returned function is called.
+//
+//	stop := sdk.StartRuntimeMetrics(30 * time.Second)
+//	defer stop()
+func (s *SDK) StartRuntimeMetrics(interval time.Duration) func() {
+	return s.startCollector(interval, s.recordRuntimeMetrics)
+}
+
+// recordRuntimeMetrics samples the runtime. ReadMemStats stops the world
+// briefly, which is why this runs on an interval rather than per request.
+func (s *SDK) recordRuntimeMetrics() {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	s.Gauge("runtime.go.goroutines", nil).Set(float64(runtime.NumGoroutine()))
+	s.Gauge("runtime.go.mem.heap_alloc", nil).Set(float64(m.HeapAlloc))
+	s.Gauge("runtime.go.mem.heap_inuse", nil).Set(float64(m.HeapInuse))
+	s.Gauge("runtime.go.mem.heap_objects", nil).Set(float64(m.HeapObjects))
+	s.Gauge("runtime.go.mem.sys", nil).Set(float64(m.Sys))
+	s.Gauge("runtime.go.gc.count", nil).Set(float64(m.NumGC))
+	s.Gauge("runtime.go.gc.pause_total", nil).Set(time.Duration(m.PauseTotalNs).Seconds())
+	if m.NumGC > 0 {
+		// PauseNs is a circular buffer; the latest pause is at (NumGC+255)%256
+		s.Gauge("runtime.go.gc.pause", nil).Set(time.Duration(m.PauseNs[(m.NumGC+255)%256]).Seconds())
+	}
+}
@@ -6,20 +6,23 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
-	"go.opentelemetry.io/otel/propagation"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
 )
 
 // Config holds the TraceKit SDK configuration
@@ -38,35 +41,295 @@ type Config struct {
 	// Optional - defaults to /v1/metrics
 	MetricsPath string
 
+	// Optional - defaults to /v1/logs (used by SDK.LogHandler)
+	LogsPath string
+
 	// Optional - defaults to true (use TLS)
 	UseSSL bool
 
 	// Optional - service version
 	ServiceVersion string
 
+	// Optional - build metadata recorded as vcs.revision / service.build_id
+	// resource attributes. Use ReadBuildInfo() to populate it automatically.
+	BuildInfo *BuildInfo
+
 	// Optional - deployment environment
 	Environment string
 
+	// Optional - deployment.version resource attribute distinguishing builds
+	// running side by side during a canary rollout, so traces can be sliced by
+	// version (default: TRACEKIT_DEPLOYMENT_VERSION env var)
+	DeploymentVersion string
+
+	// Optional - deployment.color resource attribute for blue/green rollouts
+	// (default: TRACEKIT_DEPLOYMENT_COLOR env var)
+	DeploymentColor string
+
 	// Optional - additional resource attributes
 	ResourceAttributes map[string]string
 
 	// Optional - enable code monitoring
 	EnableCodeMonitoring bool
 
-	// Optional - code monitoring poll interval (default: 30s)
+	// Optional - how often code monitoring polls for breakpoints (default: 30s)
 	CodeMonitoringPollInterval time.Duration
 
 	// Optional - sampling rate (0.0 to 1.0, default: 1.0 = 100%)
 	SamplingRate float64
 
+	// Optional - always sample the first N root traces of each operation
+	// (span name) after startup, then fall back to SamplingRate. Guarantees
+	// examples of low-traffic endpoints right after a deploy (default: 0 = disabled)
+	SampleFirstNPerOperation int
+
+	// Optional - request paths for which the HTTP middleware (HTTPHandler,
+	// Gin, Echo, Fiber, gorilla/mux) starts no span at all. Paths match
+	// exactly, or by prefix when the pattern ends in "*"
+	// Example: []string{"/healthz", "/metrics", "/debug/*"}
+	IgnorePaths []string
+
+	// Optional - per-operation sampling rates for root spans; the first rule
+	// matching the span name and start attributes sets the rate, otherwise
+	// SamplingRate applies
+	// Example: []SamplingRule{{Name: "*checkout*", Rate: 1}, {Attributes: map[string]string{"url.path": "/healthz"}, Rate: 0.01}}
+	SamplingRules []SamplingRule
+
+	// Optional - per-request sampling predicate evaluated by the HTTP, gin,
+	// echo and mux middleware before the server span starts. Return
+	// SamplingAlways or SamplingNever to force or drop that request's trace,
+	// e.g. to sample all traffic from an internal network (default: nil)
+	RequestSampler func(r *http.Request) SamplingOverride
+
+	// Optional - returns the authenticated user and tenant for a request
+	// (e.g. from JWT claims). The HTTP, gin, echo and mux middleware set them
+	// on the server span as user.id and tenant.id; empty values are skipped
+	IdentityExtractor func(r *http.Request) (userID, tenantID string)
+
 	// Optional - batch timeout (default: 5s)
 	BatchTimeout time.Duration
 
+	// Optional - drop spans shorter than this duration at span end
+	// (default: 0 = keep all spans). Useful for suppressing cache-hit noise.
+	MinSpanDuration time.Duration
+
+	// Optional - keep spans with an error status even when shorter than
+	// MinSpanDuration. nil or true = keep (default), false = drop.
+	KeepShortErrorSpans *bool
+
+	// Optional - in-process tail sampling: every span is recorded, and a
+	// trace's spans are buffered until its local root span ends, then
+	// exported only if the root took at least this long, the trace contains
+	// an error (with AlwaysSampleErrors) or SamplingRate selects its trace
+	// ID. Downstream services see every request as sampled
+	// (default: 0 = head sampling only)
+	LatencySamplingThreshold time.Duration
+
+	// Optional - with tail sampling, always export traces containing an error
+	// span. Setting it enables tail sampling even without
+	// LatencySamplingThreshold (default: false)
+	AlwaysSampleErrors bool
+
+	// Optional - record the ID of the goroutine that started each span as
+	// thread.id, for correlating spans with goroutine dumps. The ID is parsed
+	// from runtime.Stack on every span start, so enable only while debugging
+	// concurrency issues (default: false)
+	RecordGoroutineID bool
+
+	// Optional - baggage keys copied onto every span as attributes when
+	// present in the span's parent context, e.g. []string{"tenant.id"} to
+	// filter all spans of a trace by tenant (default: nil)
+	BaggageToAttributes []string
+
+	// Optional - context propagation formats, in order: "tracecontext",
+	// "baggage", "b3" (single header), "b3multi" and "jaeger". Use B3 or
+	// Jaeger to interoperate with services instrumented by Zipkin or Jaeger
+	// clients (default: tracecontext, baggage)
+	Propagators []string
+
+	// Optional - record RED metrics for every span: a span.calls counter and
+	// a span.duration histogram (ms) tagged with span.name, span.kind and
+	// status.code. Covers database, Redis, gRPC and custom spans alike; span
+	// names should be low-cardinality.
+	//
+	// Metrics are computed from all spans, not just sampled ones: spans the
+	// samplers (SamplingRate, RequestSampler, ...) would drop are still
+	// recorded in-process, unsampled, so they reach the metrics processor but
+	// are never exported. This keeps metrics exact at any sampling rate at
+	// the cost of recording every span. SetEnabled(false) still drops
+	// everything (default: false)
+	EnableSpanMetrics bool
+
+	// Optional - number of buffered metric points that triggers an export (default: 100)
+	MetricsMaxBatchSize int
+
+	// Optional - metrics export interval (default: 10s)
+	MetricsFlushInterval time.Duration
+
+	// Optional - never export a metric timestamp earlier than the latest one
+	// already exported; earlier points are moved forward to it. Protects
+	// against backend rejections while a skewed host clock is being corrected
+	// by NTP (default: false)
+	MetricsMonotonicTimestamps bool
+
+	// Optional - prefix prepended to every metric name registered through this
+	// SDK instance, e.g. "gateway." (keeps metrics of multiple SDKs distinct)
+	MetricsPrefix string
+
+	// Optional - default histogram bucket upper bounds
+	// (default: DefaultHistogramBuckets, the Prometheus defaults)
+	HistogramBuckets []float64
+
+	// Optional - quantiles (0..1, e.g. 0.5, 0.9, 0.99) estimated from each
+	// histogram's buckets and exported as a "<name>.quantile" gauge tagged
+	// quantile="<q>", for alerting that can't compute quantiles at query
+	// time. Accuracy depends on the bucket bounds. Not applied with
+	// UseOTelMetrics (default: none)
+	HistogramQuantiles []float64
+
+	// Optional - back Counter/Gauge/Histogram with the OpenTelemetry metrics
+	// SDK, exporting cumulative OTLP metrics to MetricsPath every
+	// MetricsFlushInterval instead of using the built-in lightweight
+	// exporter (default: false)
+	UseOTelMetrics bool
+
+	// Optional - record db.redis.response_bytes, the total size of the
+	// reply's string values, on Redis command spans
+	RedisRecordResponseSize bool
+
+	// Optional - add a redis.large_response span event when a Redis reply
+	// exceeds this many bytes (default: 0 = disabled)
+	RedisLargeResponseBytes int
+
+	// Optional - record Redis commands and their arguments as db.statement
+	// (e.g. "GET user:123"). Credentials and values under sensitive-looking
+	// keys are masked. Default: false to avoid accidental PII capture.
+	RedisCaptureStatement bool
+
+	// Optional - maximum length of a captured Redis statement (default: 256)
+	RedisStatementMaxLength int
+
+	// Optional - create a child span per command inside a Redis pipeline
+	// instead of a redis.command event on the pipeline span (default: false)
+	RedisPipelineChildSpans bool
+
+	// Optional - database spans (TracedDB and GORM) slower than this are
+	// flagged with db.slow_query=true and a db.slow_query event (0 = disabled)
+	SlowQueryThreshold time.Duration
+
+	// Optional - maximum length of SQL statements captured as db.statement by
+	// TracedDB, OpenDB and the GORM plugin; longer statements are truncated
+	// (0 = unlimited)
+	MaxSQLStatementLength int
+
+	// Optional - omit db.statement from SQL spans (TracedDB, OpenDB and GORM)
+	// entirely, recording only db.operation and db.sql.table (default: false)
+	DisableSQLCapture bool
+
 	// Optional - map hostnames to service names for peer.service attribute
 	// Useful for mapping localhost URLs to actual service names
 	// Example: map[string]string{"localhost:8084": "node-test-app", "localhost:8082": "go-test-app"}
+	// Entries are merged with those from ServiceNameMappingsFile and the
+	// TRACEKIT_SERVICE_NAME_MAPPINGS env var ("host=service,host2=service2");
+	// entries set here take precedence
 	ServiceNameMappings map[string]string
 
+	// Optional - path to a JSON object of hostname to service name mappings,
+	// e.g. {"localhost:9000": "payment"}
+	// (default: TRACEKIT_SERVICE_NAME_MAPPINGS_FILE env var)
+	ServiceNameMappingsFile string
+
+	// Optional - HTTP response trailers recorded on client spans as
+	// http.response.trailer.<name> attributes once the body is fully read
+	// Example: []string{"X-Backend-Instance", "X-Processing-Cost"}
+	HTTPClientTrailers []string
+
+	// Optional - record http.connection.reused, http.connection.was_idle and
+	// http.protocol (h1/h2) on HTTP client spans, to diagnose connection-pool
+	// churn (default: false)
+	HTTPClientConnectionAttributes bool
+
+	// Optional - maps outgoing request URLs to a low-cardinality path template
+	// used to name HTTP client spans "GET /accounts/{id}/balance" instead of
+	// "HTTP GET". NormalizeURLPath collapses numeric, UUID and similar
+	// ID segments (default: nil, spans keep otelhttp's name)
+	HTTPClientPathTemplater func(*url.URL) string
+
+	// Optional - decides which MongoDB commands MongoClientOptions traces;
+	// return false to drop a command (default: DefaultMongoCommandFilter,
+	// which drops hello/isMaster, ping and auth commands)
+	MongoCommandFilter func(cmdName string) bool
+
+	// Optional - HTTP status codes that don't mark net/http server and client
+	// spans (HTTPHandler, HTTPClient) as errors, e.g. []int{404} for
+	// existence checks; such spans get status Ok instead of Error
+	NonErrorStatusCodes []int
+
+	// Optional - gRPC status codes that don't mark client or server spans as
+	// errors, e.g. []codes.Code{codes.Unavailable} during planned failovers.
+	// Caller-side outcomes such as NotFound and AlreadyExists are never
+	// errors on either side.
+	NonErrorGRPCCodes []codes.Code
+
+	// Optional - sentinel errors mapped to the error.category recorded by
+	// RecordClassifiedError, matched with errors.Is before the defaults
+	// Example: map[error]string{ErrOutOfStock: "validation"}
+	ErrorClassifiers map[error]string
+
+	// Optional - functions consulted by RecordClassifiedError after
+	// ErrorClassifiers; each returns a category, or "" if it doesn't apply
+	ErrorMatchers []func(error) string
+
+	// Optional - record rpc.grpc.request.size and rpc.grpc.response.size
+	// (total bytes) on gRPC spans and log the first request/response message,
+	// truncated and redacted, of calls that end with a non-OK status
+	// (default: false)
+	GRPCCaptureMessages bool
+
+	// Optional - HTTP headers recorded on server and client spans as
+	// http.request.header.<name> / http.response.header.<name> attributes,
+	// lowercased with dashes as underscores (X-Request-ID -> x_request_id).
+	// Values are redacted per Redaction, e.g. Authorization.
+	// Example: []string{"X-Request-ID", "X-Tenant"}
+	CaptureRequestHeaders  []string
+	CaptureResponseHeaders []string
+
+	// Optional - record request/response bodies on HTTP server and client
+	// spans as http.request.body / http.response.body, truncated to
+	// HTTPBodyCaptureMaxBytes and passed through Redaction. Bodies are teed,
+	// not consumed. Off by default since bodies often carry personal data.
+	HTTPCaptureRequestBody  bool
+	HTTPCaptureResponseBody bool
+
+	// Optional - maximum bytes of each captured body (default: 4096)
+	HTTPBodyCaptureMaxBytes int
+
+	// Optional - Content-Type prefixes whose bodies may be captured
+	// (default: application/json, application/xml,
+	// application/x-www-form-urlencoded, text/)
+	HTTPBodyCaptureContentTypes []string
+
+	// Optional - URL path prefixes whose bodies may be captured
+	// Example: []string{"/api/payments", "/webhooks/"} (default: all paths)
+	HTTPBodyCapturePaths []string
+
+	// Optional - sensitive-data handling shared by all instrumentation
+	// (request context headers, SQL statements, span attributes, snapshots).
+	// If nil, only the Authorization, Cookie and X-Api-Key headers are redacted.
+	Redaction *RedactionConfig
+
+	// Optional - functions invoked by StartSpan to derive attributes from
+	// request-scoped context values (tenant, locale, feature flags, ...).
+	// Returned attributes are attached to the new span at start.
+	ContextAttributeExtractors []func(context.Context) []attribute.KeyValue
+
+	// Optional - verbosity of SDK diagnostics: error, warn, info or debug
+	// (default: info). Can be changed at runtime with SDK.SetLogLevel.
+	LogLevel LogLevel
+
+	// Optional - destination for SDK diagnostics (default: standard library logger)
+	Logger Logger
+
 	// Optional - LLM instrumentation configuration
 	// When set, NewLLMTransport will use these settings.
 	// If nil, DefaultLLMConfig() is used.
@@ -80,7 +343,77 @@ type SDK struct {
 	tracerProvider  *sdktrace.TracerProvider
 	snapshotClient  *SnapshotClient
 	metricsRegistry *metricsRegistry
+	redactor        *redactor
 	localUIEnabled  bool
+	logger          *diagLogger
+
+	// resource is shared by the trace, log and OTel metrics pipelines
+	resource *resource.Resource
+
+	// otelMetrics replaces metricsRegistry when Config.UseOTelMetrics is set
+	otelMetrics *otelMetrics
+
+	// loggerProvider is created on the first LogHandler call
+	loggerProvider *sdklog.LoggerProvider
+	logsOnce       sync.Once
+
+	// disabled is the runtime kill switch toggled by SetEnabled (zero value = enabled)
+	disabled atomic.Bool
+
+	// collectors are the periodic metric collectors (CollectDBStats, ...),
+	// stopped by Shutdown
+	collectors     sync.WaitGroup
+	collectorsStop chan struct{}
+	collectorsInit sync.Once
+	collectorsOnce sync.Once
+}
+
+// loadServiceNameMappings merges the mappings from ServiceNameMappingsFile
+// and the TRACEKIT_SERVICE_NAME_MAPPINGS env var into
+// config.ServiceNameMappings without overriding entries set in code
+func loadServiceNameMappings(config *Config) error {
+	if config.ServiceNameMappingsFile == "" {
+		config.ServiceNameMappingsFile = os.Getenv("TRACEKIT_SERVICE_NAME_MAPPINGS_FILE")
+	}
+
+	loaded := make(map[string]string)
+	if config.ServiceNameMappingsFile != "" {
+		data, err := os.ReadFile(config.ServiceNameMappingsFile)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &loaded); err != nil {
+			return fmt.Errorf("%s: %w", config.ServiceNameMappingsFile, err)
+		}
+	}
+
+	// The env var wins over the file, e.g. to patch one host per deployment
+	if env := os.Getenv("TRACEKIT_SERVICE_NAME_MAPPINGS"); env != "" {
+		for _, entry := range strings.Split(env, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			host, service, ok := strings.Cut(entry, "=")
+			if !ok || strings.TrimSpace(host) == "" {
+				return fmt.Errorf("TRACEKIT_SERVICE_NAME_MAPPINGS: invalid entry %q, want host=service", entry)
+			}
+			loaded[strings.TrimSpace(host)] = strings.TrimSpace(service)
+		}
+	}
+
+	if len(loaded) == 0 {
+		return nil
+	}
+	mappings := make(map[string]string, len(loaded)+len(config.ServiceNameMappings))
+	for host, service := range loaded {
+		mappings[host] = service
+	}
+	for host, service := range config.ServiceNameMappings {
+		mappings[host] = service
+	}
+	config.ServiceNameMappings = mappings
+	return nil
 }
 
 // resolveEndpoint builds the full endpoint URL from base endpoint and path
@@ -175,12 +508,14 @@ func detectLocalUI() bool {
 // localUISpanProcessor is a custom span processor that sends traces to local UI
 type localUISpanProcessor struct {
 	client *http.Client
+	logger *diagLogger
 }
 
 // newLocalUISpanProcessor creates a new local UI span processor
-func newLocalUISpanProcessor() *localUISpanProcessor {
+func newLocalUISpanProcessor(logger *diagLogger) *localUISpanProcessor {
 	return &localUISpanProcessor{
 		client: &http.Client{Timeout: 1 * time.Second},
+		logger: logger,
 	}
 }
 
@@ -189,8 +524,8 @@ func (p *localUISpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWr
 
 // OnEnd is called when a span ends - sends to local UI in a goroutine
 func (p *localUISpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
-	// Only send in development environment
-	if os.Getenv("ENV") != "development" {
+	// Only send in development environment, and only sampled spans
+	if os.Getenv("ENV") != "development" || !s.SpanContext().IsSampled() {
 		return
 	}
 
@@ -238,7 +573,7 @@ func (p *localUISpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
 		resp, err := p.client.Do(req)
 		if err == nil {
 			defer resp.Body.Close()
-			log.Println("🔍 Sent to Local UI")
+			p.logger.debugf("🔍 Sent to Local UI")
 		}
 	}()
 }
@@ -274,6 +609,11 @@ func NewSDK(config *Config) (*SDK, error) {
 		return nil, fmt.Errorf("ServiceName is required")
 	}
 
+	// Configure diagnostics first so initialization logs honor the level
+	logger := newDiagLogger()
+	logger.setLevel(config.LogLevel)
+	logger.setOutput(config.Logger)
+
 	// Set defaults
 	if config.Endpoint == "" {
 		config.Endpoint = "app.tracekit.dev"
@@ -284,12 +624,28 @@ func NewSDK(config *Config) (*SDK, error) {
 	if config.MetricsPath == "" {
 		config.MetricsPath = "/v1/metrics"
 	}
+	if config.LogsPath == "" {
+		config.LogsPath = "/v1/logs"
+	}
 	if config.ServiceVersion == "" {
-		config.ServiceVersion = "1.0.0"
+		if config.BuildInfo != nil && config.BuildInfo.Version != "" {
+			config.ServiceVersion = config.BuildInfo.Version
+		} else {
+			config.ServiceVersion = "1.0.0"
+		}
+	}
+	if config.DeploymentVersion == "" {
+		config.DeploymentVersion = os.Getenv("TRACEKIT_DEPLOYMENT_VERSION")
+	}
+	if config.DeploymentColor == "" {
+		config.DeploymentColor = os.Getenv("TRACEKIT_DEPLOYMENT_COLOR")
 	}
 	if config.SamplingRate == 0 {
 		config.SamplingRate = 1.0
 	}
+	if err := loadServiceNameMappings(config); err != nil {
+		return nil, fmt.Errorf("failed to load service name mappings: %w", err)
+	}
 	if config.BatchTimeout == 0 {
 		config.BatchTimeout = 5 * time.Second
 	}
@@ -302,14 +658,16 @@ func NewSDK(config *Config) (*SDK, error) {
 	metricsEndpoint := resolveEndpoint(config.Endpoint, config.MetricsPath, config.UseSSL)
 
 	sdk := &SDK{
-		config: config,
+		config:   config,
+		redactor: newRedactor(config.Redaction),
+		logger:   logger,
 	}
 
 	// Detect local UI in development mode
 	if os.Getenv("ENV") == "development" {
 		if detectLocalUI() {
 			sdk.localUIEnabled = true
-			log.Println("🔍 Local UI detected at http://localhost:9999")
+			logger.infof("🔍 Local UI detected at http://localhost:9999")
 		}
 	}
 
@@ -319,7 +677,13 @@ func NewSDK(config *Config) (*SDK, error) {
 	}
 
 	// Initialize metrics registry
-	sdk.metricsRegistry = newMetricsRegistry(metricsEndpoint, config.APIKey, config.ServiceName)
+	if config.UseOTelMetrics {
+		if err := sdk.initOTelMetrics(metricsEndpoint); err != nil {
+			return nil, fmt.Errorf("failed to initialize metrics: %w", err)
+		}
+	} else {
+		sdk.metricsRegistry = newMetricsRegistry(metricsEndpoint, config, logger)
+	}
 
 	// Initialize code monitoring if enabled
 	if config.EnableCodeMonitoring {
@@ -331,10 +695,14 @@ func NewSDK(config *Config) (*SDK, error) {
 			snapshotEndpoint,
 			config.ServiceName,
 		)
+		sdk.snapshotClient.redactor = sdk.redactor
+		sdk.snapshotClient.setLogger(logger)
+		sdk.snapshotClient.counter = sdk.Counter
+		sdk.snapshotClient.SetPollInterval(config.CodeMonitoringPollInterval)
 		sdk.snapshotClient.Start()
 	}
 
-	log.Printf("✅ TraceKit SDK initialized for service: %s", config.ServiceName)
+	logger.infof("✅ TraceKit SDK initialized for service: %s", config.ServiceName)
 	return sdk, nil
 }
 
@@ -387,55 +755,89 @@ func (s *SDK) initTracer(tracesEndpoint string) error {
 		return err
 	}
 
-	// Build resource attributes
-	attrs := []attribute.KeyValue{
-		semconv.ServiceName(s.config.ServiceName),
-		semconv.ServiceVersion(s.config.ServiceVersion),
-	}
-
-	if s.config.Environment != "" {
-		attrs = append(attrs, semconv.DeploymentEnvironment(s.config.Environment))
-	}
-
-	// Add custom attributes
-	for k, v := range s.config.ResourceAttributes {
-		attrs = append(attrs, attribute.String(k, v))
-	}
-
 	// Create resource
 	res, err := resource.New(
 		ctx,
-		resource.WithAttributes(attrs...),
+		resource.WithAttributes(s.resourceAttributes()...),
 	)
 	if err != nil {
 		return err
 	}
+	s.resource = res
+
+	// Create tracer provider with sampling. Tail sampling applies the
+	// sampling rate and rules at export time, so every span must be sampled
+	// here.
+	tailSampling := s.config.LatencySamplingThreshold > 0 || s.config.AlwaysSampleErrors
+	var rootSampler sdktrace.Sampler = sdktrace.TraceIDRatioBased(s.config.SamplingRate)
+	if len(s.config.SamplingRules) > 0 {
+		rootSampler = newRuleSampler(s.config.SamplingRules, rootSampler)
+	}
+	sampler := rootSampler
+	if tailSampling {
+		sampler = sdktrace.AlwaysSample()
+	}
+	if s.config.SampleFirstNPerOperation > 0 {
+		sampler = newFirstNPerOperationSampler(sampler, s.config.SampleFirstNPerOperation)
+	}
+	sampler = sdktrace.ParentBased(sampler)
+	if s.config.RequestSampler != nil {
+		sampler = &requestOverrideSampler{base: sampler}
+	}
+	if s.config.EnableSpanMetrics {
+		// Record unsampled spans too so span metrics aren't biased by sampling
+		sampler = &recordAllSampler{base: sampler}
+	}
+	sampler = &killSwitchSampler{base: sampler, disabled: &s.disabled}
 
-	// Create tracer provider with sampling
-	sampler := sdktrace.ParentBased(sdktrace.TraceIDRatioBased(s.config.SamplingRate))
+	// Build the export pipeline, optionally filtering out short spans
+	var exportProcessor sdktrace.SpanProcessor = sdktrace.NewBatchSpanProcessor(exporter,
+		sdktrace.WithBatchTimeout(s.config.BatchTimeout),
+	)
+	if s.config.MinSpanDuration > 0 {
+		keepErrors := s.config.KeepShortErrorSpans == nil || *s.config.KeepShortErrorSpans
+		exportProcessor = newMinDurationSpanProcessor(exportProcessor, s.config.MinSpanDuration, keepErrors)
+	}
+	if tailSampling {
+		exportProcessor = newTailSamplingSpanProcessor(exportProcessor,
+			s.config.LatencySamplingThreshold, s.config.AlwaysSampleErrors, rootSampler)
+	}
 
 	// Prepare tracer provider options
 	tpOptions := []sdktrace.TracerProviderOption{
-		sdktrace.WithBatcher(exporter,
-			sdktrace.WithBatchTimeout(s.config.BatchTimeout),
-		),
+		sdktrace.WithSpanProcessor(&transactionSpanProcessor{}),
+		sdktrace.WithSpanProcessor(&serviceNameSpanProcessor{}),
+		sdktrace.WithSpanProcessor(exportProcessor),
 		sdktrace.WithResource(res),
 		sdktrace.WithSampler(sampler),
 	}
 
+	if len(s.config.BaggageToAttributes) > 0 {
+		tpOptions = append(tpOptions, sdktrace.WithSpanProcessor(&baggageSpanProcessor{keys: s.config.BaggageToAttributes}))
+	}
+
+	if s.config.EnableSpanMetrics {
+		// Metrics are recorded through the registry created after initTracer
+		tpOptions = append(tpOptions, sdktrace.WithSpanProcessor(&spanMetricsProcessor{
+			counter:   s.Counter,
+			histogram: s.Histogram,
+		}))
+	}
+
+	if s.config.RecordGoroutineID {
+		tpOptions = append(tpOptions, sdktrace.WithSpanProcessor(&goroutineIDSpanProcessor{}))
+	}
+
 	// Add local UI span processor if enabled
 	if s.localUIEnabled {
-		tpOptions = append(tpOptions, sdktrace.WithSpanProcessor(newLocalUISpanProcessor()))
+		tpOptions = append(tpOptions, sdktrace.WithSpanProcessor(newLocalUISpanProcessor(s.logger)))
 	}
 
 	s.tracerProvider = sdktrace.NewTracerProvider(tpOptions...)
 
 	// Set global providers
 	otel.SetTracerProvider(s.tracerProvider)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
-		propagation.TraceContext{},
-		propagation.Baggage{},
-	))
+	otel.SetTextMapPropagator(newPropagator(s.config.Propagators, s.logger))
 
 	// Get tracer
 	s.tracer = s.tracerProvider.Tracer(s.config.ServiceName)
@@ -443,11 +845,61 @@ func (s *SDK) initTracer(tracesEndpoint string) error {
 	return nil
 }
 
+// resourceAttributes builds the resource attributes shared by all spans
+func (s *SDK) resourceAttributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.ServiceName(s.config.ServiceName),
+		semconv.ServiceVersion(s.config.ServiceVersion),
+	}
+
+	if s.config.Environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironment(s.config.Environment))
+	}
+	if s.config.DeploymentVersion != "" {
+		attrs = append(attrs, attribute.String("deployment.version", s.config.DeploymentVersion))
+	}
+	if s.config.DeploymentColor != "" {
+		attrs = append(attrs, attribute.String("deployment.color", s.config.DeploymentColor))
+	}
+
+	if s.config.BuildInfo != nil {
+		attrs = append(attrs, s.config.BuildInfo.resourceAttributes()...)
+	}
+
+	// Add custom attributes
+	for k, v := range s.config.ResourceAttributes {
+		attrs = append(attrs, attribute.String(k, v))
+	}
+
+	return attrs
+}
+
 // Tracer returns the underlying OpenTelemetry tracer
 func (s *SDK) Tracer() trace.Tracer {
 	return s.tracer
 }
 
+// SetEnabled turns tracing and metrics recording on or off at runtime.
+// While disabled, StartSpan returns no-op spans, every integration's spans
+// are dropped at the tracer provider and metric recordings are discarded.
+// Unlike the sampling rate, this applies regardless of the parent's sampling
+// decision, which makes it suitable as a kill switch during incident
+// mitigation. Tracing is enabled by default.
+func (s *SDK) SetEnabled(enabled bool) {
+	s.disabled.Store(!enabled)
+	if s.metricsRegistry != nil {
+		s.metricsRegistry.setEnabled(enabled)
+	}
+	if s.otelMetrics != nil {
+		s.otelMetrics.setEnabled(enabled)
+	}
+}
+
+// IsEnabled reports whether tracing is currently enabled
+func (s *SDK) IsEnabled() bool {
+	return !s.disabled.Load()
+}
+
 // NewLLMTransport creates an LLM-instrumented HTTP transport using
 // this SDK's tracer and LLM configuration. If base is nil,
 // http.DefaultTransport is used.
@@ -492,6 +944,13 @@ func (s *SDK) CheckAndCapture(filePath string, lineNumber int, variables map[str
 	}
 }
 
+// CheckAndCaptureCtx is CheckAndCapture with the snapshot linked to the active trace in ctx
+func (s *SDK) CheckAndCaptureCtx(ctx context.Context, filePath string, lineNumber int, variables map[string]interface{}) {
+	if s.snapshotClient != nil {
+		s.snapshotClient.CheckAndCaptureCtx(ctx, filePath, lineNumber, variables)
+	}
+}
+
 // CheckAndCaptureWithContext is a wrapper for code monitoring snapshot capture with context
 // It automatically registers the breakpoint location - no need to manually create breakpoints!
 //
@@ -504,14 +963,84 @@ func (s *SDK) CheckAndCaptureWithContext(ctx context.Context, label string, vari
 	}
 }
 
-// Shutdown gracefully shuts down the SDK
+// CheckAndCaptureWithReceiver is CheckAndCaptureWithContext for methods,
+// adding the exported fields of the receiver to the snapshot variables
+//
+//	func (s *PaymentService) Charge(ctx context.Context, amount int) {
+//		sdk.CheckAndCaptureWithReceiver(ctx, "charge", s, map[string]interface{}{"amount": amount})
+//	}
+func (s *SDK) CheckAndCaptureWithReceiver(ctx context.Context, label string, self interface{}, variables map[string]interface{}) {
+	if s.snapshotClient != nil {
+		s.snapshotClient.CheckAndCaptureWithReceiver(ctx, label, self, variables)
+	}
+}
+
+// Capture captures a snapshot of variables at the call site tagged with
+// label, without requiring a registered breakpoint
+//
+//	if err := reconcile(ctx, order); errors.Is(err, errLedgerMismatch) {
+//		sdk.Capture(ctx, "ledger-mismatch", map[string]interface{}{"order": order})
+//	}
+func (s *SDK) Capture(ctx context.Context, label string, variables map[string]interface{}) {
+	if s.snapshotClient != nil {
+		s.snapshotClient.Capture(ctx, label, variables)
+	}
+}
+
+// ForceFlush synchronously exports all buffered spans and metrics.
+// Useful in serverless or short-lived processes (e.g. at the end of a Lambda
+// handler) where the batch timeout would otherwise drop pending spans.
+// It is safe to call repeatedly and on a partially initialized SDK.
+func (s *SDK) ForceFlush(ctx context.Context) error {
+	if s.metricsRegistry != nil {
+		s.metricsRegistry.flush()
+	}
+
+	if s.otelMetrics != nil {
+		if err := s.otelMetrics.flush(ctx); err != nil {
+			return err
+		}
+	}
+
+	if s.loggerProvider != nil {
+		if err := s.loggerProvider.ForceFlush(ctx); err != nil {
+			return err
+		}
+	}
+
+	if s.tracerProvider != nil {
+		return s.tracerProvider.ForceFlush(ctx)
+	}
+
+	return nil
+}
+
+// Shutdown gracefully shuts down the SDK, waiting (until ctx is done) for
+// buffered metrics, logs and spans to be exported
 func (s *SDK) Shutdown(ctx context.Context) error {
 	if s.snapshotClient != nil {
 		s.snapshotClient.Stop()
 	}
 
+	// Stop collectors before the final metrics export
+	s.stopCollectors()
+
 	if s.metricsRegistry != nil {
-		s.metricsRegistry.shutdown()
+		if err := s.metricsRegistry.shutdown(ctx); err != nil {
+			s.logger.warnf("TraceKit: metrics did not finish exporting before shutdown: %v", err)
+		}
+	}
+
+	if s.otelMetrics != nil {
+		if err := s.otelMetrics.shutdown(ctx); err != nil {
+			s.logger.warnf("TraceKit: failed to shut down metric export: %v", err)
+		}
+	}
+
+	if s.loggerProvider != nil {
+		if err := s.loggerProvider.Shutdown(ctx); err != nil {
+			s.logger.warnf("TraceKit: failed to shut down log export: %v", err)
+		}
 	}
 
 	if s.tracerProvider != nil {
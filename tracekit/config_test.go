@@ -1,7 +1,15 @@
 package tracekit
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestResolveEndpoint(t *testing.T) {
@@ -241,3 +249,138 @@ func TestEndpointResolution(t *testing.T) {
 		})
 	}
 }
+
+// TestForceFlushPartialInit verifies ForceFlush is safe on an uninitialized SDK
+func TestForceFlushPartialInit(t *testing.T) {
+	sdk := &SDK{config: &Config{ServiceName: "test-service"}}
+
+	for i := 0; i < 2; i++ {
+		if err := sdk.ForceFlush(context.Background()); err != nil {
+			t.Errorf("ForceFlush call %d returned error: %v", i+1, err)
+		}
+	}
+}
+
+// TestSetEnabled verifies the runtime toggle short-circuits span creation
+func TestSetEnabled(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{config: &Config{ServiceName: "test-service"}, tracer: tp.Tracer("test")}
+
+	if !sdk.IsEnabled() {
+		t.Fatal("SDK should be enabled by default")
+	}
+
+	sdk.SetEnabled(false)
+	ctx := context.Background()
+	gotCtx, span := sdk.StartSpan(ctx, "disabled")
+	if gotCtx != ctx {
+		t.Error("expected StartSpan to return the incoming context when disabled")
+	}
+	if span.SpanContext().IsValid() {
+		t.Error("expected a no-op span when disabled")
+	}
+
+	sdk.SetEnabled(true)
+	_, span = sdk.StartSpan(ctx, "enabled")
+	defer span.End()
+	if !span.SpanContext().IsValid() {
+		t.Error("expected a recording span after re-enabling")
+	}
+}
+
+// TestContextAttributeExtractors verifies extractor attributes are attached at span start
+func TestContextAttributeExtractors(t *testing.T) {
+	type tenantKey struct{}
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+
+	sdk := &SDK{
+		config: &Config{
+			ServiceName: "test-service",
+			ContextAttributeExtractors: []func(context.Context) []attribute.KeyValue{
+				func(ctx context.Context) []attribute.KeyValue {
+					if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+						return []attribute.KeyValue{attribute.String("tenant.id", tenant)}
+					}
+					return nil
+				},
+			},
+		},
+		tracer: tp.Tracer("test"),
+	}
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	_, span := sdk.StartSpan(ctx, "op", trace.WithAttributes(attribute.String("op.kind", "test")))
+	span.End()
+
+	attrs := map[attribute.Key]string{}
+	for _, attr := range recorder.Ended()[0].Attributes() {
+		attrs[attr.Key] = attr.Value.Emit()
+	}
+	if attrs["tenant.id"] != "acme" {
+		t.Errorf("tenant.id = %q; want %q", attrs["tenant.id"], "acme")
+	}
+	if attrs["op.kind"] != "test" {
+		t.Errorf("op.kind = %q; want caller attributes preserved", attrs["op.kind"])
+	}
+}
+
+// TestDeploymentResourceAttributes verifies deployment version and color are
+// recorded on the resource only when set
+func TestDeploymentResourceAttributes(t *testing.T) {
+	sdk := &SDK{config: &Config{
+		ServiceName:       "checkout",
+		DeploymentVersion: "2024.06.1-canary",
+		DeploymentColor:   "green",
+	}}
+
+	attrs := map[string]string{}
+	for _, attr := range sdk.resourceAttributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs["deployment.version"] != "2024.06.1-canary" || attrs["deployment.color"] != "green" {
+		t.Errorf("resource attributes = %v; want deployment.version and deployment.color", attrs)
+	}
+
+	sdk.config.DeploymentColor = ""
+	for _, attr := range sdk.resourceAttributes() {
+		if attr.Key == "deployment.color" {
+			t.Error("deployment.color recorded when unset")
+		}
+	}
+}
+
+// TestLoadServiceNameMappings verifies mappings from the file and env var are
+// merged, with code-set entries winning
+func TestLoadServiceNameMappings(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "mappings.json")
+	if err := os.WriteFile(file, []byte(`{"localhost:9000": "payment", "localhost:9001": "file-orders"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TRACEKIT_SERVICE_NAME_MAPPINGS_FILE", file)
+	t.Setenv("TRACEKIT_SERVICE_NAME_MAPPINGS", "localhost:9001=orders, localhost:9002=inventory")
+
+	config := &Config{ServiceNameMappings: map[string]string{"localhost:9002": "stock"}}
+	if err := loadServiceNameMappings(config); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"localhost:9000": "payment",
+		"localhost:9001": "orders",
+		"localhost:9002": "stock",
+	}
+	for host, service := range want {
+		if got := config.ServiceNameMappings[host]; got != service {
+			t.Errorf("mapping[%q] = %q; want %q", host, got, service)
+		}
+	}
+
+	t.Setenv("TRACEKIT_SERVICE_NAME_MAPPINGS", "localhost:9001")
+	if err := loadServiceNameMappings(&Config{}); err == nil {
+		t.Error("expected error for entry without '='")
+	}
+}
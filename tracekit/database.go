@@ -4,19 +4,81 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 // WrapDB wraps a database/sql DB with OpenTelemetry tracing
 // This creates traced versions of all database operations
-func (s *SDK) WrapDB(db *sql.DB, dbSystem string) *TracedDB {
-	return &TracedDB{
+func (s *SDK) WrapDB(db *sql.DB, dbSystem string, opts ...TracedDBOption) *TracedDB {
+	tdb := &TracedDB{
 		db:       db,
 		tracer:   s.tracer,
 		dbSystem: dbSystem,
+		redactor: s.redactor,
+	}
+	if s.config != nil {
+		tdb.slowQueryThreshold = s.config.SlowQueryThreshold
+		tdb.maxStatementLength = s.config.MaxSQLStatementLength
+		tdb.disableStatement = s.config.DisableSQLCapture
+	}
+
+	for _, opt := range opts {
+		opt(tdb)
+	}
+	if tdb.peerName != "" {
+		var mappings map[string]string
+		if s.config != nil {
+			mappings = s.config.ServiceNameMappings
+		}
+		tdb.peerAttrs = peerAttributes(tdb.peerName, mappings)
+	}
+
+	return tdb
+}
+
+// TracedDBOption is a functional option for configuring WrapDB.
+type TracedDBOption func(*TracedDB)
+
+// WithCallerLocation records the application call site of each query as
+// code.function, code.filepath and code.lineno span attributes.
+func WithCallerLocation() TracedDBOption {
+	return func(tdb *TracedDB) {
+		tdb.recordCaller = true
+	}
+}
+
+// WithPeerName sets the database host ("host" or "host:port", e.g. from the
+// DSN) recorded as net.peer.name and peer.service on every span, so the
+// database appears in the service dependency map. Config.ServiceNameMappings
+// applies as for HTTP clients.
+//
+//	tdb := sdk.WrapDB(db, "postgresql", tracekit.WithPeerName("orders-db.internal:5432"))
+func WithPeerName(host string) TracedDBOption {
+	return func(tdb *TracedDB) {
+		tdb.peerName = host
+	}
+}
+
+// WithContextProvider sets the context used by the context-less methods
+// (Query, Exec, Begin, ...) in place of context.Background(), so legacy code
+// that doesn't plumb contexts can still attach queries to an ambient trace.
+// provider may return nil when no context is available.
+//
+//	tdb := sdk.WrapDB(db, "postgresql", tracekit.WithContextProvider(currentRequestContext))
+func WithContextProvider(provider func() context.Context) TracedDBOption {
+	return func(tdb *TracedDB) {
+		tdb.contextProvider = provider
 	}
 }
 
@@ -25,18 +87,200 @@ type TracedDB struct {
 	db       *sql.DB
 	tracer   trace.Tracer
 	dbSystem string
+	redactor *redactor
+
+	recordCaller       bool
+	peerName           string
+	peerAttrs          []attribute.KeyValue
+	contextProvider    func() context.Context
+	slowQueryThreshold time.Duration
+	maxStatementLength int
+	disableStatement   bool
+}
+
+// sdkPackagePrefix identifies SDK frames to skip when locating the caller
+const sdkPackagePrefix = "github.com/Tracekit-Dev/go-sdk/tracekit."
+
+// defaultContext returns the context for context-less calls: the provider's
+// context if one is set and returns non-nil, otherwise context.Background()
+func (tdb *TracedDB) defaultContext() context.Context {
+	if tdb.contextProvider != nil {
+		if ctx := tdb.contextProvider(); ctx != nil {
+			return ctx
+		}
+	}
+	return context.Background()
+}
+
+// startSpan starts a database span, adding the caller location if enabled
+// and flagging it on End if it exceeds the slow query threshold
+func (tdb *TracedDB) startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	if m, ok := ctx.Value(migrationKey{}).(*migrationSpan); ok {
+		return ctx, m.statement()
+	}
+
+	ctx, span := tdb.tracer.Start(ctx, name, trace.WithAttributes(tdb.peerAttrs...))
+	span = withSlowQueryThreshold(span, tdb.slowQueryThreshold)
+
+	if tdb.recordCaller {
+		if frame, ok := callerFrame(); ok {
+			span.SetAttributes(
+				attribute.String("code.function", frame.Function),
+				attribute.String("code.filepath", frame.File),
+				attribute.Int("code.lineno", frame.Line),
+			)
+		}
+	}
+
+	return ctx, span
+}
+
+// migrationKey is the context key for the active migration span
+type migrationKey struct{}
+
+// StartMigration starts a db.migration span for a schema migration. TracedDB
+// operations run with the returned context (including in transactions) don't
+// get spans of their own; they are counted on the migration span as
+// db.migration.statement_count, and their errors are recorded on it. End the
+// returned span when the migration finishes.
+//
+//	ctx, span := tdb.StartMigration(ctx, "20240601_add_orders")
+//	defer span.End()
+//	for _, stmt := range ddl {
+//		if _, err := tdb.ExecContext(ctx, stmt); err != nil { ... }
+//	}
+func (tdb *TracedDB) StartMigration(ctx context.Context, name string) (context.Context, trace.Span) {
+	ctx, span := tdb.tracer.Start(ctx, "db.migration", trace.WithAttributes(
+		attribute.String("db.system", tdb.dbSystem),
+		attribute.String("db.operation", "MIGRATE"),
+		attribute.String("db.migration.name", name),
+	))
+
+	m := &migrationSpan{Span: span}
+	return context.WithValue(trace.ContextWithSpan(ctx, m), migrationKey{}, m), m
+}
+
+// migrationSpan counts the statements of a migration and records them on End
+type migrationSpan struct {
+	trace.Span
+	statements atomic.Int64
+	failures   atomic.Int64
+}
+
+func (m *migrationSpan) End(options ...trace.SpanEndOption) {
+	m.Span.SetAttributes(
+		attribute.Int64("db.migration.statement_count", m.statements.Load()),
+		attribute.Int64("db.migration.failed_count", m.failures.Load()),
+	)
+	if m.failures.Load() > 0 {
+		m.Span.SetStatus(codes.Error, fmt.Sprintf("%d migration statements failed", m.failures.Load()))
+	}
+	m.Span.End(options...)
+}
+
+// statement counts one operation and returns a span standing in for it
+func (m *migrationSpan) statement() trace.Span {
+	m.statements.Add(1)
+	return &migrationStatementSpan{Span: noop.Span{}, migration: m}
+}
+
+// migrationStatementSpan discards everything except errors, which are
+// recorded on the migration span
+type migrationStatementSpan struct {
+	trace.Span
+	migration *migrationSpan
+}
+
+func (s *migrationStatementSpan) RecordError(err error, options ...trace.EventOption) {
+	s.migration.failures.Add(1)
+	s.migration.Span.RecordError(err, options...)
+}
+
+// setStatementAttribute records db.statement (redacted and truncated) unless
+// statement capture is disabled
+func (tdb *TracedDB) setStatementAttribute(span trace.Span, query string) {
+	if tdb.disableStatement {
+		return
+	}
+	span.SetAttributes(attribute.String("db.statement", formatSQL(tdb.redactor.redactSQL(query), tdb.maxStatementLength)))
+}
+
+// Helper to format SQL for display (truncate if longer than maxLen, 0 = unlimited)
+func formatSQL(sql string, maxLen int) string {
+	if maxLen > 0 && len(sql) > maxLen {
+		return fmt.Sprintf("%s... (truncated)", sql[:maxLen])
+	}
+	return sql
+}
+
+// setOperationAttributes records db.operation (default SELECT) and, when it
+// can be cheaply extracted, db.sql.table for a query
+func (tdb *TracedDB) setOperationAttributes(span trace.Span, query string) {
+	operation, table := parseSQLOperation(query)
+	if operation == "" {
+		operation = "SELECT"
+	}
+	span.SetAttributes(attribute.String("db.operation", operation))
+	if table != "" {
+		span.SetAttributes(attribute.String("db.sql.table", table))
+	}
+}
+
+// slowQuerySpan flags the wrapped span as a slow query if it ends more than
+// threshold after start
+type slowQuerySpan struct {
+	trace.Span
+	start     time.Time
+	threshold time.Duration
+}
+
+// withSlowQueryThreshold wraps span for slow query detection (threshold <= 0 = disabled)
+func withSlowQueryThreshold(span trace.Span, threshold time.Duration) trace.Span {
+	if threshold <= 0 {
+		return span
+	}
+	return &slowQuerySpan{Span: span, start: time.Now(), threshold: threshold}
+}
+
+func (s *slowQuerySpan) End(options ...trace.SpanEndOption) {
+	if elapsed := time.Since(s.start); elapsed > s.threshold {
+		s.Span.SetAttributes(attribute.Bool("db.slow_query", true))
+		s.Span.AddEvent("db.slow_query", trace.WithAttributes(
+			attribute.Float64("db.duration_ms", float64(elapsed.Microseconds())/1000),
+			attribute.Float64("db.slow_query_threshold_ms", float64(s.threshold.Microseconds())/1000),
+		))
+	}
+	s.Span.End(options...)
+}
+
+// callerFrame returns the first stack frame outside the SDK package
+func callerFrame() (runtime.Frame, bool) {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(3, pcs) // skip runtime.Callers, callerFrame, startSpan
+	if n == 0 {
+		return runtime.Frame{}, false
+	}
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, sdkPackagePrefix) {
+			return frame, true
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
 }
 
 // QueryContext executes a query with tracing
 func (tdb *TracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
-	ctx, span := tdb.tracer.Start(ctx, "sql.query")
+	ctx, span := tdb.startSpan(ctx, "sql.query")
 	defer span.End()
 
-	span.SetAttributes(
-		attribute.String("db.system", tdb.dbSystem),
-		attribute.String("db.statement", query),
-		attribute.String("db.operation", "SELECT"),
-	)
+	span.SetAttributes(attribute.String("db.system", tdb.dbSystem))
+	tdb.setStatementAttribute(span, query)
+	tdb.setOperationAttributes(span, query)
 
 	rows, err := tdb.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -51,37 +295,33 @@ func (tdb *TracedDB) QueryContext(ctx context.Context, query string, args ...int
 
 // Query executes a query with tracing (no context)
 func (tdb *TracedDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	return tdb.QueryContext(context.Background(), query, args...)
+	return tdb.QueryContext(tdb.defaultContext(), query, args...)
 }
 
 // QueryRowContext executes a query that returns a single row with tracing
 func (tdb *TracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
-	ctx, span := tdb.tracer.Start(ctx, "sql.query_row")
+	ctx, span := tdb.startSpan(ctx, "sql.query_row")
 	defer span.End()
 
-	span.SetAttributes(
-		attribute.String("db.system", tdb.dbSystem),
-		attribute.String("db.statement", query),
-		attribute.String("db.operation", "SELECT"),
-	)
+	span.SetAttributes(attribute.String("db.system", tdb.dbSystem))
+	tdb.setStatementAttribute(span, query)
+	tdb.setOperationAttributes(span, query)
 
 	return tdb.db.QueryRowContext(ctx, query, args...)
 }
 
 // QueryRow executes a query that returns a single row with tracing (no context)
 func (tdb *TracedDB) QueryRow(query string, args ...interface{}) *sql.Row {
-	return tdb.QueryRowContext(context.Background(), query, args...)
+	return tdb.QueryRowContext(tdb.defaultContext(), query, args...)
 }
 
 // ExecContext executes a query without returning rows, with tracing
 func (tdb *TracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	ctx, span := tdb.tracer.Start(ctx, "sql.exec")
+	ctx, span := tdb.startSpan(ctx, "sql.exec")
 	defer span.End()
 
-	span.SetAttributes(
-		attribute.String("db.system", tdb.dbSystem),
-		attribute.String("db.statement", query),
-	)
+	span.SetAttributes(attribute.String("db.system", tdb.dbSystem))
+	tdb.setStatementAttribute(span, query)
 
 	result, err := tdb.db.ExecContext(ctx, query, args...)
 	if err != nil {
@@ -101,18 +341,16 @@ func (tdb *TracedDB) ExecContext(ctx context.Context, query string, args ...inte
 
 // Exec executes a query without returning rows, with tracing (no context)
 func (tdb *TracedDB) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return tdb.ExecContext(context.Background(), query, args...)
+	return tdb.ExecContext(tdb.defaultContext(), query, args...)
 }
 
 // PrepareContext creates a prepared statement with tracing
 func (tdb *TracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
-	ctx, span := tdb.tracer.Start(ctx, "sql.prepare")
+	ctx, span := tdb.startSpan(ctx, "sql.prepare")
 	defer span.End()
 
-	span.SetAttributes(
-		attribute.String("db.system", tdb.dbSystem),
-		attribute.String("db.statement", query),
-	)
+	span.SetAttributes(attribute.String("db.system", tdb.dbSystem))
+	tdb.setStatementAttribute(span, query)
 
 	stmt, err := tdb.db.PrepareContext(ctx, query)
 	if err != nil {
@@ -127,13 +365,18 @@ func (tdb *TracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stm
 
 // Prepare creates a prepared statement with tracing (no context)
 func (tdb *TracedDB) Prepare(query string) (*sql.Stmt, error) {
-	return tdb.PrepareContext(context.Background(), query)
+	return tdb.PrepareContext(tdb.defaultContext(), query)
 }
 
-// BeginTx starts a transaction with tracing
-func (tdb *TracedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
-	ctx, span := tdb.tracer.Start(ctx, "sql.begin_transaction")
-	defer span.End()
+// BeginTx starts a transaction with tracing. The returned TracedTx keeps a
+// sql.transaction span open until Commit or Rollback, and its queries are
+// recorded as children of that span.
+func (tdb *TracedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*TracedTx, error) {
+	ctx, span := tdb.startSpan(ctx, "sql.transaction")
+	// A transaction's lifetime isn't a query duration
+	if slow, ok := span.(*slowQuerySpan); ok {
+		span = slow.Span
+	}
 
 	span.SetAttributes(
 		attribute.String("db.system", tdb.dbSystem),
@@ -144,21 +387,154 @@ func (tdb *TracedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx,
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
+		span.End()
 		return nil, err
 	}
 
-	span.SetStatus(codes.Ok, "")
-	return tx, nil
+	return &TracedTx{tx: tx, tdb: tdb, span: span, ctx: ctx}, nil
 }
 
 // Begin starts a transaction with tracing (no context)
-func (tdb *TracedDB) Begin() (*sql.Tx, error) {
-	return tdb.BeginTx(context.Background(), nil)
+func (tdb *TracedDB) Begin() (*TracedTx, error) {
+	return tdb.BeginTx(tdb.defaultContext(), nil)
+}
+
+// TracedTx is a wrapper around sql.Tx whose span covers the transaction's
+// whole lifetime, making slow lock-holding transactions visible
+type TracedTx struct {
+	tx   *sql.Tx
+	tdb  *TracedDB
+	span trace.Span
+	ctx  context.Context // BeginTx context, parent of the COMMIT/ROLLBACK span
+
+	// finished is set by the first Commit or Rollback
+	finished atomic.Bool
+}
+
+// startSpan starts a child span of the transaction span
+func (ttx *TracedTx) startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return ttx.tdb.startSpan(trace.ContextWithSpan(ctx, ttx.span), name)
+}
+
+// QueryContext executes a query within the transaction with tracing
+func (ttx *TracedTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := ttx.startSpan(ctx, "sql.query")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("db.system", ttx.tdb.dbSystem))
+	ttx.tdb.setStatementAttribute(span, query)
+	ttx.tdb.setOperationAttributes(span, query)
+
+	rows, err := ttx.tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	return rows, nil
+}
+
+// Query executes a query within the transaction with tracing (no context)
+func (ttx *TracedTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return ttx.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRowContext executes a single-row query within the transaction with tracing
+func (ttx *TracedTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := ttx.startSpan(ctx, "sql.query_row")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("db.system", ttx.tdb.dbSystem))
+	ttx.tdb.setStatementAttribute(span, query)
+	ttx.tdb.setOperationAttributes(span, query)
+
+	return ttx.tx.QueryRowContext(ctx, query, args...)
+}
+
+// QueryRow executes a single-row query within the transaction with tracing (no context)
+func (ttx *TracedTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return ttx.QueryRowContext(context.Background(), query, args...)
+}
+
+// ExecContext executes a statement within the transaction with tracing
+func (ttx *TracedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := ttx.startSpan(ctx, "sql.exec")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("db.system", ttx.tdb.dbSystem))
+	ttx.tdb.setStatementAttribute(span, query)
+
+	result, err := ttx.tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	// Add rows affected if available
+	if affected, err := result.RowsAffected(); err == nil {
+		span.SetAttributes(attribute.Int64("db.rows_affected", affected))
+	}
+
+	span.SetStatus(codes.Ok, "")
+	return result, nil
+}
+
+// Exec executes a statement within the transaction with tracing (no context)
+func (ttx *TracedTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return ttx.ExecContext(context.Background(), query, args...)
+}
+
+// Commit commits the transaction and ends its span
+func (ttx *TracedTx) Commit() error {
+	return ttx.finish("COMMIT", ttx.tx.Commit)
+}
+
+// Rollback aborts the transaction and ends its span. Calling it after
+// Commit (e.g. from a deferred Rollback) returns sql.ErrTxDone and records nothing.
+func (ttx *TracedTx) Rollback() error {
+	return ttx.finish("ROLLBACK", ttx.tx.Rollback)
+}
+
+// finish records a COMMIT/ROLLBACK child span and ends the transaction span
+func (ttx *TracedTx) finish(operation string, fn func() error) error {
+	if !ttx.finished.CompareAndSwap(false, true) {
+		return fn()
+	}
+	defer ttx.span.End()
+
+	_, span := ttx.startSpan(ttx.ctx, "sql."+strings.ToLower(operation))
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("db.system", ttx.tdb.dbSystem),
+		attribute.String("db.operation", operation),
+	)
+	ttx.span.SetAttributes(attribute.String("db.transaction.result", operation))
+
+	if err := fn(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		ttx.span.RecordError(err)
+		ttx.span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	span.SetStatus(codes.Ok, "")
+	ttx.span.SetStatus(codes.Ok, "")
+	return nil
+}
+
+// Tx returns the underlying sql.Tx
+func (ttx *TracedTx) Tx() *sql.Tx {
+	return ttx.tx
 }
 
 // PingContext verifies connection with tracing
 func (tdb *TracedDB) PingContext(ctx context.Context) error {
-	ctx, span := tdb.tracer.Start(ctx, "sql.ping")
+	ctx, span := tdb.startSpan(ctx, "sql.ping")
 	defer span.End()
 
 	span.SetAttributes(
@@ -179,7 +555,62 @@ func (tdb *TracedDB) PingContext(ctx context.Context) error {
 
 // Ping verifies connection with tracing (no context)
 func (tdb *TracedDB) Ping() error {
-	return tdb.PingContext(context.Background())
+	return tdb.PingContext(tdb.defaultContext())
+}
+
+// WithRetry runs fn, retrying it up to maxAttempts attempts in total while it
+// fails with a transient error (deadlock or serialization failure). The
+// attempts run under one sql.retry span, so their queries are grouped; each
+// failed attempt is recorded as a db.retry event and the span carries
+// db.retry.count, making retry storms caused by contention visible.
+//
+//	err := tdb.WithRetry(ctx, 3, func(ctx context.Context) error {
+//		_, err := tdb.ExecContext(ctx, "UPDATE accounts SET balance = balance - ? WHERE id = ?", amount, id)
+//		return err
+//	})
+func (tdb *TracedDB) WithRetry(ctx context.Context, maxAttempts int, fn func(ctx context.Context) error) error {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	ctx, span := tdb.tracer.Start(ctx, "sql.retry",
+		trace.WithAttributes(attribute.String("db.system", tdb.dbSystem)),
+	)
+	defer span.End()
+
+	var err error
+	retries := 0
+	for attempt := 1; ; attempt++ {
+		err = fn(ctx)
+		if err == nil || attempt >= maxAttempts || !isRetryableDBError(err) || ctx.Err() != nil {
+			break
+		}
+		retries++
+		span.AddEvent("db.retry", trace.WithAttributes(
+			attribute.Int("db.retry.attempt", attempt),
+			attribute.String("error.type", classifyGormError(err)),
+			attribute.String("exception.message", err.Error()),
+		))
+	}
+
+	span.SetAttributes(attribute.Int("db.retry.count", retries))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetStatus(codes.Ok, "")
+	return nil
+}
+
+// isRetryableDBError reports whether err is a transient contention error
+// worth retrying
+func isRetryableDBError(err error) bool {
+	switch classifyGormError(err) {
+	case gormErrorDeadlock, gormErrorSerialization:
+		return true
+	}
+	return false
 }
 
 // Close closes the database connection
@@ -211,3 +642,109 @@ func (tdb *TracedDB) Stats() sql.DBStats {
 func (tdb *TracedDB) Driver() driver.Driver {
 	return tdb.db.Driver()
 }
+
+// sqlIdentifier captures a possibly quoted, possibly schema-qualified name
+const sqlIdentifier = "([`\"\\[\\]\\w.]+)"
+
+// sqlTableTargets match the table targeted by each statement type
+var sqlTableTargets = map[string]*regexp.Regexp{
+	"SELECT": regexp.MustCompile(`(?i)\bFROM\s+` + sqlIdentifier),
+	"DELETE": regexp.MustCompile(`(?i)\bFROM\s+` + sqlIdentifier),
+	"INSERT": regexp.MustCompile(`(?i)\bINTO\s+` + sqlIdentifier),
+	"UPDATE": regexp.MustCompile(`(?i)^\s*UPDATE\s+` + sqlIdentifier),
+}
+
+// sqlQuoteStripper removes identifier quoting
+var sqlQuoteStripper = strings.NewReplacer("`", "", `"`, "", "[", "", "]", "")
+
+// sqlMainStatements are the statement keywords that may follow a WITH clause
+var sqlMainStatements = map[string]bool{
+	"SELECT": true, "INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true,
+}
+
+// parseSQLOperation extracts the leading SQL keyword and, best-effort, the
+// target table (FROM/INTO/UPDATE) from a statement. Leading comments are
+// skipped and for CTEs (WITH ...) the main statement's keyword is returned.
+func parseSQLOperation(query string) (operation, table string) {
+	query = stripLeadingSQLComments(query)
+	operation, query = sqlLeadingKeyword(query)
+	if operation == "WITH" {
+		operation, query = sqlStatementAfterCTE(query)
+	}
+
+	if re, ok := sqlTableTargets[operation]; ok {
+		if m := re.FindStringSubmatch(query); m != nil {
+			table = sqlQuoteStripper.Replace(m[1])
+		}
+	}
+	return operation, table
+}
+
+// stripLeadingSQLComments removes whitespace, "-- ..." and "/* ... */"
+// comments from the start of a statement
+func stripLeadingSQLComments(query string) string {
+	for {
+		query = strings.TrimLeftFunc(query, unicode.IsSpace)
+		switch {
+		case strings.HasPrefix(query, "--"):
+			idx := strings.IndexByte(query, '\n')
+			if idx == -1 {
+				return ""
+			}
+			query = query[idx+1:]
+		case strings.HasPrefix(query, "/*"):
+			idx := strings.Index(query, "*/")
+			if idx == -1 {
+				return ""
+			}
+			query = query[idx+2:]
+		default:
+			return query
+		}
+	}
+}
+
+// sqlLeadingKeyword returns the uppercased first word of query and query
+// starting at that word
+func sqlLeadingKeyword(query string) (string, string) {
+	query = strings.TrimLeftFunc(query, func(r rune) bool { return r == '(' || unicode.IsSpace(r) })
+	end := strings.IndexFunc(query, func(r rune) bool { return !unicode.IsLetter(r) && r != '_' })
+	if end == -1 {
+		end = len(query)
+	}
+	return strings.ToUpper(query[:end]), query
+}
+
+// sqlStatementAfterCTE finds the main statement following a WITH clause by
+// scanning for the first statement keyword outside parentheses and quotes
+func sqlStatementAfterCTE(query string) (string, string) {
+	depth := 0
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == '\'':
+			if end := strings.IndexByte(query[i+1:], '\''); end != -1 {
+				i += end + 1
+			}
+		case depth == 0 && isSQLWordStart(query, i):
+			word, rest := sqlLeadingKeyword(query[i:])
+			if sqlMainStatements[word] {
+				return word, rest
+			}
+			i += len(word) - 1
+		}
+	}
+	return "", ""
+}
+
+// isSQLWordStart reports whether a word begins at query[i]
+func isSQLWordStart(query string, i int) bool {
+	c := rune(query[i])
+	if !unicode.IsLetter(c) {
+		return false
+	}
+	return i == 0 || !(unicode.IsLetter(rune(query[i-1])) || unicode.IsDigit(rune(query[i-1])) || query[i-1] == '_')
+}
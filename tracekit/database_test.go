@@ -0,0 +1,374 @@
+package tracekit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestParseSQLOperation verifies operation and table detection for raw SQL
+func TestParseSQLOperation(t *testing.T) {
+	tests := []struct {
+		query     string
+		wantOp    string
+		wantTable string
+	}{
+		{query: "SELECT * FROM users WHERE id = ?", wantOp: "SELECT", wantTable: "users"},
+		{query: "  select name from `app`.`users`", wantOp: "SELECT", wantTable: "app.users"},
+		{query: `INSERT INTO "orders" (id) VALUES (1)`, wantOp: "INSERT", wantTable: "orders"},
+		{query: "UPDATE [accounts] SET balance = 0", wantOp: "UPDATE", wantTable: "accounts"},
+		{query: "DELETE FROM sessions WHERE expired", wantOp: "DELETE", wantTable: "sessions"},
+		{query: "SELECT 1", wantOp: "SELECT", wantTable: ""},
+		{query: "VACUUM", wantOp: "VACUUM", wantTable: ""},
+		{query: "", wantOp: "", wantTable: ""},
+		{query: "-- fetch user\nSELECT * FROM users", wantOp: "SELECT", wantTable: "users"},
+		{query: "/* app:api */ /* trace */ DELETE FROM carts", wantOp: "DELETE", wantTable: "carts"},
+		{query: "/* unterminated", wantOp: "", wantTable: ""},
+		{query: "(SELECT id FROM a) UNION (SELECT id FROM b)", wantOp: "SELECT", wantTable: "a"},
+		{query: "WITH recent AS (SELECT * FROM orders WHERE created > now()) SELECT count(*) FROM recent", wantOp: "SELECT", wantTable: "recent"},
+		{query: "WITH moved AS (DELETE FROM queue RETURNING *) INSERT INTO archive SELECT * FROM moved", wantOp: "INSERT", wantTable: "archive"},
+		{query: "with t as(select 'select (' as s) update items set s = 1", wantOp: "UPDATE", wantTable: "items"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			op, table := parseSQLOperation(tt.query)
+			if op != tt.wantOp || table != tt.wantTable {
+				t.Errorf("parseSQLOperation(%q) = (%q, %q); want (%q, %q)", tt.query, op, table, tt.wantOp, tt.wantTable)
+			}
+		})
+	}
+}
+
+// TestTracedTx verifies transaction spans stay open until Commit and parent the transaction's queries
+func TestTracedTx(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{tracer: tp.Tracer("test")}
+
+	db, err := sql.Open("tracekit-fake", "")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	tdb := sdk.WrapDB(db, "fake")
+	defer tdb.Close()
+
+	tx, err := tdb.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	if _, err := tx.ExecContext(context.Background(), "UPDATE users SET name = ?", "a"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+	if len(recorder.Ended()) != 1 {
+		t.Fatalf("transaction span ended before Commit")
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if err := tx.Rollback(); !errors.Is(err, sql.ErrTxDone) {
+		t.Errorf("Rollback() after Commit error = %v; want sql.ErrTxDone", err)
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	if len(recorder.Ended()) != 3 {
+		t.Fatalf("got %d spans; want sql.exec, sql.commit and sql.transaction", len(recorder.Ended()))
+	}
+
+	txID := spans["sql.transaction"].SpanContext().SpanID()
+	for _, name := range []string{"sql.exec", "sql.commit"} {
+		if spans[name] == nil || spans[name].Parent().SpanID() != txID {
+			t.Errorf("%s is not a child of the transaction span", name)
+		}
+	}
+}
+
+// TestSlowQuerySpan verifies spans exceeding the threshold are flagged
+func TestSlowQuerySpan(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		wantSlow  bool
+	}{
+		{name: "disabled", threshold: 0, wantSlow: false},
+		{name: "under threshold", threshold: time.Hour, wantSlow: false},
+		{name: "over threshold", threshold: time.Nanosecond, wantSlow: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			defer tp.Shutdown(context.Background())
+
+			_, span := tp.Tracer("test").Start(context.Background(), "sql.query")
+			span = withSlowQueryThreshold(span, tt.threshold)
+			time.Sleep(time.Millisecond)
+			span.End()
+
+			ended := recorder.Ended()[0]
+			var slow bool
+			for _, attr := range ended.Attributes() {
+				if attr.Key == "db.slow_query" {
+					slow = attr.Value.AsBool()
+				}
+			}
+			if slow != tt.wantSlow {
+				t.Errorf("db.slow_query = %v; want %v", slow, tt.wantSlow)
+			}
+			if hasEvent := len(ended.Events()) == 1 && ended.Events()[0].Name == "db.slow_query"; hasEvent != tt.wantSlow {
+				t.Errorf("db.slow_query event present = %v; want %v", hasEvent, tt.wantSlow)
+			}
+		})
+	}
+}
+
+// TestSetEnabledDropsDatabaseSpans verifies the kill switch also drops spans from integrations
+func TestSetEnabledDropsDatabaseSpans(t *testing.T) {
+	sdk := &SDK{}
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(&killSwitchSampler{base: sdktrace.AlwaysSample(), disabled: &sdk.disabled}),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	defer tp.Shutdown(context.Background())
+	sdk.tracer = tp.Tracer("test")
+
+	db, err := sql.Open("tracekit-fake", "")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	tdb := sdk.WrapDB(db, "fake")
+	defer tdb.Close()
+
+	sdk.SetEnabled(false)
+	if _, err := tdb.ExecContext(context.Background(), "UPDATE users SET name = ?", "a"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+	if got := len(recorder.Ended()); got != 0 {
+		t.Errorf("recorded %d spans while disabled; want 0", got)
+	}
+
+	sdk.SetEnabled(true)
+	if _, err := tdb.ExecContext(context.Background(), "UPDATE users SET name = ?", "a"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+	if got := len(recorder.Ended()); got != 1 {
+		t.Errorf("recorded %d spans after re-enabling; want 1", got)
+	}
+}
+
+// TestStartMigration verifies statements run during a migration are counted
+// on a single db.migration span instead of producing spans of their own
+func TestStartMigration(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{tracer: tp.Tracer("test")}
+
+	db, err := sql.Open("tracekit-fake", "")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	tdb := sdk.WrapDB(db, "fake")
+	defer tdb.Close()
+
+	ctx, span := tdb.StartMigration(context.Background(), "add_orders")
+	for _, stmt := range []string{"CREATE TABLE orders (id INT)", "CREATE INDEX idx ON orders (id)", "ALTER TABLE orders ADD total INT"} {
+		if _, err := tdb.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("ExecContext() error = %v", err)
+		}
+	}
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "db.migration" {
+		t.Fatalf("got %d spans; want a single db.migration span", len(spans))
+	}
+	var count int64
+	for _, attr := range spans[0].Attributes() {
+		if attr.Key == "db.migration.statement_count" {
+			count = attr.Value.AsInt64()
+		}
+	}
+	if count != 3 {
+		t.Errorf("db.migration.statement_count = %d; want 3", count)
+	}
+}
+
+// TestWithContextProvider verifies context-less calls join the provider's
+// trace and fall back to a root span when it returns nil
+func TestWithContextProvider(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{tracer: tp.Tracer("test")}
+
+	db, err := sql.Open("tracekit-fake", "")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	var ambient context.Context
+	tdb := sdk.WrapDB(db, "fake", WithContextProvider(func() context.Context { return ambient }))
+	defer tdb.Close()
+
+	var request trace.Span
+	ambient, request = tp.Tracer("test").Start(context.Background(), "request")
+	if _, err := tdb.Exec("UPDATE users SET name = ?", "a"); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	request.End()
+
+	ambient = nil
+	if _, err := tdb.Exec("UPDATE users SET name = ?", "b"); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans; want 3", len(spans))
+	}
+	if spans[0].Parent().SpanID() != request.SpanContext().SpanID() {
+		t.Error("query with ambient context is not a child of the request span")
+	}
+	if spans[2].Parent().IsValid() {
+		t.Error("query without ambient context has a parent; want root span")
+	}
+}
+
+// TestWithPeerName verifies query spans carry the mapped peer.service and net.peer.name
+func TestWithPeerName(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{
+		tracer: tp.Tracer("test"),
+		config: &Config{ServiceNameMappings: map[string]string{"10.0.0.7": "orders-db"}},
+	}
+
+	db, err := sql.Open("tracekit-fake", "")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	tdb := sdk.WrapDB(db, "postgresql", WithPeerName("10.0.0.7:5432"))
+	defer tdb.Close()
+
+	if _, err := tdb.ExecContext(context.Background(), "UPDATE orders SET status = ?", "paid"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+
+	attrs := map[string]string{}
+	for _, attr := range recorder.Ended()[0].Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs["peer.service"] != "orders-db" || attrs["net.peer.name"] != "10.0.0.7" {
+		t.Errorf("peer.service = %q, net.peer.name = %q; want orders-db, 10.0.0.7", attrs["peer.service"], attrs["net.peer.name"])
+	}
+}
+
+// TestWithRetry verifies transient failures are retried and recorded while
+// other errors fail immediately
+func TestWithRetry(t *testing.T) {
+	deadlock := errors.New("Error 1213 (40001): Deadlock found when trying to get lock")
+	tests := []struct {
+		name        string
+		errs        []error
+		wantCalls   int
+		wantRetries int64
+		wantErr     bool
+	}{
+		{name: "succeeds after deadlocks", errs: []error{deadlock, deadlock, nil}, wantCalls: 3, wantRetries: 2},
+		{name: "exhausted", errs: []error{deadlock, deadlock, deadlock, deadlock}, wantCalls: 3, wantRetries: 2, wantErr: true},
+		{name: "not retryable", errs: []error{errors.New("syntax error"), nil}, wantCalls: 1, wantRetries: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			defer tp.Shutdown(context.Background())
+			tdb := &TracedDB{tracer: tp.Tracer("test"), dbSystem: "mysql"}
+
+			calls := 0
+			err := tdb.WithRetry(context.Background(), 3, func(ctx context.Context) error {
+				calls++
+				return tt.errs[calls-1]
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WithRetry() error = %v; wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("fn called %d times; want %d", calls, tt.wantCalls)
+			}
+
+			span := recorder.Ended()[0]
+			for _, attr := range span.Attributes() {
+				if attr.Key == "db.retry.count" && attr.Value.AsInt64() != tt.wantRetries {
+					t.Errorf("db.retry.count = %d; want %d", attr.Value.AsInt64(), tt.wantRetries)
+				}
+			}
+			var events int64
+			for _, event := range span.Events() {
+				if event.Name == "db.retry" {
+					events++
+				}
+			}
+			if events != tt.wantRetries {
+				t.Errorf("got %d db.retry events; want %d", events, tt.wantRetries)
+			}
+		})
+	}
+}
+
+// TestCollectDBStats verifies pool statistics are recorded as gauges and the
+// collector stops on shutdown
+func TestCollectDBStats(t *testing.T) {
+	mr := &metricsRegistry{
+		gauges: make(map[string]*gauge),
+		buffer: newMetricsBuffer("http://localhost", "test-key", "test-service", 0, 0),
+	}
+	sdk := &SDK{metricsRegistry: mr}
+
+	db, err := sql.Open("tracekit-fake", "")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close()
+	if err := db.Ping(); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	tdb := sdk.WrapDB(db, "fake")
+
+	stop := sdk.CollectDBStats(tdb, time.Hour) // Collects once at start
+	stop()
+	sdk.stopCollectors()
+
+	tags := map[string]string{"db.system": "fake"}
+	want := map[string]float64{
+		"db.pool.open_connections": 1,
+		"db.pool.in_use":           0,
+		"db.pool.idle":             1,
+		"db.pool.wait_count":       0,
+	}
+	for name, value := range want {
+		g, ok := mr.gauges[metricKey(name, tags)]
+		if !ok {
+			t.Errorf("gauge %s not recorded", name)
+			continue
+		}
+		if g.value != value {
+			t.Errorf("%s = %v; want %v", name, g.value, value)
+		}
+	}
+}
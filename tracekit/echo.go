@@ -3,11 +3,28 @@ package tracekit
 import (
 	"github.com/labstack/echo/v4"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // EchoMiddleware returns an Echo middleware with OpenTelemetry instrumentation
 func (s *SDK) EchoMiddleware() echo.MiddlewareFunc {
-	return otelecho.Middleware(s.config.ServiceName,
+	otelMiddleware := otelecho.Middleware(s.config.ServiceName,
 		otelecho.WithTracerProvider(s.tracerProvider),
 	)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		traced := otelMiddleware(func(c echo.Context) error {
+			s.tagIdentity(trace.SpanFromContext(c.Request().Context()), c.Request())
+			return next(c)
+		})
+		return func(c echo.Context) error {
+			if s.ignorePath(c.Request().URL.Path) {
+				return next(c)
+			}
+
+			// Apply any per-request sampling override before the span starts
+			c.SetRequest(s.applyRequestSampling(c.Request()))
+			return traced(c)
+		}
+	}
 }
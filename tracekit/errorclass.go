@@ -0,0 +1,104 @@
+package tracekit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// Error categories set by the default classifiers
+const (
+	ErrorCategoryTimeout    = "timeout"
+	ErrorCategoryCanceled   = "canceled"
+	ErrorCategoryNotFound   = "not_found"
+	ErrorCategoryPermission = "permission"
+	ErrorCategoryValidation = "validation"
+	ErrorCategoryDownstream = "downstream"
+	ErrorCategoryUnknown    = "unknown"
+)
+
+// defaultErrorClassifiers map well-known sentinel errors to categories.
+// Order matters: os.ErrDeadlineExceeded must be checked before net.Error.
+var defaultErrorClassifiers = []struct {
+	target   error
+	category string
+}{
+	{context.DeadlineExceeded, ErrorCategoryTimeout},
+	{os.ErrDeadlineExceeded, ErrorCategoryTimeout},
+	{context.Canceled, ErrorCategoryCanceled},
+	{sql.ErrNoRows, ErrorCategoryNotFound},
+	{gorm.ErrRecordNotFound, ErrorCategoryNotFound},
+	{fs.ErrNotExist, ErrorCategoryNotFound},
+	{fs.ErrPermission, ErrorCategoryPermission},
+	{strconv.ErrSyntax, ErrorCategoryValidation},
+	{strconv.ErrRange, ErrorCategoryValidation},
+	{io.EOF, ErrorCategoryDownstream},
+	{io.ErrUnexpectedEOF, ErrorCategoryDownstream},
+}
+
+// RecordClassifiedError records err like RecordError and adds an
+// error.category attribute so errors can be grouped on dashboards.
+// The category comes from, in order:
+//   - Config.ErrorClassifiers (matched with errors.Is)
+//   - Config.ErrorMatchers
+//   - the default classifiers: timeout, canceled, not_found, permission,
+//     validation (strconv and JSON decoding errors) and downstream (EOF and
+//     network errors)
+//
+// It falls back to "unknown".
+func (s *SDK) RecordClassifiedError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	category := s.classifyError(err)
+	span.SetAttributes(attribute.String("error.category", category))
+	s.recordError(span, err, 4) // skip runtime.Callers, captureStackTrace, recordError, RecordClassifiedError
+}
+
+// classifyError returns the error.category for err
+func (s *SDK) classifyError(err error) string {
+	if s.config != nil {
+		for target, category := range s.config.ErrorClassifiers {
+			if errors.Is(err, target) {
+				return category
+			}
+		}
+		for _, match := range s.config.ErrorMatchers {
+			if category := match(err); category != "" {
+				return category
+			}
+		}
+	}
+
+	for _, c := range defaultErrorClassifiers {
+		if errors.Is(err, c.target) {
+			return c.category
+		}
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return ErrorCategoryValidation
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorCategoryTimeout
+		}
+		return ErrorCategoryDownstream
+	}
+
+	return ErrorCategoryUnknown
+}
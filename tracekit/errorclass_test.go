@@ -0,0 +1,63 @@
+package tracekit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestRecordClassifiedError verifies error.category comes from the
+// configured classifiers, then matchers, then the defaults
+func TestRecordClassifiedError(t *testing.T) {
+	errOutOfStock := errors.New("out of stock")
+	var jsonErr error = json.Unmarshal([]byte("{"), &struct{}{})
+
+	sdk := &SDK{config: &Config{
+		ErrorClassifiers: map[error]string{errOutOfStock: "inventory"},
+		ErrorMatchers: []func(error) string{func(err error) string {
+			if strings.Contains(err.Error(), "payment declined") {
+				return "payment"
+			}
+			return ""
+		}},
+	}}
+
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("reserve: %w", errOutOfStock), "inventory"},
+		{errors.New("payment declined"), "payment"},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), ErrorCategoryTimeout},
+		{fmt.Errorf("load user: %w", sql.ErrNoRows), ErrorCategoryNotFound},
+		{&strconv.NumError{Func: "Atoi", Num: "x", Err: strconv.ErrSyntax}, ErrorCategoryValidation},
+		{jsonErr, ErrorCategoryValidation},
+		{errors.New("something else"), ErrorCategoryUnknown},
+	}
+
+	for _, tt := range tests {
+		recorder := tracetest.NewSpanRecorder()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		_, span := tp.Tracer("test").Start(context.Background(), "op")
+		sdk.RecordClassifiedError(span, tt.err)
+		span.End()
+
+		var got string
+		for _, attr := range recorder.Ended()[0].Attributes() {
+			if attr.Key == "error.category" {
+				got = attr.Value.AsString()
+			}
+		}
+		if got != tt.want {
+			t.Errorf("category(%v) = %q; want %q", tt.err, got, tt.want)
+		}
+	}
+}
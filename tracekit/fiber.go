@@ -0,0 +1,94 @@
+package tracekit
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// FiberMiddleware returns a Fiber middleware with OpenTelemetry
+// instrumentation. It continues the W3C trace context of incoming requests,
+// starts a SERVER span named "<METHOD> <route path>" and stores its context
+// as the request's user context, so spans started from c.UserContext()
+// become children.
+//
+//	app := fiber.New()
+//	app.Use(sdk.FiberMiddleware())
+func (s *SDK) FiberMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if s.ignorePath(c.Path()) {
+			return c.Next()
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), fiberHeaderCarrier{c: c})
+
+		method := c.Method()
+		attrs := []attribute.KeyValue{
+			semconv.HTTPMethod(method),
+			attribute.String("http.target", c.OriginalURL()),
+			attribute.String("http.scheme", c.Protocol()),
+		}
+		if ip := c.IP(); ip != "" {
+			attrs = append(attrs, attribute.String("http.client_ip", ip))
+		}
+
+		ctx, span := s.tracer.Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attrs...),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+
+		// The matched route is only known once the handler chain has run
+		route := c.Route().Path
+		span.SetName(method + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route))
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// The app's error handler sets the response status after the
+			// middleware returns, so derive it from the error here
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+			span.RecordError(err)
+		}
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		return err
+	}
+}
+
+// fiberHeaderCarrier adapts Fiber request headers to propagation.TextMapCarrier
+type fiberHeaderCarrier struct {
+	c *fiber.Ctx
+}
+
+func (h fiberHeaderCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h fiberHeaderCarrier) Set(key, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h fiberHeaderCarrier) Keys() []string {
+	var keys []string
+	h.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
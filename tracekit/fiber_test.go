@@ -0,0 +1,62 @@
+package tracekit
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestFiberMiddleware verifies the SERVER span continues the incoming trace,
+// is named after the route and parents spans started from the user context
+func TestFiberMiddleware(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{tracer: tp.Tracer("test"), tracerProvider: tp}
+
+	app := fiber.New()
+	app.Use(sdk.FiberMiddleware())
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		_, child := sdk.tracer.Start(c.UserContext(), "load user")
+		child.End()
+		return c.SendString("ok")
+	})
+
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if _, err := app.Test(req); err != nil {
+		t.Fatalf("app.Test() error = %v", err)
+	}
+
+	var server, child sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		if s.SpanKind() == trace.SpanKindServer {
+			server = s
+		} else {
+			child = s
+		}
+	}
+	if server == nil || child == nil {
+		t.Fatalf("got %d spans; want a server span and a child", len(recorder.Ended()))
+	}
+	if got, want := server.Name(), "GET /users/:id"; got != want {
+		t.Errorf("server span name = %q; want %q", got, want)
+	}
+	if got := server.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("server span trace ID = %s; want the incoming trace", got)
+	}
+	if child.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("child span is not parented to the server span")
+	}
+}
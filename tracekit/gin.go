@@ -1,52 +1,186 @@
 package tracekit
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 	"go.opentelemetry.io/otel/trace"
 )
 
 // contextKey is a custom type for context keys to avoid collisions
 type contextKey string
 
-const requestContextKey contextKey = "tracekit.request_context"
+const (
+	requestContextKey contextKey = "tracekit.request_context"
+	ginSpanKey        contextKey = "tracekit.gin_span"
+)
+
+// GinMiddlewareOption is a functional option for configuring GinMiddleware
+type GinMiddlewareOption func(*ginMiddleware)
+
+// WithGinRouteSpanNames names server spans after the matched route
+// (c.FullPath(), e.g. "/users/:id") and sets it as http.route. Unmatched
+// requests are named "HTTP <METHOD>" rather than after the raw URL, keeping
+// span name cardinality bounded.
+func WithGinRouteSpanNames() GinMiddlewareOption {
+	return func(g *ginMiddleware) {
+		g.routeSpanNames = true
+	}
+}
+
+type ginMiddleware struct {
+	routeSpanNames bool
+}
 
 // GinMiddleware returns a Gin middleware with OpenTelemetry instrumentation
 // It captures request context for code monitoring and adds client IP to spans
-func (s *SDK) GinMiddleware() gin.HandlerFunc {
+func (s *SDK) GinMiddleware(opts ...GinMiddlewareOption) gin.HandlerFunc {
+	g := &ginMiddleware{}
+	for _, opt := range opts {
+		opt(g)
+	}
+
 	return func(c *gin.Context) {
+		if s.ignorePath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
 		// Extract client IP before creating span
 		clientIP := ExtractClientIP(c.Request)
 
 		// Capture request context for code monitoring
-		requestContext := extractGinRequestContext(c)
+		requestContext := extractGinRequestContext(c, s.redactor)
 
 		// Store in gin context for later retrieval
 		c.Set(string(requestContextKey), requestContext)
 
 		// Create OTEL middleware with client IP as a span attribute
 		// We need to create it per-request so we can include the IP
-		opts := []otelgin.Option{
-			otelgin.WithTracerProvider(s.tracerProvider),
+		otelOpts := []otelgin.Option{
+			otelgin.WithTracerProvider(s.ginTracerProvider()),
 		}
 
 		// Add client IP as initial span attribute if available
 		if clientIP != "" {
-			opts = append(opts, otelgin.WithSpanStartOptions(
+			otelOpts = append(otelOpts, otelgin.WithSpanStartOptions(
 				trace.WithAttributes(attribute.String("http.client_ip", clientIP)),
 			))
 		}
 
-		otelMiddleware := otelgin.Middleware(s.config.ServiceName, opts...)
+		otelMiddleware := otelgin.Middleware(s.config.ServiceName, otelOpts...)
+
+		// Apply any per-request sampling override before the span starts
+		c.Request = s.applyRequestSampling(c.Request)
+
+		// otelgin ends its span with a plain defer, so a panicking handler
+		// would end it before we could record anything. Its End is postponed
+		// until here (see ginTracerProvider).
+		holder := &ginSpanHolder{}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), ginSpanKey, holder))
+		defer func() {
+			if holder.span == nil {
+				return
+			}
+			if g.routeSpanNames {
+				nameGinSpan(holder.span, c)
+			}
+			s.tagIdentity(holder.span, c.Request)
+			if r := recover(); r != nil {
+				s.RecordError(holder.span, fmt.Errorf("panic: %v", r))
+				holder.span.SetAttributes(attribute.Int("http.status_code", http.StatusInternalServerError))
+				if !c.Writer.Written() {
+					c.Writer.WriteHeader(http.StatusInternalServerError)
+				}
+				holder.span.End(holder.endOpts...)
+				// Re-panic so gin.Recovery (or the server) still handles it
+				panic(r)
+			}
+			holder.span.End(holder.endOpts...)
+		}()
 
 		// Call OTEL middleware
 		otelMiddleware(c)
 	}
 }
 
-// extractGinRequestContext extracts HTTP request details from Gin context
-func extractGinRequestContext(c *gin.Context) map[string]interface{} {
+// nameGinSpan renames span after the route Gin matched for c
+func nameGinSpan(span trace.Span, c *gin.Context) {
+	route := c.FullPath()
+	if route == "" {
+		span.SetName("HTTP " + c.Request.Method)
+		return
+	}
+	span.SetName(route)
+	span.SetAttributes(semconv.HTTPRoute(route))
+}
+
+// ginSpanHolder receives the span otelgin starts for a request and the
+// options it ended it with
+type ginSpanHolder struct {
+	span    trace.Span
+	endOpts []trace.SpanEndOption
+}
+
+// ginTracerProvider returns the provider handed to otelgin. Spans started for
+// a request carrying a ginSpanHolder only record their End call, leaving
+// GinMiddleware to end them once it has checked for a panic.
+func (s *SDK) ginTracerProvider() trace.TracerProvider {
+	var tp trace.TracerProvider = otel.GetTracerProvider()
+	if s.tracerProvider != nil {
+		tp = s.tracerProvider
+	}
+	return ginTracerProvider{tp}
+}
+
+type ginTracerProvider struct {
+	trace.TracerProvider
+}
+
+func (p ginTracerProvider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return ginTracer{p.TracerProvider.Tracer(name, opts...)}
+}
+
+type ginTracer struct {
+	trace.Tracer
+}
+
+func (t ginTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	ctx, span := t.Tracer.Start(ctx, name, opts...)
+	holder, ok := ctx.Value(ginSpanKey).(*ginSpanHolder)
+	if !ok || holder.span != nil {
+		return ctx, span
+	}
+	holder.span = span
+	deferred := &deferredEndSpan{Span: span, holder: holder}
+	return trace.ContextWithSpan(ctx, deferred), deferred
+}
+
+// deferredEndSpan stores End's options, stamped with the time it was called,
+// instead of ending the span
+type deferredEndSpan struct {
+	trace.Span
+	holder *ginSpanHolder
+}
+
+func (s *deferredEndSpan) End(opts ...trace.SpanEndOption) {
+	s.holder.endOpts = append(opts, trace.WithTimestamp(time.Now()))
+}
+
+// extractGinRequestContext extracts HTTP request details from Gin context,
+// redacting sensitive headers and query parameters
+func extractGinRequestContext(c *gin.Context, r *redactor) map[string]interface{} {
+	if r == nil {
+		r = newRedactor(nil)
+	}
+
 	ctx := make(map[string]interface{})
 
 	// Basic request info
@@ -60,7 +194,7 @@ func extractGinRequestContext(c *gin.Context) map[string]interface{} {
 		params := make(map[string]string)
 		for key, values := range c.Request.URL.Query() {
 			if len(values) > 0 {
-				params[key] = values[0]
+				params[key] = r.redact(key, values[0])
 			}
 		}
 		ctx["query_params"] = params
@@ -69,13 +203,8 @@ func extractGinRequestContext(c *gin.Context) map[string]interface{} {
 	// Headers (filtered for security)
 	headers := make(map[string]string)
 	for key, values := range c.Request.Header {
-		// Skip sensitive headers
-		if key == "Authorization" || key == "Cookie" || key == "X-Api-Key" {
-			headers[key] = "[REDACTED]"
-			continue
-		}
 		if len(values) > 0 {
-			headers[key] = values[0]
+			headers[key] = r.redactHeader(key, values[0])
 		}
 	}
 	ctx["headers"] = headers
@@ -0,0 +1,114 @@
+package tracekit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestGinMiddlewarePanic verifies a panicking handler leaves an exception
+// event and error status on the span and still reaches gin's recovery
+func TestGinMiddlewarePanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{config: &Config{ServiceName: "test"}, tracer: tp.Tracer("test"), tracerProvider: tp}
+
+	recovered := false
+	router := gin.New()
+	router.Use(gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		recovered = true
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	router.Use(sdk.GinMiddleware())
+	router.GET("/boom", func(c *gin.Context) { panic("boom") })
+	router.GET("/ok", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/boom", nil))
+	if !recovered {
+		t.Error("panic did not reach gin's recovery")
+	}
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d; want 500", rec.Code)
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ok", nil))
+
+	ended := recorder.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(ended))
+	}
+	panicked, ok := ended[0], ended[1]
+	if panicked.Status().Code != codes.Error {
+		t.Errorf("panic span status = %v; want Error", panicked.Status().Code)
+	}
+	hasException := false
+	for _, event := range panicked.Events() {
+		if event.Name == "exception" {
+			hasException = true
+		}
+	}
+	if !hasException {
+		t.Error("panic span has no exception event")
+	}
+	if ok.Status().Code == codes.Error || len(ok.Events()) != 0 {
+		t.Errorf("ok span status = %v, events = %d; want no error", ok.Status().Code, len(ok.Events()))
+	}
+}
+
+// TestGinRouteSpanNames verifies spans are named after the matched route and
+// unmatched requests fall back to "HTTP <METHOD>"
+func TestGinRouteSpanNames(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name      string
+		target    string
+		wantName  string
+		wantRoute string
+	}{
+		{name: "matched route", target: "/users/42?tab=orders", wantName: "/users/:id", wantRoute: "/users/:id"},
+		{name: "wildcard route", target: "/files/a/b.txt", wantName: "/files/*path", wantRoute: "/files/*path"},
+		{name: "no match", target: "/missing/7", wantName: "HTTP GET"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			defer tp.Shutdown(context.Background())
+			sdk := &SDK{config: &Config{ServiceName: "test"}, tracer: tp.Tracer("test"), tracerProvider: tp}
+
+			router := gin.New()
+			router.Use(sdk.GinMiddleware(WithGinRouteSpanNames()))
+			router.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+			router.GET("/files/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.target, nil))
+
+			ended := recorder.Ended()
+			if len(ended) != 1 {
+				t.Fatalf("expected 1 span, got %d", len(ended))
+			}
+			if got := ended[0].Name(); got != tt.wantName {
+				t.Errorf("span name = %q; want %q", got, tt.wantName)
+			}
+			var route string
+			for _, attr := range ended[0].Attributes() {
+				if attr.Key == "http.route" {
+					route = attr.Value.AsString()
+				}
+			}
+			if route != tt.wantRoute {
+				t.Errorf("http.route = %q; want %q", route, tt.wantRoute)
+			}
+		})
+	}
+}
@@ -1,24 +1,190 @@
 package tracekit
 
 import (
+	"context"
+	"fmt"
+	"sync"
+
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
 )
 
+// maxGRPCPayloadLog caps each payload logged by Config.GRPCCaptureMessages
+const maxGRPCPayloadLog = 1024
+
 // GRPCServerInterceptors returns gRPC server interceptors with OpenTelemetry
 func (s *SDK) GRPCServerInterceptors() []grpc.ServerOption {
 	return []grpc.ServerOption{
-		grpc.StatsHandler(otelgrpc.NewServerHandler(
+		grpc.StatsHandler(s.wrapGRPCStatsHandler(otelgrpc.NewServerHandler(
 			otelgrpc.WithTracerProvider(s.tracerProvider),
-		)),
+		), false)),
 	}
 }
 
 // GRPCClientInterceptors returns gRPC client interceptors with OpenTelemetry
 func (s *SDK) GRPCClientInterceptors() []grpc.DialOption {
 	return []grpc.DialOption{
-		grpc.WithStatsHandler(otelgrpc.NewClientHandler(
+		grpc.WithStatsHandler(s.wrapGRPCStatsHandler(otelgrpc.NewClientHandler(
 			otelgrpc.WithTracerProvider(s.tracerProvider),
-		)),
+		), true)),
+	}
+}
+
+// wrapGRPCStatsHandler wraps an otelgrpc stats handler to classify client
+// status codes like the server does, to apply Config.NonErrorGRPCCodes and,
+// with Config.GRPCCaptureMessages,
+// to record message sizes and log payloads of failed calls
+func (s *SDK) wrapGRPCStatsHandler(base stats.Handler, client bool) stats.Handler {
+	h := &grpcStatsHandler{Handler: base, client: client, logger: s.logger, redactor: s.redactor}
+	if s.config != nil {
+		h.capture = s.config.GRPCCaptureMessages
+		if len(s.config.NonErrorGRPCCodes) > 0 {
+			h.nonError = make(map[codes.Code]bool, len(s.config.NonErrorGRPCCodes))
+			for _, code := range s.config.NonErrorGRPCCodes {
+				h.nonError[code] = true
+			}
+		}
+	}
+	return h
+}
+
+// grpcStatsHandler decorates the otelgrpc handler, which creates and ends
+// the RPC span
+type grpcStatsHandler struct {
+	stats.Handler
+	client   bool
+	capture  bool
+	logger   *diagLogger
+	redactor *redactor
+	nonError map[codes.Code]bool // Config.NonErrorGRPCCodes
+}
+
+// grpcRPCKey is the context key for the per-RPC grpcRPC
+type grpcRPCKey struct{}
+
+// grpcRPC accumulates the messages of one RPC
+type grpcRPC struct {
+	method string
+
+	mu           sync.Mutex
+	requestSize  int
+	responseSize int
+	request      string // first request message, for logging
+	response     string // first response message, for logging
+}
+
+func (h *grpcStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	ctx = h.Handler.TagRPC(ctx, info)
+	if h.capture {
+		ctx = context.WithValue(ctx, grpcRPCKey{}, &grpcRPC{method: info.FullMethodName})
+	}
+	return ctx
+}
+
+func (h *grpcStatsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
+	rpc, _ := ctx.Value(grpcRPCKey{}).(*grpcRPC)
+
+	switch rs := rs.(type) {
+	case *stats.InPayload:
+		// Incoming payloads are responses on the client, requests on the server
+		if rpc != nil {
+			rpc.addPayload(!h.client, rs.Length, rs.Payload)
+		}
+	case *stats.OutPayload:
+		if rpc != nil {
+			rpc.addPayload(h.client, rs.Length, rs.Payload)
+		}
+	case *stats.End:
+		if rpc != nil {
+			h.finish(ctx, rpc, rs.Error)
+		}
+		if code := status.Code(rs.Error); code != codes.OK && !h.isError(code) {
+			// otelgrpc marks every non-OK client call as an error; keep the
+			// span status unset for expected outcomes such as NotFound
+			ctx = trace.ContextWithSpan(ctx, unsetErrorSpan{trace.SpanFromContext(ctx)})
+		}
+	}
+
+	h.Handler.HandleRPC(ctx, rs)
+}
+
+// finish records the message sizes and logs the payloads of a failed call
+func (h *grpcStatsHandler) finish(ctx context.Context, rpc *grpcRPC, err error) {
+	rpc.mu.Lock()
+	defer rpc.mu.Unlock()
+
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int("rpc.grpc.request.size", rpc.requestSize),
+		attribute.Int("rpc.grpc.response.size", rpc.responseSize),
+	)
+
+	if code := status.Code(err); code != codes.OK {
+		h.logger.warnf("TraceKit: gRPC %s failed with %s: request=%s response=%s",
+			rpc.method, code,
+			h.redactor.redact("rpc.grpc.request", rpc.request),
+			h.redactor.redact("rpc.grpc.response", rpc.response))
+	}
+}
+
+func (rpc *grpcRPC) addPayload(request bool, length int, payload interface{}) {
+	rpc.mu.Lock()
+	defer rpc.mu.Unlock()
+
+	if request {
+		rpc.requestSize += length
+		if rpc.request == "" {
+			rpc.request = formatGRPCPayload(payload)
+		}
+		return
+	}
+	rpc.responseSize += length
+	if rpc.response == "" {
+		rpc.response = formatGRPCPayload(payload)
+	}
+}
+
+// formatGRPCPayload renders a message (protobuf text format via String)
+// truncated to maxGRPCPayloadLog bytes
+func formatGRPCPayload(payload interface{}) string {
+	text := fmt.Sprint(payload)
+	if len(text) > maxGRPCPayloadLog {
+		text = text[:maxGRPCPayloadLog] + "... (truncated)"
+	}
+	return text
+}
+
+// isError reports whether code marks the span as an error
+func (h *grpcStatsHandler) isError(code codes.Code) bool {
+	return !h.nonError[code] && grpcCodeIsError(code)
+}
+
+// grpcCodeIsError reports whether a status code indicates a failure of the
+// service rather than an expected outcome for the caller (NotFound,
+// AlreadyExists, InvalidArgument, ...), matching otelgrpc's server mapping
+func grpcCodeIsError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented,
+		codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
+// unsetErrorSpan ignores attempts to mark the span as an error
+type unsetErrorSpan struct {
+	trace.Span
+}
+
+func (s unsetErrorSpan) SetStatus(code otelcodes.Code, description string) {
+	if code == otelcodes.Error {
+		return
 	}
+	s.Span.SetStatus(code, description)
 }
@@ -0,0 +1,68 @@
+package tracekit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+// TestGRPCStatsHandler verifies client status classification, including
+// Config.NonErrorGRPCCodes, and message size attributes
+func TestGRPCStatsHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus otelcodes.Code
+	}{
+		{"ok", nil, otelcodes.Unset},
+		{"not found", status.Error(codes.NotFound, "no such order"), otelcodes.Unset},
+		{"internal", status.Error(codes.Internal, "boom"), otelcodes.Error},
+		{"configured non-error", status.Error(codes.Unavailable, "failover"), otelcodes.Unset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			defer tp.Shutdown(context.Background())
+
+			sdk := &SDK{config: &Config{
+				GRPCCaptureMessages: true,
+				NonErrorGRPCCodes:   []codes.Code{codes.Unavailable},
+			}, tracerProvider: tp}
+			h := sdk.wrapGRPCStatsHandler(otelgrpc.NewClientHandler(otelgrpc.WithTracerProvider(tp)), true)
+
+			ctx := h.TagRPC(context.Background(), &stats.RPCTagInfo{FullMethodName: "/orders.Orders/Get"})
+			now := time.Now()
+			h.HandleRPC(ctx, &stats.Begin{Client: true, BeginTime: now})
+			h.HandleRPC(ctx, &stats.OutPayload{Client: true, Payload: "id: 7", Length: 5})
+			h.HandleRPC(ctx, &stats.InPayload{Client: true, Payload: "total: 12", Length: 9})
+			h.HandleRPC(ctx, &stats.End{Client: true, BeginTime: now, EndTime: now, Error: tt.err})
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("recorded %d spans; want 1", len(spans))
+			}
+			if got := spans[0].Status().Code; got != tt.wantStatus {
+				t.Errorf("status = %v; want %v", got, tt.wantStatus)
+			}
+			sizes := map[string]int64{}
+			for _, attr := range spans[0].Attributes() {
+				if attr.Key == "rpc.grpc.request.size" || attr.Key == "rpc.grpc.response.size" {
+					sizes[string(attr.Key)] = attr.Value.AsInt64()
+				}
+			}
+			if sizes["rpc.grpc.request.size"] != 5 || sizes["rpc.grpc.response.size"] != 9 {
+				t.Errorf("sizes = %v; want request 5, response 9", sizes)
+			}
+		})
+	}
+}
@@ -1,12 +1,18 @@
 package tracekit
 
 import (
+	"io"
 	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"regexp"
 	"strings"
+	"sync"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -36,13 +42,112 @@ func (m *clientIPMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // HTTPHandler wraps an http.Handler with OpenTelemetry instrumentation
 // and automatically captures client IP address
 func (s *SDK) HTTPHandler(handler http.Handler, operation string) http.Handler {
+	return s.httpHandler(handler, operation)
+}
+
+// httpHandler implements HTTPHandler, passing opts on to otelhttp
+func (s *SDK) httpHandler(handler http.Handler, operation string, opts ...otelhttp.Option) http.Handler {
 	// Wrap with OTEL instrumentation
-	otelHandler := otelhttp.NewHandler(handler, operation,
-		otelhttp.WithTracerProvider(s.tracerProvider),
-	)
+	opts = append([]otelhttp.Option{otelhttp.WithTracerProvider(s.tracerProvider)}, opts...)
+	otelHandler := otelhttp.NewHandler(s.wrapIdentityHandler(s.wrapStatusHandler(s.wrapHeaderHandler(s.wrapBodyHandler(handler)))), operation, opts...)
 
 	// Wrap with client IP middleware
-	return &clientIPMiddleware{handler: otelHandler}
+	return s.withIgnorePaths(handler, &clientIPMiddleware{handler: s.withRequestSampling(otelHandler)})
+}
+
+// ignorePath reports whether requests for path match Config.IgnorePaths
+// and must not be traced. Patterns match exactly, or by prefix when they
+// end in "*" ("/internal/*").
+func (s *SDK) ignorePath(path string) bool {
+	if s.config == nil {
+		return false
+	}
+	for _, pattern := range s.config.IgnorePaths {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// withIgnorePaths sends requests for Config.IgnorePaths straight to
+// untraced, so no span is started for them
+func (s *SDK) withIgnorePaths(untraced, traced http.Handler) http.Handler {
+	if s.config == nil || len(s.config.IgnorePaths) == 0 {
+		return traced
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.ignorePath(r.URL.Path) {
+			untraced.ServeHTTP(w, r)
+			return
+		}
+		traced.ServeHTTP(w, r)
+	})
+}
+
+// withRequestSampling evaluates Config.RequestSampler before next starts the
+// server span, passing any override to the sampler through the context
+func (s *SDK) withRequestSampling(next http.Handler) http.Handler {
+	if s.config == nil || s.config.RequestSampler == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, s.applyRequestSampling(r))
+	})
+}
+
+// applyRequestSampling returns r with its sampling override, if any, in the context
+func (s *SDK) applyRequestSampling(r *http.Request) *http.Request {
+	if s.config == nil || s.config.RequestSampler == nil {
+		return r
+	}
+	if override := s.config.RequestSampler(r); override != SamplingDefault {
+		return r.WithContext(withSamplingOverride(r.Context(), override))
+	}
+	return r
+}
+
+// HTTPHandlerWithPattern wraps an http.ServeMux (or any handler that sets
+// Request.Pattern, as Go 1.22+ ServeMux does) and names each span after the
+// matched pattern, e.g. "GET /users/{id}", instead of a fixed operation name.
+// Unmatched requests keep the name "http.request".
+func (s *SDK) HTTPHandlerWithPattern(handler http.Handler) http.Handler {
+	// Runs inside otelhttp: ServeMux sets Pattern on the request it receives,
+	// which is otelhttp's copy, so it is only visible from here
+	withRoute := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+
+		if r.Pattern != "" {
+			_, route := splitPattern(r)
+			trace.SpanFromContext(r.Context()).SetAttributes(semconv.HTTPRoute(route))
+		}
+	})
+
+	// otelhttp renames the span from the formatter once the handler returns
+	// with Pattern set, so the name must come from the formatter too
+	return s.httpHandler(withRoute, "http.request",
+		otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
+			if r.Pattern == "" {
+				return operation
+			}
+			method, route := splitPattern(r)
+			return method + " " + route
+		}),
+	)
+}
+
+// splitPattern splits r.Pattern ("GET /users/{id}" or "/users/{id}") into
+// its method, defaulting to r.Method, and route
+func splitPattern(r *http.Request) (method, route string) {
+	method, route, hasMethod := strings.Cut(r.Pattern, " ")
+	if !hasMethod {
+		return r.Method, r.Pattern
+	}
+	return method, route
 }
 
 // HTTPMiddleware returns a middleware function for standard http.Handler chains
@@ -53,18 +158,21 @@ func (s *SDK) HTTPMiddleware(operation string) func(http.Handler) http.Handler {
 }
 
 // HTTPClient wraps an http.Client with OpenTelemetry instrumentation
-// Automatically creates CLIENT spans for outgoing HTTP calls with peer.service attribute
+// Automatically creates CLIENT spans for outgoing HTTP calls with peer.service attribute.
+// It returns a copy and leaves client (default: http.DefaultClient)
+// untouched; a client that is already instrumented is copied as-is.
 func (s *SDK) HTTPClient(client *http.Client) *http.Client {
 	if client == nil {
 		client = http.DefaultClient
 	}
 
-	client.Transport = otelhttp.NewTransport(client.Transport,
-		otelhttp.WithTracerProvider(s.tracerProvider),
-		otelhttp.WithSpanOptions(
-			trace.WithSpanKind(trace.SpanKindClient),
-		),
-	)
+	clone := *client
+	client = &clone
+	if _, ok := client.Transport.(*peerServiceTransport); ok {
+		return client // Already instrumented
+	}
+
+	client.Transport = otelhttp.NewTransport(s.wrapClientTransport(client.Transport), s.clientTransportOptions()...)
 
 	// Wrap with our custom transport to add peer.service
 	client.Transport = &peerServiceTransport{
@@ -77,17 +185,147 @@ func (s *SDK) HTTPClient(client *http.Client) *http.Client {
 
 // WrapRoundTripper wraps an http.RoundTripper with OpenTelemetry instrumentation
 func (s *SDK) WrapRoundTripper(rt http.RoundTripper) http.RoundTripper {
-	wrapped := otelhttp.NewTransport(rt,
+	if _, ok := rt.(*peerServiceTransport); ok {
+		return rt // Already instrumented
+	}
+
+	wrapped := otelhttp.NewTransport(s.wrapClientTransport(rt), s.clientTransportOptions()...)
+
+	// Wrap with our custom transport to add peer.service
+	return &peerServiceTransport{
+		base: wrapped,
+	}
+}
+
+// clientTransportOptions configures the otelhttp client transport, naming
+// spans "GET /accounts/{id}" when Config.HTTPClientPathTemplater is set
+func (s *SDK) clientTransportOptions() []otelhttp.Option {
+	opts := []otelhttp.Option{
 		otelhttp.WithTracerProvider(s.tracerProvider),
 		otelhttp.WithSpanOptions(
 			trace.WithSpanKind(trace.SpanKindClient),
 		),
-	)
+	}
+	if s.config != nil && s.config.HTTPClientPathTemplater != nil {
+		templater := s.config.HTTPClientPathTemplater
+		opts = append(opts, otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + templater(r.URL)
+		}))
+	}
+	return opts
+}
 
-	// Wrap with our custom transport to add peer.service
-	return &peerServiceTransport{
-		base: wrapped,
+// idPathSegments match URL path segments that identify a resource rather
+// than name a route: numbers, UUIDs, long hex strings and prefixed IDs
+// such as "abc-123" or "order_42"
+var idPathSegments = []*regexp.Regexp{
+	regexp.MustCompile(`^\d+$`),
+	regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`),
+	regexp.MustCompile(`^[0-9a-fA-F]{16,}$`),
+	regexp.MustCompile(`^[A-Za-z]+[-_]\d+$`),
+}
+
+// NormalizeURLPath is a Config.HTTPClientPathTemplater that replaces ID-like
+// path segments with {id}, e.g. /accounts/abc-123/balance ->
+// /accounts/{id}/balance. Version segments such as v2 are kept.
+func NormalizeURLPath(u *url.URL) string {
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		for _, re := range idPathSegments {
+			if re.MatchString(segment) {
+				segments[i] = "{id}"
+				break
+			}
+		}
+	}
+	path := strings.Join(segments, "/")
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// wrapClientTransport applies the optional transports that record on the
+// CLIENT span; they all run inside the otelhttp transport
+func (s *SDK) wrapClientTransport(rt http.RoundTripper) http.RoundTripper {
+	return s.wrapStatusTransport(s.wrapConnectionTransport(s.wrapTrailerTransport(s.wrapHeaderTransport(s.wrapBodyTransport(rt)))))
+}
+
+// nonErrorStatusCodes returns Config.NonErrorStatusCodes as a set, or nil
+func (s *SDK) nonErrorStatusCodes() map[int]bool {
+	if s.config == nil || len(s.config.NonErrorStatusCodes) == 0 {
+		return nil
+	}
+	set := make(map[int]bool, len(s.config.NonErrorStatusCodes))
+	for _, code := range s.config.NonErrorStatusCodes {
+		set[code] = true
+	}
+	return set
+}
+
+// wrapStatusTransport marks client spans for Config.NonErrorStatusCodes as
+// Ok. otelhttp sets the Error status after the response arrives, but an Ok
+// status is final and the later Error is ignored.
+func (s *SDK) wrapStatusTransport(rt http.RoundTripper) http.RoundTripper {
+	nonError := s.nonErrorStatusCodes()
+	if nonError == nil {
+		return rt
 	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := rt.RoundTrip(req)
+		if err == nil && nonError[resp.StatusCode] {
+			trace.SpanFromContext(req.Context()).SetStatus(codes.Ok, "")
+		}
+		return resp, err
+	})
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// wrapStatusHandler is the server-side wrapStatusTransport. It must run
+// inside the otelhttp handler.
+func (s *SDK) wrapStatusHandler(next http.Handler) http.Handler {
+	nonError := s.nonErrorStatusCodes()
+	if nonError == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		if nonError[sw.status] {
+			trace.SpanFromContext(r.Context()).SetStatus(codes.Ok, "")
+		}
+	})
+}
+
+// statusResponseWriter remembers the response status code
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+}
+
+func (w *statusResponseWriter) WriteHeader(statusCode int) {
+	if !w.wrote {
+		w.status, w.wrote = statusCode, true
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusResponseWriter) Write(p []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach Flush, Hijack etc.
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
 }
 
 // peerServiceTransport adds peer.service attribute to outgoing HTTP requests
@@ -114,12 +352,145 @@ func (t *peerServiceTransport) RoundTrip(req *http.Request) (*http.Response, err
 	return t.base.RoundTrip(req)
 }
 
+// wrapTrailerTransport adds trailer capture when Config.HTTPClientTrailers is
+// set. It must sit inside the otelhttp transport so the request context
+// carries the CLIENT span, which otelhttp ends only after the body is drained.
+func (s *SDK) wrapTrailerTransport(rt http.RoundTripper) http.RoundTripper {
+	if s.config == nil || len(s.config.HTTPClientTrailers) == 0 {
+		return rt
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &trailerTransport{
+		base:     rt,
+		trailers: s.config.HTTPClientTrailers,
+		redactor: s.redactor,
+	}
+}
+
+// trailerTransport records configured response trailers as span attributes
+type trailerTransport struct {
+	base     http.RoundTripper
+	trailers []string
+	redactor *redactor
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *trailerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		return resp, err
+	}
+	// Upgraded connections hand back an io.ReadWriteCloser body that must not
+	// be wrapped, and carry no trailers anyway
+	if resp.StatusCode == http.StatusSwitchingProtocols {
+		return resp, nil
+	}
+
+	span := trace.SpanFromContext(req.Context())
+	if span.SpanContext().IsValid() {
+		// Trailers are only populated once the body has been read to EOF
+		resp.Body = &trailerBody{ReadCloser: resp.Body, resp: resp, span: span, transport: t}
+	}
+	return resp, nil
+}
+
+// trailerBody records response trailers when the body reaches EOF or is closed
+type trailerBody struct {
+	io.ReadCloser
+	resp      *http.Response
+	span      trace.Span
+	transport *trailerTransport
+	once      sync.Once
+}
+
+func (b *trailerBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.record()
+	}
+	return n, err
+}
+
+func (b *trailerBody) Close() error {
+	b.record()
+	return b.ReadCloser.Close()
+}
+
+func (b *trailerBody) record() {
+	b.once.Do(func() {
+		for _, name := range b.transport.trailers {
+			value := b.resp.Trailer.Get(name)
+			if value == "" {
+				continue
+			}
+			b.span.SetAttributes(attribute.String(
+				"http.response.trailer."+strings.ToLower(name),
+				b.transport.redactor.redactHeader(name, value),
+			))
+		}
+	})
+}
+
+// wrapConnectionTransport adds connection attributes when
+// Config.HTTPClientConnectionAttributes is set. Like the trailer transport it
+// must sit inside the otelhttp transport to see the CLIENT span.
+func (s *SDK) wrapConnectionTransport(rt http.RoundTripper) http.RoundTripper {
+	if s.config == nil || !s.config.HTTPClientConnectionAttributes {
+		return rt
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &connectionTransport{base: rt}
+}
+
+// connectionTransport records connection reuse and protocol on client spans
+type connectionTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *connectionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	span := trace.SpanFromContext(req.Context())
+	if !span.SpanContext().IsValid() {
+		return t.base.RoundTrip(req)
+	}
+
+	clientTrace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			span.SetAttributes(
+				attribute.Bool("http.connection.reused", info.Reused),
+				attribute.Bool("http.connection.was_idle", info.WasIdle),
+			)
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), clientTrace))
+
+	resp, err := t.base.RoundTrip(req)
+	if resp != nil {
+		protocol := "h1"
+		if resp.ProtoMajor == 2 {
+			protocol = "h2"
+		}
+		span.SetAttributes(attribute.String("http.protocol", protocol))
+	}
+	return resp, err
+}
+
 // extractServiceName extracts or maps service name from hostname
 func (t *peerServiceTransport) extractServiceName(hostname string) string {
+	return mapServiceName(hostname, t.serviceNameMappings)
+}
+
+// mapServiceName returns the service name configured for hostname in
+// mappings (with or without its port), falling back to extractServiceName
+func mapServiceName(hostname string, mappings map[string]string) string {
 	// First, check if there's a configured mapping for this hostname
 	// This allows mapping localhost:port to actual service names
-	if t.serviceNameMappings != nil {
-		if serviceName, ok := t.serviceNameMappings[hostname]; ok {
+	if mappings != nil {
+		if serviceName, ok := mappings[hostname]; ok {
 			return serviceName
 		}
 
@@ -128,7 +499,7 @@ func (t *peerServiceTransport) extractServiceName(hostname string) string {
 		if idx := strings.Index(hostname, ":"); idx != -1 {
 			hostWithoutPort = hostname[:idx]
 		}
-		if serviceName, ok := t.serviceNameMappings[hostWithoutPort]; ok {
+		if serviceName, ok := mappings[hostWithoutPort]; ok {
 			return serviceName
 		}
 	}
@@ -137,6 +508,24 @@ func (t *peerServiceTransport) extractServiceName(hostname string) string {
 	return extractServiceName(hostname)
 }
 
+// peerAttributes returns net.peer.name and peer.service for a datastore
+// host ("host" or "host:port"), mapped like outgoing HTTP calls. Returns nil
+// for an empty host.
+func peerAttributes(host string, mappings map[string]string) []attribute.KeyValue {
+	if host == "" {
+		return nil
+	}
+
+	name := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		name = h
+	}
+	return []attribute.KeyValue{
+		attribute.String("net.peer.name", name),
+		attribute.String("peer.service", mapServiceName(host, mappings)),
+	}
+}
+
 // extractServiceName extracts service name from hostname
 func extractServiceName(hostname string) string {
 	// Handle Kubernetes service names
@@ -0,0 +1,486 @@
+package tracekit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestHTTPClientTrailers verifies configured response trailers are recorded on the client span
+func TestHTTPClientTrailers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Backend-Instance")
+		w.Write([]byte("ok"))
+		w.Header().Set("X-Backend-Instance", "backend-7")
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+
+	sdk := &SDK{
+		config:         &Config{HTTPClientTrailers: []string{"X-Backend-Instance"}},
+		tracer:         tp.Tracer("test"),
+		tracerProvider: tp,
+	}
+	client := sdk.HTTPClient(&http.Client{})
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	var found bool
+	for _, span := range recorder.Ended() {
+		if span.SpanKind() != trace.SpanKindClient {
+			continue
+		}
+		for _, attr := range span.Attributes() {
+			if attr.Key == "http.response.trailer.x-backend-instance" && attr.Value.AsString() == "backend-7" {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Error("client span missing http.response.trailer.x-backend-instance attribute")
+	}
+}
+
+// rwcBody is an upgraded-connection body implementing io.ReadWriteCloser
+type rwcBody struct{ io.ReadCloser }
+
+func (rwcBody) Write(p []byte) (int, error) { return len(p), nil }
+
+// TestTrailerTransportSwitchingProtocols verifies upgraded responses keep their
+// io.ReadWriteCloser body
+func TestTrailerTransportSwitchingProtocols(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	defer tp.Shutdown(context.Background())
+
+	base := roundTripperFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusSwitchingProtocols,
+			Body:       rwcBody{io.NopCloser(strings.NewReader(""))},
+		}, nil
+	})
+	transport := &trailerTransport{base: base, trailers: []string{"X-Backend-Instance"}}
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "upgrade")
+	defer span.End()
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/ws", nil).WithContext(ctx)
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	if _, ok := resp.Body.(io.ReadWriteCloser); !ok {
+		t.Errorf("response body %T is not an io.ReadWriteCloser", resp.Body)
+	}
+}
+
+// TestHTTPHandlerWithPattern verifies spans are named after the ServeMux pattern
+func TestHTTPHandlerWithPattern(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{tracer: tp.Tracer("test"), tracerProvider: tp}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+	handler := sdk.HTTPHandlerWithPattern(mux)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/users/42", want: "GET /users/{id}"},
+		{path: "/health", want: "GET /health"},
+		{path: "/missing", want: "http.request"},
+	}
+	for _, tt := range tests {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+		spans := recorder.Ended()
+		if got := spans[len(spans)-1].Name(); got != tt.want {
+			t.Errorf("%s: span name = %q; want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestHTTPClientConnectionAttributes verifies the second request over a
+// keep-alive connection is recorded as reused
+func TestHTTPClientConnectionAttributes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+
+	sdk := &SDK{
+		config:         &Config{HTTPClientConnectionAttributes: true},
+		tracer:         tp.Tracer("test"),
+		tracerProvider: tp,
+	}
+	client := sdk.HTTPClient(&http.Client{Transport: &http.Transport{}})
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	var reused []bool
+	for _, span := range recorder.Ended() {
+		attrs := map[string]string{}
+		for _, attr := range span.Attributes() {
+			attrs[string(attr.Key)] = attr.Value.Emit()
+		}
+		if attrs["http.protocol"] != "h1" {
+			t.Errorf("http.protocol = %q; want h1", attrs["http.protocol"])
+		}
+		reused = append(reused, attrs["http.connection.reused"] == "true")
+	}
+	if len(reused) != 2 || reused[0] || !reused[1] {
+		t.Errorf("http.connection.reused = %v; want [false true]", reused)
+	}
+}
+
+// TestPeerServiceMappings verifies configured mappings take precedence over
+// hostname-based service name extraction
+func TestPeerServiceMappings(t *testing.T) {
+	transport := &peerServiceTransport{serviceNameMappings: map[string]string{
+		"localhost:9000": "payment",
+		"inventory-db":   "inventory",
+	}}
+
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"localhost:9000", "payment"},
+		{"inventory-db:5432", "inventory"},
+		{"orders.internal:3000", "orders"},
+	}
+	for _, tt := range tests {
+		if got := transport.extractServiceName(tt.host); got != tt.want {
+			t.Errorf("extractServiceName(%q) = %q; want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+// TestHTTPClientDoesNotMutate verifies HTTPClient returns an instrumented copy
+// and doesn't wrap an already instrumented client twice
+func TestHTTPClientDoesNotMutate(t *testing.T) {
+	sdk := &SDK{config: &Config{}, tracerProvider: sdktrace.NewTracerProvider()}
+
+	defaultTransport := http.DefaultClient.Transport
+	client := sdk.HTTPClient(http.DefaultClient)
+	if client == http.DefaultClient {
+		t.Fatal("HTTPClient returned http.DefaultClient itself")
+	}
+	if http.DefaultClient.Transport != defaultTransport {
+		t.Error("HTTPClient replaced http.DefaultClient.Transport")
+	}
+	transport, ok := client.Transport.(*peerServiceTransport)
+	if !ok {
+		t.Fatalf("Transport = %T; want *peerServiceTransport", client.Transport)
+	}
+
+	again := sdk.HTTPClient(client)
+	if again.Transport != transport {
+		t.Error("instrumented client was wrapped again")
+	}
+	if rt := sdk.WrapRoundTripper(transport); rt != transport {
+		t.Error("WrapRoundTripper wrapped an instrumented transport again")
+	}
+}
+
+// TestHTTPBodyCapture verifies request and response bodies are recorded on
+// client and server spans, truncated, without consuming them
+func TestHTTPBodyCapture(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+
+	sdk := &SDK{
+		config: &Config{
+			HTTPCaptureRequestBody:  true,
+			HTTPCaptureResponseBody: true,
+			HTTPBodyCaptureMaxBytes: 16,
+			HTTPBodyCapturePaths:    []string{"/api/"},
+		},
+		tracer:         tp.Tracer("test"),
+		tracerProvider: tp,
+	}
+
+	server := httptest.NewServer(sdk.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}), "echo"))
+	defer server.Close()
+
+	client := sdk.HTTPClient(&http.Client{})
+	payload := `{"order":"A-1","items":[1,2,3]}`
+	for _, path := range []string{"/api/orders", "/internal/orders"} {
+		resp, err := client.Post(server.URL+path, "application/json", strings.NewReader(payload))
+		if err != nil {
+			t.Fatalf("Post() error = %v", err)
+		}
+		got, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(got) != payload {
+			t.Errorf("response body = %q; want %q (body consumed by capture)", got, payload)
+		}
+	}
+
+	captured := map[trace.SpanKind]int{}
+	for _, span := range recorder.Ended() {
+		attrs := map[string]string{}
+		for _, attr := range span.Attributes() {
+			attrs[string(attr.Key)] = attr.Value.Emit()
+		}
+		if attrs["http.request.body"] == "" {
+			continue
+		}
+		captured[span.SpanKind()]++
+		want := payload[:16]
+		if attrs["http.request.body"] != want || attrs["http.response.body"] != want {
+			t.Errorf("%s bodies = %q / %q; want %q", span.SpanKind(), attrs["http.request.body"], attrs["http.response.body"], want)
+		}
+		if attrs["http.request.body.truncated"] != "true" {
+			t.Errorf("%s span missing http.request.body.truncated", span.SpanKind())
+		}
+	}
+	if captured[trace.SpanKindClient] != 1 || captured[trace.SpanKindServer] != 1 {
+		t.Errorf("spans with captured bodies = %v; want one client and one server span for /api/ only", captured)
+	}
+}
+
+// TestHTTPHeaderCapture verifies configured headers are recorded on client and
+// server spans, with sensitive headers redacted
+func TestHTTPHeaderCapture(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+
+	sdk := &SDK{
+		config: &Config{
+			CaptureRequestHeaders:  []string{"X-Request-ID", "Authorization"},
+			CaptureResponseHeaders: []string{"X-Tenant"},
+		},
+		redactor:       newRedactor(nil),
+		tracer:         tp.Tracer("test"),
+		tracerProvider: tp,
+	}
+
+	server := httptest.NewServer(sdk.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Tenant", "acme")
+	}), "test"))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := sdk.HTTPClient(&http.Client{}).Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	want := map[string]string{
+		"http.request.header.x_request_id":  "req-42",
+		"http.request.header.authorization": "[REDACTED]",
+		"http.response.header.x_tenant":     "acme",
+	}
+	spans := 0
+	for _, span := range recorder.Ended() {
+		if span.SpanKind() != trace.SpanKindClient && span.SpanKind() != trace.SpanKindServer {
+			continue
+		}
+		spans++
+		attrs := map[string]string{}
+		for _, attr := range span.Attributes() {
+			attrs[string(attr.Key)] = attr.Value.Emit()
+		}
+		for key, value := range want {
+			if attrs[key] != value {
+				t.Errorf("%s span %s = %q; want %q", span.SpanKind(), key, attrs[key], value)
+			}
+		}
+	}
+	if spans != 2 {
+		t.Errorf("got %d client/server spans; want 2", spans)
+	}
+}
+
+// TestNormalizeURLPath verifies ID-like path segments collapse to {id}
+func TestNormalizeURLPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/accounts/abc-123/balance", "/accounts/{id}/balance"},
+		{"/users/42", "/users/{id}"},
+		{"/orders/3f2b8c1e-9d4a-4e6b-8f0a-1c2d3e4f5a6b/items/7", "/orders/{id}/items/{id}"},
+		{"/blobs/0123456789abcdef0123", "/blobs/{id}"},
+		{"/v2/health", "/v2/health"},
+		{"", "/"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeURLPath(&url.URL{Path: tt.path}); got != tt.want {
+			t.Errorf("NormalizeURLPath(%q) = %q; want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestNonErrorStatusCodes verifies configured status codes don't mark client
+// and server spans as errors
+func TestNonErrorStatusCodes(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+
+	sdk := &SDK{
+		config:         &Config{NonErrorStatusCodes: []int{http.StatusNotFound, http.StatusServiceUnavailable}},
+		tracer:         tp.Tracer("test"),
+		tracerProvider: tp,
+	}
+
+	server := httptest.NewServer(sdk.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/maintenance":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}), "test"))
+	defer server.Close()
+
+	client := sdk.HTTPClient(&http.Client{})
+	for _, path := range []string{"/missing", "/maintenance", "/broken"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	var errorSpans int
+	for _, span := range recorder.Ended() {
+		if span.Status().Code == codes.Error {
+			errorSpans++
+		}
+	}
+	// Only /broken: its client and server span
+	if errorSpans != 2 {
+		t.Errorf("got %d error spans; want 2", errorSpans)
+	}
+}
+
+// TestIgnorePaths verifies requests for ignored paths start no span
+func TestIgnorePaths(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+
+	sdk := &SDK{
+		config:         &Config{IgnorePaths: []string{"/healthz", "/debug/*"}},
+		tracer:         tp.Tracer("test"),
+		tracerProvider: tp,
+	}
+	var served int
+	handler := sdk.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+		ignored := r.URL.Path == "/healthz" || strings.HasPrefix(r.URL.Path, "/debug/")
+		if traced := trace.SpanFromContext(r.Context()).SpanContext().IsValid(); traced == ignored {
+			t.Errorf("%s: traced = %v; want %v", r.URL.Path, traced, !ignored)
+		}
+	}), "test")
+
+	for _, path := range []string{"/healthz", "/debug/pprof/heap", "/orders", "/healthz/deep"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if served != 4 {
+		t.Errorf("handler served %d requests; want 4", served)
+	}
+	if got := len(recorder.Ended()); got != 2 {
+		t.Errorf("recorded %d spans; want 2 (/orders and /healthz/deep)", got)
+	}
+}
+
+// TestIdentityExtractor verifies the server span carries user.id and
+// tenant.id from Config.IdentityExtractor without handler code
+func TestIdentityExtractor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		handler func(sdk *SDK) http.Handler
+	}{
+		{name: "net/http", handler: func(sdk *SDK) http.Handler {
+			return sdk.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), "test")
+		}},
+		{name: "gin", handler: func(sdk *SDK) http.Handler {
+			router := gin.New()
+			router.Use(sdk.GinMiddleware())
+			router.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+			return router
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			defer tp.Shutdown(context.Background())
+
+			sdk := &SDK{
+				config: &Config{ServiceName: "test", IdentityExtractor: func(r *http.Request) (string, string) {
+					return r.Header.Get("X-User"), r.Header.Get("X-Tenant")
+				}},
+				tracer:         tp.Tracer("test"),
+				tracerProvider: tp,
+			}
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			req.Header.Set("X-User", "u-42")
+			tt.handler(sdk).ServeHTTP(httptest.NewRecorder(), req)
+
+			ended := recorder.Ended()
+			if len(ended) != 1 {
+				t.Fatalf("expected 1 span, got %d", len(ended))
+			}
+			attrs := map[string]string{}
+			for _, attr := range ended[0].Attributes() {
+				attrs[string(attr.Key)] = attr.Value.Emit()
+			}
+			if attrs["user.id"] != "u-42" {
+				t.Errorf("user.id = %q; want %q", attrs["user.id"], "u-42")
+			}
+			if _, ok := attrs["tenant.id"]; ok {
+				t.Error("tenant.id recorded for an empty tenant")
+			}
+		})
+	}
+}
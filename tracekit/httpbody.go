@@ -0,0 +1,274 @@
+package tracekit
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultBodyCaptureMaxBytes caps captured bodies when
+// Config.HTTPBodyCaptureMaxBytes is unset
+const defaultBodyCaptureMaxBytes = 4096
+
+// defaultBodyCaptureContentTypes are the textual content types captured when
+// Config.HTTPBodyCaptureContentTypes is nil
+var defaultBodyCaptureContentTypes = []string{
+	"application/json",
+	"application/xml",
+	"application/x-www-form-urlencoded",
+	"text/",
+}
+
+// bodyCapture decides which HTTP bodies are recorded and how
+type bodyCapture struct {
+	request      bool
+	response     bool
+	maxBytes     int
+	contentTypes []string
+	paths        []string
+	redactor     *redactor
+}
+
+// newBodyCapture returns the body capture settings, or nil when neither
+// request nor response capture is enabled
+func (s *SDK) newBodyCapture() *bodyCapture {
+	if s.config == nil || (!s.config.HTTPCaptureRequestBody && !s.config.HTTPCaptureResponseBody) {
+		return nil
+	}
+
+	bc := &bodyCapture{
+		request:      s.config.HTTPCaptureRequestBody,
+		response:     s.config.HTTPCaptureResponseBody,
+		maxBytes:     s.config.HTTPBodyCaptureMaxBytes,
+		contentTypes: s.config.HTTPBodyCaptureContentTypes,
+		paths:        s.config.HTTPBodyCapturePaths,
+		redactor:     s.redactor,
+	}
+	if bc.maxBytes <= 0 {
+		bc.maxBytes = defaultBodyCaptureMaxBytes
+	}
+	if bc.contentTypes == nil {
+		bc.contentTypes = defaultBodyCaptureContentTypes
+	}
+	return bc
+}
+
+// allowed reports whether a body with contentType on path may be captured
+func (bc *bodyCapture) allowed(path, contentType string) bool {
+	if len(bc.paths) > 0 {
+		matched := false
+		for _, prefix := range bc.paths {
+			if strings.HasPrefix(path, prefix) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	for _, prefix := range bc.contentTypes {
+		if strings.HasPrefix(contentType, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// record sets attr (and attr.truncated) on span from the captured bytes.
+// Bodies that aren't valid UTF-8 are skipped.
+func (bc *bodyCapture) record(span trace.Span, attr string, body *bodyBuffer) {
+	if body.buf.Len() == 0 || !utf8.Valid(body.buf.Bytes()) {
+		return
+	}
+	span.SetAttributes(attribute.String(attr, bc.redactor.redact(attr, body.buf.String())))
+	if body.truncated {
+		span.SetAttributes(attribute.Bool(attr+".truncated", true))
+	}
+}
+
+// bodyBuffer keeps the first max bytes written to it and discards the rest
+type bodyBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *bodyBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.max - b.buf.Len(); len(p) > room {
+		b.truncated = true
+		p = p[:room]
+	}
+	b.buf.Write(p)
+	return n, nil
+}
+
+// teeBody copies what the reader consumes into a bodyBuffer and calls done
+// once, when the body reaches EOF or is closed
+type teeBody struct {
+	io.Reader
+	closer io.Closer
+	once   sync.Once
+	done   func()
+}
+
+func newTeeBody(body io.ReadCloser, buf *bodyBuffer, done func()) *teeBody {
+	return &teeBody{Reader: io.TeeReader(body, buf), closer: body, done: done}
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *teeBody) Close() error {
+	b.finish()
+	return b.closer.Close()
+}
+
+func (b *teeBody) finish() {
+	b.once.Do(b.done)
+}
+
+// wrapBodyTransport adds body capture when Config.HTTPCaptureRequestBody or
+// HTTPCaptureResponseBody is set. Like the trailer transport it must sit
+// inside the otelhttp transport so the bodies land on the CLIENT span.
+func (s *SDK) wrapBodyTransport(rt http.RoundTripper) http.RoundTripper {
+	bc := s.newBodyCapture()
+	if bc == nil {
+		return rt
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &bodyTransport{base: rt, capture: bc}
+}
+
+// bodyTransport records request and response bodies on client spans
+type bodyTransport struct {
+	base    http.RoundTripper
+	capture *bodyCapture
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *bodyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	span := trace.SpanFromContext(req.Context())
+	if !span.SpanContext().IsValid() {
+		return t.base.RoundTrip(req)
+	}
+
+	bc := t.capture
+	if bc.request && req.Body != nil && req.Body != http.NoBody &&
+		bc.allowed(req.URL.Path, req.Header.Get("Content-Type")) {
+		// RoundTrippers must not modify the caller's request
+		clone := *req
+		buf := &bodyBuffer{max: bc.maxBytes}
+		clone.Body = newTeeBody(req.Body, buf, func() { bc.record(span, "http.request.body", buf) })
+		req = &clone
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.Body == nil || resp.StatusCode == http.StatusSwitchingProtocols {
+		return resp, err
+	}
+
+	if bc.response && bc.allowed(req.URL.Path, resp.Header.Get("Content-Type")) {
+		buf := &bodyBuffer{max: bc.maxBytes}
+		resp.Body = newTeeBody(resp.Body, buf, func() { bc.record(span, "http.response.body", buf) })
+	}
+	return resp, nil
+}
+
+// wrapBodyHandler adds body capture to a server handler. It must run inside
+// the otelhttp handler so the request context carries the SERVER span.
+func (s *SDK) wrapBodyHandler(next http.Handler) http.Handler {
+	bc := s.newBodyCapture()
+	if bc == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		if !span.SpanContext().IsValid() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if bc.request && r.Body != nil && r.Body != http.NoBody &&
+			bc.allowed(r.URL.Path, r.Header.Get("Content-Type")) {
+			buf := &bodyBuffer{max: bc.maxBytes}
+			body := newTeeBody(r.Body, buf, func() { bc.record(span, "http.request.body", buf) })
+			r.Body = body
+			// Handlers that don't read the whole body still get what they read
+			defer body.finish()
+		}
+
+		if bc.response {
+			bw := &bodyResponseWriter{ResponseWriter: w, capture: bc, path: r.URL.Path}
+			defer func() {
+				if bw.buf != nil {
+					bc.record(span, "http.response.body", bw.buf)
+				}
+			}()
+			w = bw
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bodyResponseWriter copies the response body into a bodyBuffer when the
+// response Content-Type is allowed
+type bodyResponseWriter struct {
+	http.ResponseWriter
+	capture *bodyCapture
+	path    string
+	buf     *bodyBuffer
+	checked bool
+}
+
+func (w *bodyResponseWriter) WriteHeader(statusCode int) {
+	w.check(nil)
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *bodyResponseWriter) Write(p []byte) (int, error) {
+	w.check(p)
+	if w.buf != nil {
+		w.buf.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// check decides on the first write, once the handler has set Content-Type.
+// Without one, the type is sniffed from the first bytes as net/http does.
+func (w *bodyResponseWriter) check(p []byte) {
+	if w.checked {
+		return
+	}
+	w.checked = true
+	contentType := w.Header().Get("Content-Type")
+	if contentType == "" && len(p) > 0 {
+		contentType = http.DetectContentType(p)
+	}
+	if w.capture.allowed(w.path, contentType) {
+		w.buf = &bodyBuffer{max: w.capture.maxBytes}
+	}
+}
+
+// Unwrap lets http.ResponseController reach Flush, Hijack etc.
+func (w *bodyResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
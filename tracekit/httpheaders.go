@@ -0,0 +1,88 @@
+package tracekit
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// headerAttributes returns an attribute per configured header present in h,
+// named prefix + the lowercased header name with dashes as underscores
+// (X-Request-ID -> http.request.header.x_request_id). Values are redacted
+// like the request context headers.
+func headerAttributes(prefix string, names []string, h http.Header, r *redactor) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, name := range names {
+		values := h.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		key := prefix + strings.ReplaceAll(strings.ToLower(name), "-", "_")
+		attrs = append(attrs, attribute.String(key, r.redactHeader(name, strings.Join(values, ","))))
+	}
+	return attrs
+}
+
+// wrapHeaderHandler records Config.CaptureRequestHeaders and
+// CaptureResponseHeaders on the server span. Like the body handler it must
+// run inside the otelhttp handler. Response headers are read once the
+// handler returns.
+func (s *SDK) wrapHeaderHandler(next http.Handler) http.Handler {
+	if s.config == nil || (len(s.config.CaptureRequestHeaders) == 0 && len(s.config.CaptureResponseHeaders) == 0) {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		if !span.SpanContext().IsValid() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		span.SetAttributes(headerAttributes("http.request.header.", s.config.CaptureRequestHeaders, r.Header, s.redactor)...)
+		next.ServeHTTP(w, r)
+		span.SetAttributes(headerAttributes("http.response.header.", s.config.CaptureResponseHeaders, w.Header(), s.redactor)...)
+	})
+}
+
+// wrapHeaderTransport records the configured headers on client spans. It
+// must sit inside the otelhttp transport to see the CLIENT span.
+func (s *SDK) wrapHeaderTransport(rt http.RoundTripper) http.RoundTripper {
+	if s.config == nil || (len(s.config.CaptureRequestHeaders) == 0 && len(s.config.CaptureResponseHeaders) == 0) {
+		return rt
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &headerTransport{
+		base:            rt,
+		requestHeaders:  s.config.CaptureRequestHeaders,
+		responseHeaders: s.config.CaptureResponseHeaders,
+		redactor:        s.redactor,
+	}
+}
+
+// headerTransport records configured request and response headers
+type headerTransport struct {
+	base            http.RoundTripper
+	requestHeaders  []string
+	responseHeaders []string
+	redactor        *redactor
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	span := trace.SpanFromContext(req.Context())
+	if !span.SpanContext().IsValid() {
+		return t.base.RoundTrip(req)
+	}
+
+	span.SetAttributes(headerAttributes("http.request.header.", t.requestHeaders, req.Header, t.redactor)...)
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		span.SetAttributes(headerAttributes("http.response.header.", t.responseHeaders, resp.Header, t.redactor)...)
+	}
+	return resp, err
+}
@@ -0,0 +1,40 @@
+package tracekit
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tagIdentity sets user.id and tenant.id on span from Config.IdentityExtractor
+func (s *SDK) tagIdentity(span trace.Span, r *http.Request) {
+	if s.config == nil || s.config.IdentityExtractor == nil {
+		return
+	}
+
+	userID, tenantID := s.config.IdentityExtractor(r)
+	attrs := []attribute.KeyValue{}
+	if userID != "" {
+		attrs = append(attrs, attribute.String("user.id", userID))
+	}
+	if tenantID != "" {
+		attrs = append(attrs, attribute.String("tenant.id", tenantID))
+	}
+	if len(attrs) > 0 {
+		span.SetAttributes(attrs...)
+	}
+}
+
+// wrapIdentityHandler tags the server span with the request's identity. It
+// must run inside the otelhttp handler so the request context carries the
+// SERVER span.
+func (s *SDK) wrapIdentityHandler(next http.Handler) http.Handler {
+	if s.config == nil || s.config.IdentityExtractor == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.tagIdentity(trace.SpanFromContext(r.Context()), r)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,133 @@
+package tracekit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WrapKafkaWriter wraps a kafka-go Writer so each written message gets a
+// PRODUCER span whose context is injected into the message headers.
+// Consumers continue the trace with TraceKafkaMessage.
+func (s *SDK) WrapKafkaWriter(w *kafka.Writer) *TracedKafkaWriter {
+	return &TracedKafkaWriter{Writer: w, tracer: s.tracer}
+}
+
+// TracedKafkaWriter is a kafka.Writer with tracing on WriteMessages
+type TracedKafkaWriter struct {
+	*kafka.Writer
+	tracer trace.Tracer
+}
+
+// WriteMessages writes messages with one PRODUCER span per message. The
+// caller's messages are not modified; trace headers are added to copies.
+func (w *TracedKafkaWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	traced := make([]kafka.Message, len(msgs))
+	spans := make([]trace.Span, len(msgs))
+
+	for i, msg := range msgs {
+		topic := msg.Topic
+		if topic == "" {
+			topic = w.Topic
+		}
+
+		spanCtx, span := w.tracer.Start(ctx, topic+" publish",
+			trace.WithSpanKind(trace.SpanKindProducer),
+			trace.WithAttributes(
+				attribute.String("messaging.system", "kafka"),
+				attribute.String("messaging.destination.name", topic),
+				attribute.String("messaging.operation", "publish"),
+				attribute.Int("messaging.message.body.size", len(msg.Value)),
+			),
+		)
+		spans[i] = span
+
+		// Copy headers so the caller's slice isn't mutated
+		msg.Headers = append([]kafka.Header(nil), msg.Headers...)
+		otel.GetTextMapPropagator().Inject(spanCtx, kafkaHeaderCarrier{headers: &msg.Headers})
+		traced[i] = msg
+	}
+
+	err := w.Writer.WriteMessages(ctx, traced...)
+
+	// kafka.WriteErrors reports failures per message
+	var writeErrs kafka.WriteErrors
+	perMessage := errors.As(err, &writeErrs) && len(writeErrs) == len(spans)
+
+	for i, span := range spans {
+		spanErr := err
+		if perMessage {
+			spanErr = writeErrs[i]
+		}
+		if spanErr != nil {
+			span.RecordError(spanErr)
+			span.SetStatus(codes.Error, spanErr.Error())
+		} else {
+			span.SetStatus(codes.Ok, "")
+		}
+		span.End()
+	}
+
+	return err
+}
+
+// TraceKafkaMessage starts a CONSUMER span for a received message, continuing
+// the producer's trace from the message headers. The caller must end the span
+// once the message has been processed.
+//
+//	msg, err := reader.ReadMessage(ctx)
+//	ctx, span := sdk.TraceKafkaMessage(ctx, msg)
+//	defer span.End()
+func (s *SDK) TraceKafkaMessage(ctx context.Context, msg kafka.Message) (context.Context, trace.Span) {
+	headers := msg.Headers
+	ctx = otel.GetTextMapPropagator().Extract(ctx, kafkaHeaderCarrier{headers: &headers})
+
+	return s.tracer.Start(ctx, msg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.String("messaging.operation", "process"),
+			attribute.Int("messaging.kafka.destination.partition", msg.Partition),
+			attribute.Int64("messaging.kafka.message.offset", msg.Offset),
+			attribute.Int("messaging.message.body.size", len(msg.Value)),
+		),
+	)
+}
+
+// kafkaHeaderCarrier adapts Kafka message headers to propagation.TextMapCarrier
+type kafkaHeaderCarrier struct {
+	headers *[]kafka.Header
+}
+
+func (c kafkaHeaderCarrier) Get(key string) string {
+	for _, h := range *c.headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c kafkaHeaderCarrier) Set(key, value string) {
+	for i, h := range *c.headers {
+		if h.Key == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c kafkaHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.headers))
+	for _, h := range *c.headers {
+		keys = append(keys, h.Key)
+	}
+	return keys
+}
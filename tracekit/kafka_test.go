@@ -0,0 +1,41 @@
+package tracekit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestTraceKafkaMessage verifies the consumer span continues the trace carried in message headers
+func TestTraceKafkaMessage(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	tp := sdktrace.NewTracerProvider()
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{tracer: tp.Tracer("test")}
+
+	producerCtx, producer := tp.Tracer("test").Start(context.Background(), "orders publish",
+		trace.WithSpanKind(trace.SpanKindProducer))
+	msg := kafka.Message{Topic: "orders", Partition: 2, Offset: 42, Value: []byte("{}")}
+	otel.GetTextMapPropagator().Inject(producerCtx, kafkaHeaderCarrier{headers: &msg.Headers})
+	producer.End()
+
+	carrier := kafkaHeaderCarrier{headers: &msg.Headers}
+	if carrier.Get("traceparent") == "" {
+		t.Fatal("traceparent header not injected")
+	}
+
+	_, consumer := sdk.TraceKafkaMessage(context.Background(), msg)
+	defer consumer.End()
+
+	if consumer.SpanContext().TraceID() != producer.SpanContext().TraceID() {
+		t.Error("consumer span does not continue the producer trace")
+	}
+}
@@ -0,0 +1,111 @@
+package tracekit
+
+import (
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// LogLevel controls the verbosity of SDK diagnostic logging
+type LogLevel string
+
+const (
+	LogLevelError LogLevel = "error"
+	LogLevelWarn  LogLevel = "warn"
+	LogLevelInfo  LogLevel = "info"
+	LogLevelDebug LogLevel = "debug"
+)
+
+// Logger is the destination for SDK diagnostics. *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// severity orders log levels from least to most verbose
+func (l LogLevel) severity() int32 {
+	switch LogLevel(strings.ToLower(string(l))) {
+	case LogLevelError:
+		return 0
+	case LogLevelWarn:
+		return 1
+	case LogLevelDebug:
+		return 3
+	default:
+		return 2 // info
+	}
+}
+
+// diagLogger filters SDK diagnostics by level before writing them to the
+// configured Logger. Each SDK owns one and shares it with its components, so
+// SDK instances with different settings don't affect each other. A nil
+// *diagLogger logs through defaultLogger.
+type diagLogger struct {
+	level atomic.Int32
+	out   atomic.Value // holds loggerHolder
+}
+
+// loggerHolder keeps atomic.Value storing a single concrete type
+type loggerHolder struct {
+	Logger
+}
+
+// defaultLogger serves components created without an SDK (info to the standard logger)
+var defaultLogger = newDiagLogger()
+
+func newDiagLogger() *diagLogger {
+	l := &diagLogger{}
+	l.level.Store(LogLevelInfo.severity())
+	l.out.Store(loggerHolder{log.Default()})
+	return l
+}
+
+// setLevel changes the minimum level that is written
+func (l *diagLogger) setLevel(level LogLevel) {
+	l.level.Store(level.severity())
+}
+
+// setOutput changes where diagnostics are written (nil = standard logger)
+func (l *diagLogger) setOutput(out Logger) {
+	if out == nil {
+		out = log.Default()
+	}
+	l.out.Store(loggerHolder{out})
+}
+
+func (l *diagLogger) logf(level LogLevel, format string, args ...interface{}) {
+	if l == nil {
+		l = defaultLogger
+	}
+	if level.severity() > l.level.Load() {
+		return
+	}
+	l.out.Load().(loggerHolder).Printf(format, args...)
+}
+
+func (l *diagLogger) errorf(format string, args ...interface{}) {
+	l.logf(LogLevelError, format, args...)
+}
+
+func (l *diagLogger) warnf(format string, args ...interface{}) {
+	l.logf(LogLevelWarn, format, args...)
+}
+
+func (l *diagLogger) infof(format string, args ...interface{}) {
+	l.logf(LogLevelInfo, format, args...)
+}
+
+func (l *diagLogger) debugf(format string, args ...interface{}) {
+	l.logf(LogLevelDebug, format, args...)
+}
+
+// SetLogLevel changes the verbosity of this SDK's diagnostics at runtime,
+// including those of its snapshot client
+func (s *SDK) SetLogLevel(level LogLevel) {
+	s.logger.setLevel(level)
+}
+
+// SetLogLevel changes the verbosity of the snapshot client's diagnostics.
+// Clients created by NewSDK share the SDK's level.
+func (c *SnapshotClient) SetLogLevel(level LogLevel) {
+	c.logger.setLevel(level)
+}
@@ -0,0 +1,72 @@
+package tracekit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// captureLogger records formatted messages
+type captureLogger struct {
+	lines []string
+}
+
+func (c *captureLogger) Printf(format string, v ...interface{}) {
+	c.lines = append(c.lines, fmt.Sprintf(format, v...))
+}
+
+// TestDiagLoggerLevels verifies messages below the configured level are dropped
+func TestDiagLoggerLevels(t *testing.T) {
+	tests := []struct {
+		level LogLevel
+		want  []string
+	}{
+		{level: LogLevelError, want: []string{"error"}},
+		{level: LogLevelWarn, want: []string{"error", "warn"}},
+		{level: LogLevelInfo, want: []string{"error", "warn", "info"}},
+		{level: LogLevelDebug, want: []string{"error", "warn", "info", "debug"}},
+		{level: "WARN", want: []string{"error", "warn"}},
+		{level: "", want: []string{"error", "warn", "info"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.level), func(t *testing.T) {
+			out := &captureLogger{}
+			l := newDiagLogger()
+			l.setOutput(out)
+			l.setLevel(tt.level)
+
+			l.errorf("error")
+			l.warnf("warn")
+			l.infof("info")
+			l.debugf("debug")
+
+			if got := strings.Join(out.lines, ","); got != strings.Join(tt.want, ",") {
+				t.Errorf("logged %q; want %q", got, strings.Join(tt.want, ","))
+			}
+		})
+	}
+}
+
+// TestSDKLoggersIndependent verifies SetLogLevel on one SDK doesn't change
+// another SDK's diagnostics
+func TestSDKLoggersIndependent(t *testing.T) {
+	quietOut, verboseOut := &captureLogger{}, &captureLogger{}
+	quiet := &SDK{logger: newDiagLogger()}
+	quiet.logger.setOutput(quietOut)
+	verbose := &SDK{logger: newDiagLogger()}
+	verbose.logger.setOutput(verboseOut)
+
+	quiet.SetLogLevel(LogLevelError)
+	verbose.SetLogLevel(LogLevelDebug)
+
+	quiet.logger.debugf("quiet")
+	verbose.logger.debugf("verbose")
+
+	if len(quietOut.lines) != 0 {
+		t.Errorf("quiet SDK logged %v; want nothing", quietOut.lines)
+	}
+	if len(verboseOut.lines) != 1 {
+		t.Errorf("verbose SDK logged %v; want one line", verboseOut.lines)
+	}
+}
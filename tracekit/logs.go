@@ -0,0 +1,239 @@
+package tracekit
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LogHandler returns a slog.Handler that exports records as OTLP logs to the
+// configured endpoint (Config.LogsPath) and adds the active span's trace_id
+// and span_id to each record, so logs are correlated with traces in the
+// backend. The logs pipeline is created on first use.
+//
+//	logger := slog.New(sdk.LogHandler())
+//	logger.InfoContext(ctx, "order placed", "order.id", id)
+func (s *SDK) LogHandler(opts ...SlogHandlerOption) slog.Handler {
+	s.logsOnce.Do(func() {
+		if err := s.initLogs(); err != nil {
+			s.logger.warnf("TraceKit: failed to initialize log export: %v", err)
+		}
+	})
+
+	if s.loggerProvider == nil {
+		return newTraceContextHandler(nil, opts)
+	}
+	return newTraceContextHandler(
+		otelslog.NewHandler(s.config.ServiceName, otelslog.WithLoggerProvider(s.loggerProvider)),
+		opts,
+	)
+}
+
+// SlogHandler wraps base so records logged with a context carrying a span
+// get trace_id and span_id attributes, correlating existing log output (e.g.
+// JSON to stdout) with traces without exporting it through OTLP.
+//
+//	logger := slog.New(sdk.SlogHandler(slog.NewJSONHandler(os.Stdout, nil)))
+//	logger.InfoContext(ctx, "payment captured")
+func (s *SDK) SlogHandler(base slog.Handler, opts ...SlogHandlerOption) slog.Handler {
+	return newTraceContextHandler(base, opts)
+}
+
+// SlogHandlerOption configures the handlers returned by LogHandler and SlogHandler
+type SlogHandlerOption func(*traceContextHandler)
+
+// WithSpanEvents adds records at or above level to the active span as events
+// carrying the message and record attributes, so logs show up on the trace
+// timeline. Records at slog.LevelError or above also set the span status to
+// error.
+//
+//	logger := slog.New(sdk.SlogHandler(base, tracekit.WithSpanEvents(slog.LevelWarn)))
+func WithSpanEvents(level slog.Level) SlogHandlerOption {
+	return func(h *traceContextHandler) {
+		h.spanEvents = true
+		h.eventLevel = level
+	}
+}
+
+// initLogs creates the OTLP logs exporter and logger provider
+func (s *SDK) initLogs() error {
+	logsEndpoint := resolveEndpoint(s.config.Endpoint, s.config.LogsPath, s.config.UseSSL)
+
+	useSSL := !strings.HasPrefix(logsEndpoint, "http://")
+	logsEndpoint = strings.TrimPrefix(strings.TrimPrefix(logsEndpoint, "https://"), "http://")
+
+	// Split host and path
+	parts := strings.SplitN(logsEndpoint, "/", 2)
+	urlPath := "/v1/logs"
+	if len(parts) > 1 {
+		urlPath = "/" + parts[1]
+	}
+
+	opts := []otlploghttp.Option{
+		otlploghttp.WithEndpoint(parts[0]),
+		otlploghttp.WithURLPath(urlPath),
+		otlploghttp.WithTimeout(30 * time.Second),
+		otlploghttp.WithHeaders(map[string]string{
+			"X-API-Key": s.config.APIKey,
+		}),
+	}
+	if useSSL {
+		opts = append(opts, otlploghttp.WithTLSClientConfig(&tls.Config{}))
+	} else {
+		opts = append(opts, otlploghttp.WithInsecure())
+	}
+
+	exporter, err := otlploghttp.New(context.Background(), opts...)
+	if err != nil {
+		return err
+	}
+
+	providerOpts := []sdklog.LoggerProviderOption{
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)),
+	}
+	if s.resource != nil {
+		providerOpts = append(providerOpts, sdklog.WithResource(s.resource))
+	}
+	s.loggerProvider = sdklog.NewLoggerProvider(providerOpts...)
+
+	s.logger.infof("TraceKit: log export enabled (%s)", urlPath)
+	return nil
+}
+
+// traceContextHandler adds trace_id/span_id from the record's context and
+// forwards to next. A nil next drops all records, though span events are
+// still added.
+type traceContextHandler struct {
+	next slog.Handler
+
+	spanEvents bool
+	eventLevel slog.Level
+
+	// eventAttrs are the attributes added through WithAttrs, and groupPrefix
+	// the dotted key prefix from WithGroup, for span events
+	eventAttrs  []attribute.KeyValue
+	groupPrefix string
+}
+
+func newTraceContextHandler(next slog.Handler, opts []SlogHandlerOption) *traceContextHandler {
+	h := &traceContextHandler{next: next}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *traceContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.eventEnabled(level) || (h.next != nil && h.next.Enabled(ctx, level))
+}
+
+// eventEnabled reports whether records at level become span events
+func (h *traceContextHandler) eventEnabled(level slog.Level) bool {
+	return h.spanEvents && level >= h.eventLevel
+}
+
+func (h *traceContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if h.eventEnabled(record.Level) {
+		h.addSpanEvent(trace.SpanFromContext(ctx), record)
+	}
+	if h.next == nil || !h.next.Enabled(ctx, record.Level) {
+		return nil
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		record = record.Clone()
+		record.AddAttrs(
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func (h *traceContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	clone := *h
+	if h.next != nil {
+		clone.next = h.next.WithAttrs(attrs)
+	}
+	if h.spanEvents {
+		clone.eventAttrs = append([]attribute.KeyValue(nil), h.eventAttrs...)
+		for _, attr := range attrs {
+			clone.eventAttrs = appendSlogAttr(clone.eventAttrs, h.groupPrefix, attr)
+		}
+	}
+	return &clone
+}
+
+func (h *traceContextHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	if h.next != nil {
+		clone.next = h.next.WithGroup(name)
+	}
+	clone.groupPrefix = h.groupPrefix + name + "."
+	return &clone
+}
+
+// addSpanEvent records a log record, with the attributes and groups from
+// WithAttrs and WithGroup, as an event on span, marking the span as failed
+// for error records
+func (h *traceContextHandler) addSpanEvent(span trace.Span, record slog.Record) {
+	if !span.IsRecording() {
+		return
+	}
+
+	attrs := []attribute.KeyValue{attribute.String("log.severity", record.Level.String())}
+	attrs = append(attrs, h.eventAttrs...)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = appendSlogAttr(attrs, h.groupPrefix, attr)
+		return true
+	})
+	span.AddEvent(record.Message, trace.WithTimestamp(record.Time), trace.WithAttributes(attrs...))
+
+	if record.Level >= slog.LevelError {
+		span.SetStatus(codes.Error, record.Message)
+	}
+}
+
+// appendSlogAttr converts attr to span attributes, flattening groups into
+// dotted keys
+func appendSlogAttr(attrs []attribute.KeyValue, prefix string, attr slog.Attr) []attribute.KeyValue {
+	key := prefix + attr.Key
+	value := attr.Value.Resolve()
+
+	switch value.Kind() {
+	case slog.KindGroup:
+		if attr.Key != "" {
+			prefix = key + "."
+		}
+		for _, a := range value.Group() {
+			attrs = appendSlogAttr(attrs, prefix, a)
+		}
+		return attrs
+	case slog.KindString:
+		return append(attrs, attribute.String(key, value.String()))
+	case slog.KindInt64:
+		return append(attrs, attribute.Int64(key, value.Int64()))
+	case slog.KindUint64:
+		return append(attrs, attribute.Int64(key, int64(value.Uint64())))
+	case slog.KindFloat64:
+		return append(attrs, attribute.Float64(key, value.Float64()))
+	case slog.KindBool:
+		return append(attrs, attribute.Bool(key, value.Bool()))
+	default:
+		return append(attrs, attribute.String(key, value.String()))
+	}
+}
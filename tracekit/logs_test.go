@@ -0,0 +1,127 @@
+package tracekit
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// captureHandler records the attributes of handled slog records
+type captureHandler struct {
+	attrs map[string]string
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *captureHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *captureHandler) Handle(_ context.Context, record slog.Record) error {
+	record.Attrs(func(attr slog.Attr) bool {
+		h.attrs[attr.Key] = attr.Value.String()
+		return true
+	})
+	return nil
+}
+
+// TestTraceContextHandler verifies records logged inside a span carry its trace and span IDs
+func TestTraceContextHandler(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	defer tp.Shutdown(context.Background())
+
+	capture := &captureHandler{attrs: map[string]string{}}
+	logger := slog.New((&SDK{}).SlogHandler(capture))
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+	logger.InfoContext(ctx, "hello", "order.id", "42")
+	span.End()
+
+	if got, want := capture.attrs["trace_id"], span.SpanContext().TraceID().String(); got != want {
+		t.Errorf("trace_id = %q; want %q", got, want)
+	}
+	if got, want := capture.attrs["span_id"], span.SpanContext().SpanID().String(); got != want {
+		t.Errorf("span_id = %q; want %q", got, want)
+	}
+	if capture.attrs["order.id"] != "42" {
+		t.Errorf("order.id = %q; want record attributes preserved", capture.attrs["order.id"])
+	}
+}
+
+// TestSlogHandlerSpanEvents verifies records at or above the event level are
+// added to the span and error records fail it
+func TestSlogHandlerSpanEvents(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+
+	capture := &captureHandler{attrs: map[string]string{}}
+	logger := slog.New((&SDK{}).SlogHandler(capture, WithSpanEvents(slog.LevelWarn)))
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+	logger.InfoContext(ctx, "starting")
+	logger.WarnContext(ctx, "retrying", "attempt", 2)
+	logger.ErrorContext(ctx, "payment failed", slog.Group("payment", "id", "p-1"))
+	span.End()
+
+	ended := recorder.Ended()[0]
+	events := ended.Events()
+	if len(events) != 2 || events[0].Name != "retrying" || events[1].Name != "payment failed" {
+		t.Fatalf("events = %v; want [retrying, payment failed]", events)
+	}
+
+	attrs := map[string]string{}
+	for _, attr := range events[1].Attributes {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs["payment.id"] != "p-1" || attrs["log.severity"] != "ERROR" {
+		t.Errorf("event attributes = %v; want payment.id and log.severity", attrs)
+	}
+	if ended.Status().Code != codes.Error {
+		t.Errorf("span status = %v; want Error", ended.Status().Code)
+	}
+}
+
+// TestSlogHandlerSpanEventsWithAttrs verifies attributes and groups added
+// through With and WithGroup reach span events, also without a next handler
+func TestSlogHandlerSpanEventsWithAttrs(t *testing.T) {
+	tests := []struct {
+		name string
+		next slog.Handler
+	}{
+		{name: "with next", next: &captureHandler{attrs: map[string]string{}}},
+		{name: "without next", next: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			defer tp.Shutdown(context.Background())
+
+			logger := slog.New(newTraceContextHandler(tt.next, []SlogHandlerOption{WithSpanEvents(slog.LevelWarn)}))
+			logger = logger.With("request.id", "r-1").WithGroup("db").With("system", "postgres")
+
+			ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+			logger.WarnContext(ctx, "slow query", "table", "orders")
+			span.End()
+
+			events := recorder.Ended()[0].Events()
+			if len(events) != 1 {
+				t.Fatalf("got %d events; want 1", len(events))
+			}
+			attrs := map[string]string{}
+			for _, attr := range events[0].Attributes {
+				attrs[string(attr.Key)] = attr.Value.Emit()
+			}
+			want := map[string]string{"request.id": "r-1", "db.system": "postgres", "db.table": "orders"}
+			for key, value := range want {
+				if attrs[key] != value {
+					t.Errorf("%s = %q; want %q (attributes %v)", key, attrs[key], value, attrs)
+				}
+			}
+		})
+	}
+}
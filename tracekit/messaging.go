@@ -0,0 +1,68 @@
+package tracekit
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+)
+
+// InjectMessageContext writes the trace context (and baggage) from ctx into
+// an outgoing message's headers through setHeader, so any broker can carry it
+// without a dedicated wrapper. Call it inside the producer span.
+//
+//	ctx, span := sdk.StartSpan(ctx, "orders publish")
+//	defer span.End()
+//	sdk.InjectMessageContext(ctx, func(k, v string) { msg.Headers[k] = v })
+func (s *SDK) InjectMessageContext(ctx context.Context, setHeader func(key, value string)) {
+	otel.GetTextMapPropagator().Inject(ctx, messageInjectCarrier(setHeader))
+}
+
+// ExtractMessageContext returns ctx carrying the trace context (and baggage)
+// read from a received message's headers, so a consumer span started from
+// it continues the producer's trace.
+//
+//	ctx = sdk.ExtractMessageContext(ctx, func() map[string]string { return msg.Headers })
+//	ctx, span := sdk.StartSpan(ctx, "orders process")
+func (s *SDK) ExtractMessageContext(ctx context.Context, getHeaders func() map[string]string) context.Context {
+	headers := getHeaders()
+	if len(headers) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, messageExtractCarrier(headers))
+}
+
+// messageInjectCarrier adapts a header setter to propagation.TextMapCarrier.
+// It is write-only.
+type messageInjectCarrier func(key, value string)
+
+func (c messageInjectCarrier) Get(key string) string { return "" }
+func (c messageInjectCarrier) Set(key, value string) { c(key, value) }
+func (c messageInjectCarrier) Keys() []string        { return nil }
+
+// messageExtractCarrier adapts a header map to propagation.TextMapCarrier.
+// It is read-only.
+type messageExtractCarrier map[string]string
+
+func (c messageExtractCarrier) Get(key string) string {
+	if v, ok := c[key]; ok {
+		return v
+	}
+	// Brokers differ in header casing; fall back to a case-insensitive match
+	for k, v := range c {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+func (c messageExtractCarrier) Set(key, value string) {}
+
+func (c messageExtractCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,45 @@
+package tracekit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestMessageContextPropagation verifies trace context and baggage survive a
+// round trip through a plain header map, regardless of header casing
+func TestMessageContextPropagation(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	tp := sdktrace.NewTracerProvider()
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{}
+
+	ctx := sdk.SetBaggage(context.Background(), "tenant.id", "acme")
+	ctx, producer := tp.Tracer("test").Start(ctx, "publish")
+	producer.End()
+
+	headers := map[string]string{}
+	sdk.InjectMessageContext(ctx, func(k, v string) { headers[k] = v })
+
+	// Simulate a broker that upper-cases header names
+	received := map[string]string{}
+	for k, v := range headers {
+		received[strings.ToUpper(k)] = v
+	}
+
+	consumerCtx := sdk.ExtractMessageContext(context.Background(), func() map[string]string { return received })
+	if got := trace.SpanContextFromContext(consumerCtx).TraceID(); got != producer.SpanContext().TraceID() {
+		t.Errorf("extracted trace ID = %s; want %s", got, producer.SpanContext().TraceID())
+	}
+	if got := sdk.GetBaggage(consumerCtx, "tenant.id"); got != "acme" {
+		t.Errorf("tenant.id = %q; want %q", got, "acme")
+	}
+}
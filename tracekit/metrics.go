@@ -1,8 +1,14 @@
 package tracekit
 
 import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Counter tracks monotonically increasing values
@@ -11,6 +17,16 @@ type Counter interface {
 	Add(value float64)
 }
 
+// UpDownCounter tracks a value that can increase and decrease, such as
+// in-flight requests. Unlike a Gauge, whose reports are last-value-wins, its
+// changes are exported as a non-monotonic sum, so values from multiple
+// instances add up to a total.
+type UpDownCounter interface {
+	Add(delta float64)
+	Inc()
+	Dec()
+}
+
 // Gauge tracks point-in-time values
 type Gauge interface {
 	Set(value float64)
@@ -21,6 +37,9 @@ type Gauge interface {
 // Histogram tracks value distributions
 type Histogram interface {
 	Record(value float64)
+	// RecordCtx records value and, when ctx carries a sampled span, keeps
+	// its trace as an exemplar for the value's bucket
+	RecordCtx(ctx context.Context, value float64)
 }
 
 // counter implementation
@@ -28,6 +47,10 @@ type counter struct {
 	name   string
 	tags   map[string]string
 	buffer *metricsBuffer
+
+	// total is the cumulative value for Prometheus scrapes
+	total float64
+	mu    sync.Mutex
 }
 
 func (c *counter) Inc() {
@@ -35,10 +58,14 @@ func (c *counter) Inc() {
 }
 
 func (c *counter) Add(value float64) {
-	if value < 0 {
+	if value < 0 || c.buffer.paused.Load() {
 		return // Counters must be monotonic
 	}
 
+	c.mu.Lock()
+	c.total += value
+	c.mu.Unlock()
+
 	c.buffer.add(metricDataPoint{
 		name:      c.name,
 		tags:      c.tags,
@@ -48,6 +75,43 @@ func (c *counter) Add(value float64) {
 	})
 }
 
+// upDownCounter implementation - exports each change as a delta
+type upDownCounter struct {
+	name   string
+	tags   map[string]string
+	buffer *metricsBuffer
+
+	// value is the running sum for Prometheus scrapes
+	value float64
+	mu    sync.Mutex
+}
+
+func (c *upDownCounter) Inc() {
+	c.Add(1)
+}
+
+func (c *upDownCounter) Dec() {
+	c.Add(-1)
+}
+
+func (c *upDownCounter) Add(delta float64) {
+	if c.buffer.paused.Load() {
+		return
+	}
+
+	c.mu.Lock()
+	c.value += delta
+	c.mu.Unlock()
+
+	c.buffer.add(metricDataPoint{
+		name:      c.name,
+		tags:      c.tags,
+		value:     delta,
+		timestamp: time.Now(),
+		typ:       "updowncounter",
+	})
+}
+
 // gauge implementation
 type gauge struct {
 	name   string
@@ -101,46 +165,207 @@ func (g *gauge) Dec() {
 	})
 }
 
-// histogram implementation
+// DefaultHistogramBuckets are the bucket upper bounds used when neither
+// Config.HistogramBuckets nor WithHistogramBuckets is set (Prometheus defaults)
+var DefaultHistogramBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// HistogramOption configures a histogram created by SDK.Histogram
+type HistogramOption func(*histogramOptions)
+
+type histogramOptions struct {
+	buckets []float64
+}
+
+// WithHistogramBuckets overrides the bucket upper bounds for one histogram
+func WithHistogramBuckets(bounds ...float64) HistogramOption {
+	return func(o *histogramOptions) {
+		o.buckets = bounds
+	}
+}
+
+// histogram implementation - aggregates recordings client-side between flushes
 type histogram struct {
 	name   string
 	tags   map[string]string
+	bounds []float64 // sorted bucket upper bounds
 	buffer *metricsBuffer
+
+	mu           sync.Mutex
+	bucketCounts []uint64    // len(bounds)+1, last bucket is +Inf
+	exemplars    []*exemplar // latest exemplar per bucket, nil if none
+	count        uint64
+	sum          float64
+	min          float64
+	max          float64
+	startTime    time.Time
+
+	// Cumulative state for Prometheus scrapes (not reset by collect)
+	totalBucketCounts []uint64
+	totalCount        uint64
+	totalSum          float64
+	totalMin          float64
+	totalMax          float64
+}
+
+// exemplar links a histogram recording to the trace it happened in
+type exemplar struct {
+	value     float64
+	timestamp time.Time
+	traceID   string
+	spanID    string
+}
+
+// histogramData is the aggregated state of a histogram over one export interval
+type histogramData struct {
+	startTime    time.Time
+	bounds       []float64
+	bucketCounts []uint64
+	exemplars    []*exemplar
+	count        uint64
+	sum          float64
+	min          float64
+	max          float64
+}
+
+func newHistogram(name string, tags map[string]string, bounds []float64, buffer *metricsBuffer) *histogram {
+	sorted := append([]float64(nil), bounds...)
+	sort.Float64s(sorted)
+
+	return &histogram{
+		name:         name,
+		tags:         copyTags(tags),
+		bounds:       sorted,
+		buffer:       buffer,
+		bucketCounts: make([]uint64, len(sorted)+1),
+		exemplars:    make([]*exemplar, len(sorted)+1),
+		startTime:    time.Now(),
+
+		totalBucketCounts: make([]uint64, len(sorted)+1),
+	}
 }
 
 func (h *histogram) Record(value float64) {
-	h.buffer.add(metricDataPoint{
+	h.RecordCtx(context.Background(), value)
+}
+
+func (h *histogram) RecordCtx(ctx context.Context, value float64) {
+	if h.buffer.paused.Load() {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Bucket i counts values in (bounds[i-1], bounds[i]]
+	bucket := sort.SearchFloat64s(h.bounds, value)
+	h.bucketCounts[bucket]++
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() && sc.IsSampled() {
+		h.exemplars[bucket] = &exemplar{
+			value:     value,
+			timestamp: time.Now(),
+			traceID:   sc.TraceID().String(),
+			spanID:    sc.SpanID().String(),
+		}
+	}
+	if h.count == 0 || value < h.min {
+		h.min = value
+	}
+	if h.count == 0 || value > h.max {
+		h.max = value
+	}
+	h.count++
+	h.sum += value
+
+	h.totalBucketCounts[bucket]++
+	if h.totalCount == 0 || value < h.totalMin {
+		h.totalMin = value
+	}
+	if h.totalCount == 0 || value > h.totalMax {
+		h.totalMax = value
+	}
+	h.totalCount++
+	h.totalSum += value
+}
+
+// collect returns the data accumulated since the last collection and resets
+// the histogram. Returns false if nothing was recorded.
+func (h *histogram) collect(now time.Time) (metricDataPoint, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.count == 0 {
+		h.startTime = now
+		return metricDataPoint{}, false
+	}
+
+	data := &histogramData{
+		startTime:    h.startTime,
+		bounds:       h.bounds,
+		bucketCounts: h.bucketCounts,
+		exemplars:    h.exemplars,
+		count:        h.count,
+		sum:          h.sum,
+		min:          h.min,
+		max:          h.max,
+	}
+
+	h.bucketCounts = make([]uint64, len(h.bounds)+1)
+	h.exemplars = make([]*exemplar, len(h.bounds)+1)
+	h.count = 0
+	h.sum = 0
+	h.min = 0
+	h.max = 0
+	h.startTime = now
+
+	return metricDataPoint{
 		name:      h.name,
 		tags:      h.tags,
-		value:     value,
-		timestamp: time.Now(),
+		timestamp: now,
 		typ:       "histogram",
-	})
+		histogram: data,
+	}, true
 }
 
 // metricsRegistry manages all metrics
 type metricsRegistry struct {
-	counters   map[string]*counter
-	gauges     map[string]*gauge
-	histograms map[string]*histogram
-	mu         sync.RWMutex
-	buffer     *metricsBuffer
+	counters       map[string]*counter
+	upDownCounters map[string]*upDownCounter
+	gauges         map[string]*gauge
+	histograms     map[string]*histogram
+	mu             sync.RWMutex
+	buffer         *metricsBuffer
+
+	histogramBuckets   []float64 // default bucket bounds for new histograms
+	histogramQuantiles []float64 // quantiles derived from each histogram
+	prefix             string    // prepended to every metric name
 }
 
-func newMetricsRegistry(endpoint, apiKey, serviceName string) *metricsRegistry {
+func newMetricsRegistry(endpoint string, config *Config, logger *diagLogger) *metricsRegistry {
 	mr := &metricsRegistry{
-		counters:   make(map[string]*counter),
-		gauges:     make(map[string]*gauge),
-		histograms: make(map[string]*histogram),
+		counters:           make(map[string]*counter),
+		upDownCounters:     make(map[string]*upDownCounter),
+		gauges:             make(map[string]*gauge),
+		histograms:         make(map[string]*histogram),
+		histogramBuckets:   config.HistogramBuckets,
+		histogramQuantiles: config.HistogramQuantiles,
+		prefix:             config.MetricsPrefix,
+	}
+	if len(mr.histogramBuckets) == 0 {
+		mr.histogramBuckets = DefaultHistogramBuckets
 	}
 
-	mr.buffer = newMetricsBuffer(endpoint, apiKey, serviceName)
+	mr.buffer = newMetricsBuffer(endpoint, config.APIKey, config.ServiceName,
+		config.MetricsMaxBatchSize, config.MetricsFlushInterval)
+	mr.buffer.collect = mr.collectHistograms
+	mr.buffer.logger = logger
+	mr.buffer.exporter.monotonicTimestamps = config.MetricsMonotonicTimestamps
 	mr.buffer.start()
 
 	return mr
 }
 
 func (mr *metricsRegistry) counter(name string, tags map[string]string) Counter {
+	name = mr.prefix + name
 	key := metricKey(name, tags)
 
 	mr.mu.RLock()
@@ -167,7 +392,36 @@ func (mr *metricsRegistry) counter(name string, tags map[string]string) Counter
 	return c
 }
 
+func (mr *metricsRegistry) upDownCounter(name string, tags map[string]string) UpDownCounter {
+	name = mr.prefix + name
+	key := metricKey(name, tags)
+
+	mr.mu.RLock()
+	if c, exists := mr.upDownCounters[key]; exists {
+		mr.mu.RUnlock()
+		return c
+	}
+	mr.mu.RUnlock()
+
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	// Double-check after lock
+	if c, exists := mr.upDownCounters[key]; exists {
+		return c
+	}
+
+	c := &upDownCounter{
+		name:   name,
+		tags:   copyTags(tags),
+		buffer: mr.buffer,
+	}
+	mr.upDownCounters[key] = c
+	return c
+}
+
 func (mr *metricsRegistry) gauge(name string, tags map[string]string) Gauge {
+	name = mr.prefix + name
 	key := metricKey(name, tags)
 
 	mr.mu.RLock()
@@ -194,7 +448,8 @@ func (mr *metricsRegistry) gauge(name string, tags map[string]string) Gauge {
 	return g
 }
 
-func (mr *metricsRegistry) histogram(name string, tags map[string]string) Histogram {
+func (mr *metricsRegistry) histogram(name string, tags map[string]string, opts ...HistogramOption) Histogram {
+	name = mr.prefix + name
 	key := metricKey(name, tags)
 
 	mr.mu.RLock()
@@ -212,17 +467,99 @@ func (mr *metricsRegistry) histogram(name string, tags map[string]string) Histog
 		return h
 	}
 
-	h := &histogram{
-		name:   name,
-		tags:   copyTags(tags),
-		buffer: mr.buffer,
+	options := histogramOptions{buckets: mr.histogramBuckets}
+	for _, opt := range opts {
+		opt(&options)
 	}
+
+	h := newHistogram(name, tags, options.buckets, mr.buffer)
 	mr.histograms[key] = h
 	return h
 }
 
-func (mr *metricsRegistry) shutdown() {
-	mr.buffer.shutdown()
+// quantilePoints derives a "<name>.quantile" gauge point per quantile, tagged
+// with quantile="<q>", from an interval's aggregated histogram
+func quantilePoints(dp metricDataPoint, quantiles []float64) []metricDataPoint {
+	h := dp.histogram
+	points := make([]metricDataPoint, 0, len(quantiles))
+	for _, q := range quantiles {
+		tags := copyTags(dp.tags)
+		if tags == nil {
+			tags = make(map[string]string, 1)
+		}
+		tags["quantile"] = strconv.FormatFloat(q, 'g', -1, 64)
+		points = append(points, metricDataPoint{
+			name:      dp.name + ".quantile",
+			tags:      tags,
+			value:     estimateQuantile(q, h.bounds, h.bucketCounts, h.min, h.max),
+			timestamp: dp.timestamp,
+			typ:       "gauge",
+		})
+	}
+	return points
+}
+
+// estimateQuantile estimates quantile q (0..1) from bucket counts by linear
+// interpolation within the bucket holding the target rank, like Prometheus'
+// histogram_quantile. min and max bound the first and +Inf buckets, and the
+// result is clamped to them.
+func estimateQuantile(q float64, bounds []float64, counts []uint64, min, max float64) float64 {
+	var total uint64
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := q * float64(total)
+	var cumulative uint64
+	for i, c := range counts {
+		if c == 0 || float64(cumulative+c) < rank {
+			cumulative += c
+			continue
+		}
+
+		lower, upper := min, max
+		if i > 0 && bounds[i-1] > lower {
+			lower = bounds[i-1]
+		}
+		if i < len(bounds) && bounds[i] < upper {
+			upper = bounds[i]
+		}
+		value := lower + (upper-lower)*(rank-float64(cumulative))/float64(c)
+		return math.Max(min, math.Min(max, value))
+	}
+	return max
+}
+
+// collectHistograms gathers aggregated histogram data for export
+func (mr *metricsRegistry) collectHistograms() []metricDataPoint {
+	now := time.Now()
+
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	var dataPoints []metricDataPoint
+	for _, h := range mr.histograms {
+		if dp, ok := h.collect(now); ok {
+			dataPoints = append(dataPoints, dp)
+			dataPoints = append(dataPoints, quantilePoints(dp, mr.histogramQuantiles)...)
+		}
+	}
+	return dataPoints
+}
+
+func (mr *metricsRegistry) setEnabled(enabled bool) {
+	mr.buffer.paused.Store(!enabled)
+}
+
+func (mr *metricsRegistry) flush() {
+	mr.buffer.flush()
+}
+
+func (mr *metricsRegistry) shutdown(ctx context.Context) error {
+	return mr.buffer.shutdown(ctx)
 }
 
 // Helper: create unique key for metric
@@ -231,15 +568,20 @@ func metricKey(name string, tags map[string]string) string {
 		return name
 	}
 
-	// Simple key format: name{k1=v1,k2=v2}
+	// Simple key format: name{k1=v1,k2=v2}, tags sorted so map order
+	// doesn't split one series into several
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
 	key := name + "{"
-	first := true
-	for k, v := range tags {
-		if !first {
+	for i, k := range keys {
+		if i > 0 {
 			key += ","
 		}
-		key += k + "=" + v
-		first = false
+		key += k + "=" + tags[k]
 	}
 	key += "}"
 	return key
@@ -259,24 +601,84 @@ func copyTags(tags map[string]string) map[string]string {
 
 // SDK methods for metrics
 func (s *SDK) Counter(name string, tags map[string]string) Counter {
+	if !s.IsEnabled() {
+		return &noopCounter{}
+	}
+	if s.otelMetrics != nil {
+		return s.otelMetrics.counter(name, tags)
+	}
 	if s.metricsRegistry == nil {
 		return &noopCounter{}
 	}
 	return s.metricsRegistry.counter(name, tags)
 }
 
+// UpDownCounter returns a counter that can go up and down and is summed
+// across instances. Use it for quantities like in-flight requests or queue
+// depth; use Gauge for values that are sampled, like temperature or memory
+// usage, where only the latest report matters.
+func (s *SDK) UpDownCounter(name string, tags map[string]string) UpDownCounter {
+	if !s.IsEnabled() {
+		return &noopUpDownCounter{}
+	}
+	if s.otelMetrics != nil {
+		return s.otelMetrics.upDownCounter(name, tags)
+	}
+	if s.metricsRegistry == nil {
+		return &noopUpDownCounter{}
+	}
+	return s.metricsRegistry.upDownCounter(name, tags)
+}
+
 func (s *SDK) Gauge(name string, tags map[string]string) Gauge {
+	if !s.IsEnabled() {
+		return &noopGauge{}
+	}
+	if s.otelMetrics != nil {
+		return s.otelMetrics.gauge(name, tags)
+	}
 	if s.metricsRegistry == nil {
 		return &noopGauge{}
 	}
 	return s.metricsRegistry.gauge(name, tags)
 }
 
-func (s *SDK) Histogram(name string, tags map[string]string) Histogram {
+// Histogram returns a histogram that aggregates recordings into buckets
+// client-side. Bucket bounds default to Config.HistogramBuckets and can be
+// overridden per histogram with WithHistogramBuckets.
+func (s *SDK) Histogram(name string, tags map[string]string, opts ...HistogramOption) Histogram {
+	if !s.IsEnabled() {
+		return &noopHistogram{}
+	}
+	if s.otelMetrics != nil {
+		return s.otelMetrics.histogram(name, tags, opts...)
+	}
 	if s.metricsRegistry == nil {
 		return &noopHistogram{}
 	}
-	return s.metricsRegistry.histogram(name, tags)
+	return s.metricsRegistry.histogram(name, tags, opts...)
+}
+
+// Timer records durations in milliseconds into a histogram
+type Timer struct {
+	histogram Histogram
+}
+
+// Timer returns a Timer recording into the histogram name/tags, shared with
+// Histogram so repeated calls reuse the same histogram:
+//
+//	defer sdk.Timer("db.op", tags).Start()()
+func (s *SDK) Timer(name string, tags map[string]string, opts ...HistogramOption) *Timer {
+	return &Timer{histogram: s.Histogram(name, tags, opts...)}
+}
+
+// Start begins timing and returns a function that records the elapsed
+// milliseconds when called
+func (t *Timer) Start() func() {
+	start := time.Now()
+	return func() {
+		t.histogram.Record(float64(time.Since(start)) / float64(time.Millisecond))
+	}
 }
 
 // No-op implementations for when metrics are disabled
@@ -285,6 +687,12 @@ type noopCounter struct{}
 func (n *noopCounter) Inc()             {}
 func (n *noopCounter) Add(value float64) {}
 
+type noopUpDownCounter struct{}
+
+func (n *noopUpDownCounter) Add(delta float64) {}
+func (n *noopUpDownCounter) Inc()              {}
+func (n *noopUpDownCounter) Dec()              {}
+
 type noopGauge struct{}
 
 func (n *noopGauge) Set(value float64) {}
@@ -293,4 +701,5 @@ func (n *noopGauge) Dec()              {}
 
 type noopHistogram struct{}
 
-func (n *noopHistogram) Record(value float64) {}
+func (n *noopHistogram) Record(value float64)                         {}
+func (n *noopHistogram) RecordCtx(ctx context.Context, value float64) {}
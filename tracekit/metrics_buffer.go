@@ -1,7 +1,9 @@
 package tracekit
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -11,7 +13,8 @@ type metricDataPoint struct {
 	tags      map[string]string
 	value     float64
 	timestamp time.Time
-	typ       string // "counter", "gauge", "histogram"
+	typ       string         // "counter", "gauge", "histogram"
+	histogram *histogramData // aggregated data, set only for histograms
 }
 
 // metricsBuffer collects metrics and flushes them periodically
@@ -20,37 +23,81 @@ type metricsBuffer struct {
 	mu       sync.Mutex
 	exporter *metricsExporter
 	stop     chan struct{}
+	done     chan struct{} // closed when the flush loop has finished its final flush
+	started  bool
+	paused   atomic.Bool // set while the SDK is disabled; new points are discarded
+
+	// flushMu serializes exports so only one runs at a time; flushSignal
+	// asks the flush loop for an early flush when the buffer fills up
+	flushMu     sync.Mutex
+	flushSignal chan struct{}
+
+	// collect returns aggregated data points (histograms) to include in each flush
+	collect func() []metricDataPoint
+
+	logger *diagLogger
 
 	maxSize      int
 	flushInterval time.Duration
+
+	// Retry policy for retryable export failures
+	maxAttempts    int           // total export attempts per flush
+	retryBaseDelay time.Duration // delay before the first retry, doubled each time
+	maxBuffered    int           // cap on points kept in memory after a failed export
 }
 
-func newMetricsBuffer(endpoint, apiKey, serviceName string) *metricsBuffer {
+// newMetricsBuffer creates a buffer that exports once maxSize points are
+// collected or every flushInterval, whichever comes first
+// (zero values = 100 points / 10s)
+func newMetricsBuffer(endpoint, apiKey, serviceName string, maxSize int, flushInterval time.Duration) *metricsBuffer {
+	if maxSize <= 0 {
+		maxSize = 100
+	}
+	if flushInterval <= 0 {
+		flushInterval = 10 * time.Second
+	}
+
 	return &metricsBuffer{
-		data:          make([]metricDataPoint, 0, 100),
-		exporter:      newMetricsExporter(endpoint, apiKey, serviceName),
-		stop:          make(chan struct{}),
-		maxSize:       100,
-		flushInterval: 10 * time.Second,
+		data:           make([]metricDataPoint, 0, maxSize),
+		exporter:       newMetricsExporter(endpoint, apiKey, serviceName),
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+		flushSignal:    make(chan struct{}, 1),
+		maxSize:        maxSize,
+		flushInterval:  flushInterval,
+		maxAttempts:    3,
+		retryBaseDelay: 500 * time.Millisecond,
+		maxBuffered:    maxSize * 100,
 	}
 }
 
 func (b *metricsBuffer) add(dp metricDataPoint) {
+	if b.paused.Load() {
+		return
+	}
+
 	b.mu.Lock()
 	b.data = append(b.data, dp)
 	shouldFlush := len(b.data) >= b.maxSize
 	b.mu.Unlock()
 
 	if shouldFlush {
-		go b.flush()
+		// Non-blocking: a pending signal already covers this overflow
+		select {
+		case b.flushSignal <- struct{}{}:
+		default:
+		}
 	}
 }
 
 func (b *metricsBuffer) start() {
+	b.started = true
 	go b.flushLoop()
 }
 
 func (b *metricsBuffer) flushLoop() {
+	defer close(b.done)
+
 	ticker := time.NewTicker(b.flushInterval)
 	defer ticker.Stop()
 
@@ -61,12 +108,23 @@ func (b *metricsBuffer) flushLoop() {
 			return
 		case <-ticker.C:
 			b.flush()
+		case <-b.flushSignal:
+			b.flush()
 		}
 	}
 }
 
 func (b *metricsBuffer) flush() {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	var collected []metricDataPoint
+	if b.collect != nil {
+		collected = b.collect()
+	}
+
 	b.mu.Lock()
+	b.data = append(b.data, collected...)
 	if len(b.data) == 0 {
 		b.mu.Unlock()
 		return
@@ -78,14 +136,65 @@ func (b *metricsBuffer) flush() {
 	b.mu.Unlock()
 
 	// Export in background
-	if err := b.exporter.export(dataPoints); err != nil {
-		// Silent fail - metrics are best-effort
-		// TODO: Add optional logging
+	if err := b.exportWithRetry(dataPoints); err != nil {
+		// Metrics are best-effort: keep retryable failures for the next flush,
+		// drop batches the backend rejected outright
+		if isRetryableExportError(err) {
+			b.logger.warnf("TraceKit: metrics export failed, re-buffering %d points: %v", len(dataPoints), err)
+			b.requeue(dataPoints)
+		} else {
+			b.logger.warnf("TraceKit: metrics export rejected, dropping %d points: %v", len(dataPoints), err)
+		}
+		return
+	}
+
+	b.logger.debugf("TraceKit: exported %d metric points", len(dataPoints))
+}
+
+// exportWithRetry exports data points, retrying retryable failures with
+// exponential backoff. Retries stop early when the buffer is shutting down.
+func (b *metricsBuffer) exportWithRetry(dataPoints []metricDataPoint) error {
+	delay := b.retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := b.exporter.export(dataPoints)
+		if err == nil || !isRetryableExportError(err) || attempt >= b.maxAttempts {
+			return err
+		}
+		b.logger.debugf("TraceKit: metrics export attempt %d failed, retrying in %s: %v", attempt, delay, err)
+
+		select {
+		case <-time.After(delay):
+		case <-b.stop:
+			return err
+		}
+		delay *= 2
 	}
 }
 
-func (b *metricsBuffer) shutdown() {
+// requeue puts failed data points back at the front of the buffer,
+// dropping the oldest points once maxBuffered is exceeded
+func (b *metricsBuffer) requeue(dataPoints []metricDataPoint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.data = append(dataPoints, b.data...)
+	if over := len(b.data) - b.maxBuffered; over > 0 {
+		b.data = b.data[over:]
+	}
+}
+
+// shutdown stops the flush loop and waits until its final flush has been
+// exported, or until ctx is done. Retries of the final flush are skipped.
+func (b *metricsBuffer) shutdown(ctx context.Context) error {
 	close(b.stop)
-	// Give it a moment to finish the final flush
-	time.Sleep(100 * time.Millisecond)
+	if !b.started {
+		return nil
+	}
+
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
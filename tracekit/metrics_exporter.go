@@ -3,8 +3,10 @@ package tracekit
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -15,6 +17,10 @@ type metricsExporter struct {
 	apiKey      string
 	serviceName string
 	client      *http.Client
+
+	// monotonicTimestamps clamps each point to at least lastTimestamp
+	monotonicTimestamps bool
+	lastTimestamp       time.Time
 }
 
 func newMetricsExporter(endpoint, apiKey, serviceName string) *metricsExporter {
@@ -33,6 +39,10 @@ func (e *metricsExporter) export(dataPoints []metricDataPoint) error {
 		return nil
 	}
 
+	if e.monotonicTimestamps {
+		e.clampTimestamps(dataPoints)
+	}
+
 	payload := e.toOTLP(dataPoints)
 
 	jsonData, err := json.Marshal(payload)
@@ -55,12 +65,57 @@ func (e *metricsExporter) export(dataPoints []metricDataPoint) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("bad status: %d", resp.StatusCode)
+		return &exportStatusError{statusCode: resp.StatusCode}
 	}
 
 	return nil
 }
 
+// clampTimestamps moves data points timestamped before the latest exported
+// timestamp forward to it, then advances the latest timestamp. Exports are
+// serialized by metricsBuffer.flushMu, so no locking is needed.
+func (e *metricsExporter) clampTimestamps(dataPoints []metricDataPoint) {
+	latest := e.lastTimestamp
+	for i := range dataPoints {
+		if dataPoints[i].timestamp.Before(e.lastTimestamp) {
+			dataPoints[i].timestamp = e.lastTimestamp
+		}
+		if dataPoints[i].timestamp.After(latest) {
+			latest = dataPoints[i].timestamp
+		}
+	}
+	e.lastTimestamp = latest
+}
+
+// exportStatusError is returned when the backend rejects an export
+type exportStatusError struct {
+	statusCode int
+}
+
+func (e *exportStatusError) Error() string {
+	return fmt.Sprintf("bad status: %d", e.statusCode)
+}
+
+// isRetryableExportError returns true for network errors and for
+// 429/500/502/503/504 responses. Other 4xx responses fail fast.
+func isRetryableExportError(err error) bool {
+	var statusErr *exportStatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.statusCode {
+		case http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
 // toOTLP converts metrics to OTLP format
 func (e *metricsExporter) toOTLP(dataPoints []metricDataPoint) map[string]interface{} {
 	// Group by name and type
@@ -92,6 +147,11 @@ func (e *metricsExporter) toOTLP(dataPoints []metricDataPoint) map[string]interf
 				})
 			}
 
+			if dp.histogram != nil {
+				otlpDPs = append(otlpDPs, histogramToOTLP(dp, attributes))
+				continue
+			}
+
 			otlpDPs = append(otlpDPs, map[string]interface{}{
 				"attributes":   attributes,
 				"timeUnixNano": fmt.Sprintf("%d", dp.timestamp.UnixNano()),
@@ -111,13 +171,30 @@ func (e *metricsExporter) toOTLP(dataPoints []metricDataPoint) map[string]interf
 					"isMonotonic":            true,
 				},
 			}
-		case "gauge", "histogram":
+		case "updowncounter":
+			metric = map[string]interface{}{
+				"name": name,
+				"sum": map[string]interface{}{
+					"dataPoints":             otlpDPs,
+					"aggregationTemporality": 1, // DELTA - each point is a change
+					"isMonotonic":            false,
+				},
+			}
+		case "gauge":
 			metric = map[string]interface{}{
 				"name": name,
 				"gauge": map[string]interface{}{
 					"dataPoints": otlpDPs,
 				},
 			}
+		case "histogram":
+			metric = map[string]interface{}{
+				"name": name,
+				"histogram": map[string]interface{}{
+					"dataPoints":             otlpDPs,
+					"aggregationTemporality": 1, // DELTA - histograms reset after each flush
+				},
+			}
 		}
 
 		metrics = append(metrics, metric)
@@ -148,3 +225,42 @@ func (e *metricsExporter) toOTLP(dataPoints []metricDataPoint) map[string]interf
 		},
 	}
 }
+
+// histogramToOTLP converts an aggregated histogram data point to OTLP format
+func histogramToOTLP(dp metricDataPoint, attributes []map[string]interface{}) map[string]interface{} {
+	h := dp.histogram
+
+	bucketCounts := make([]string, len(h.bucketCounts))
+	for i, c := range h.bucketCounts {
+		bucketCounts[i] = fmt.Sprintf("%d", c)
+	}
+
+	var exemplars []map[string]interface{}
+	for _, ex := range h.exemplars {
+		if ex == nil {
+			continue
+		}
+		exemplars = append(exemplars, map[string]interface{}{
+			"timeUnixNano": fmt.Sprintf("%d", ex.timestamp.UnixNano()),
+			"asDouble":     ex.value,
+			"traceId":      ex.traceID,
+			"spanId":       ex.spanID,
+		})
+	}
+
+	point := map[string]interface{}{
+		"attributes":        attributes,
+		"startTimeUnixNano": fmt.Sprintf("%d", h.startTime.UnixNano()),
+		"timeUnixNano":      fmt.Sprintf("%d", dp.timestamp.UnixNano()),
+		"count":             fmt.Sprintf("%d", h.count),
+		"sum":               h.sum,
+		"min":               h.min,
+		"max":               h.max,
+		"bucketCounts":      bucketCounts,
+		"explicitBounds":    h.bounds,
+	}
+	if len(exemplars) > 0 {
+		point["exemplars"] = exemplars
+	}
+	return point
+}
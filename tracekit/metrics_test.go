@@ -0,0 +1,277 @@
+package tracekit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestMetricsExportRetry verifies retryable statuses are retried and 4xx fails fast
+func TestMetricsExportRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantAttempts int32
+		wantBuffered int
+	}{
+		{name: "retry then succeed", statuses: []int{503, 502, 200}, wantAttempts: 3, wantBuffered: 0},
+		{name: "bad request fails fast", statuses: []int{400}, wantAttempts: 1, wantBuffered: 0},
+		{name: "exhausted retries re-buffer", statuses: []int{503, 503, 503}, wantAttempts: 3, wantBuffered: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(tt.statuses[int(n-1)%len(tt.statuses)])
+			}))
+			defer server.Close()
+
+			b := newMetricsBuffer(server.URL, "test-key", "test-service", 0, 0)
+			b.retryBaseDelay = time.Millisecond
+			b.add(metricDataPoint{name: "requests", value: 1, timestamp: time.Now(), typ: "counter"})
+			b.flush()
+
+			if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+				t.Errorf("export attempts = %d; want %d", got, tt.wantAttempts)
+			}
+			if got := len(b.data); got != tt.wantBuffered {
+				t.Errorf("buffered points = %d; want %d", got, tt.wantBuffered)
+			}
+		})
+	}
+}
+
+// TestHistogramAggregation verifies recordings are bucketed and reset on collect
+func TestHistogramAggregation(t *testing.T) {
+	b := newMetricsBuffer("http://localhost", "test-key", "test-service", 0, 0)
+	h := newHistogram("latency", nil, []float64{10, 1, 5}, b)
+
+	for _, v := range []float64{0.5, 1, 3, 7, 20} {
+		h.Record(v)
+	}
+
+	dp, ok := h.collect(time.Now())
+	if !ok {
+		t.Fatal("expected histogram data after recordings")
+	}
+
+	data := dp.histogram
+	wantCounts := []uint64{2, 1, 1, 1} // (-inf,1] (1,5] (5,10] (10,+inf)
+	for i, want := range wantCounts {
+		if data.bucketCounts[i] != want {
+			t.Errorf("bucket %d count = %d; want %d", i, data.bucketCounts[i], want)
+		}
+	}
+	if data.count != 5 || data.sum != 31.5 || data.min != 0.5 || data.max != 20 {
+		t.Errorf("unexpected aggregate: count=%d sum=%v min=%v max=%v", data.count, data.sum, data.min, data.max)
+	}
+
+	if _, ok := h.collect(time.Now()); ok {
+		t.Error("expected no data after collect reset the histogram")
+	}
+}
+
+// TestClampTimestamps verifies timestamps never go backwards across exports
+func TestClampTimestamps(t *testing.T) {
+	e := newMetricsExporter("http://localhost", "test-key", "test-service")
+	base := time.Now()
+
+	first := []metricDataPoint{{timestamp: base}, {timestamp: base.Add(time.Second)}}
+	e.clampTimestamps(first)
+
+	// A clock stepped back 5s produces points earlier than the last export
+	second := []metricDataPoint{{timestamp: base.Add(-5 * time.Second)}, {timestamp: base.Add(2 * time.Second)}}
+	e.clampTimestamps(second)
+
+	if want := base.Add(time.Second); !second[0].timestamp.Equal(want) {
+		t.Errorf("skewed timestamp = %v; want clamped to %v", second[0].timestamp, want)
+	}
+	if want := base.Add(2 * time.Second); !second[1].timestamp.Equal(want) {
+		t.Errorf("later timestamp = %v; want unchanged %v", second[1].timestamp, want)
+	}
+}
+
+type recordingHistogram struct {
+	values []float64
+}
+
+func (h *recordingHistogram) Record(value float64) {
+	h.values = append(h.values, value)
+}
+
+func (h *recordingHistogram) RecordCtx(ctx context.Context, value float64) {
+	h.Record(value)
+}
+
+// TestTimer verifies the stop function records elapsed milliseconds once per call
+func TestTimer(t *testing.T) {
+	h := &recordingHistogram{}
+	timer := &Timer{histogram: h}
+
+	stop := timer.Start()
+	time.Sleep(20 * time.Millisecond)
+	stop()
+
+	if len(h.values) != 1 || h.values[0] < 20 || h.values[0] > 1000 {
+		t.Errorf("recorded %v; want one value of about 20ms", h.values)
+	}
+}
+
+// TestUpDownCounterExport verifies up/down changes export as a non-monotonic delta sum
+func TestUpDownCounterExport(t *testing.T) {
+	b := newMetricsBuffer("http://localhost", "test-key", "test-service", 0, 0)
+	c := &upDownCounter{name: "http.in_flight", buffer: b}
+	c.Inc()
+	c.Dec()
+
+	payload := b.exporter.toOTLP(b.data)
+	metrics := payload["resourceMetrics"].([]map[string]interface{})[0]["scopeMetrics"].([]map[string]interface{})[0]["metrics"].([]map[string]interface{})
+	sum, ok := metrics[0]["sum"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("metric = %v; want a sum", metrics[0])
+	}
+	if sum["isMonotonic"] != false || sum["aggregationTemporality"] != 1 {
+		t.Errorf("sum = %v; want non-monotonic DELTA", sum)
+	}
+	if dps := sum["dataPoints"].([]map[string]interface{}); len(dps) != 2 || dps[1]["asDouble"] != -1.0 {
+		t.Errorf("dataPoints = %v; want +1 then -1", dps)
+	}
+}
+
+// TestHistogramExemplars verifies sampled recordings export as bucket exemplars
+func TestHistogramExemplars(t *testing.T) {
+	b := newMetricsBuffer("http://localhost", "test-key", "test-service", 0, 0)
+	h := newHistogram("latency", nil, []float64{100}, b)
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	h.RecordCtx(trace.ContextWithSpanContext(context.Background(), sc), 250)
+	h.Record(50)
+
+	dp, _ := h.collect(time.Now())
+	point := histogramToOTLP(dp, nil)
+	exemplars, _ := point["exemplars"].([]map[string]interface{})
+	if len(exemplars) != 1 {
+		t.Fatalf("exemplars = %v; want one", point["exemplars"])
+	}
+	if exemplars[0]["traceId"] != sc.TraceID().String() || exemplars[0]["spanId"] != sc.SpanID().String() || exemplars[0]["asDouble"] != 250.0 {
+		t.Errorf("exemplar = %v; want trace %s span %s value 250", exemplars[0], sc.TraceID(), sc.SpanID())
+	}
+}
+
+// TestMetricKeyTagOrder verifies tag maps built in different orders resolve to one counter
+func TestMetricKeyTagOrder(t *testing.T) {
+	mr := &metricsRegistry{counters: make(map[string]*counter)}
+
+	first := map[string]string{}
+	second := map[string]string{}
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	for i, k := range keys {
+		first[k] = k
+		second[keys[len(keys)-1-i]] = keys[len(keys)-1-i]
+	}
+
+	for i := 0; i < 20; i++ {
+		if mr.counter("requests", first) != mr.counter("requests", second) {
+			t.Fatal("same tags in different order returned different counters")
+		}
+	}
+	if len(mr.counters) != 1 {
+		t.Errorf("registry has %d counters; want 1", len(mr.counters))
+	}
+}
+
+// TestEstimateQuantile verifies quantiles interpolate within buckets and stay within min/max
+func TestEstimateQuantile(t *testing.T) {
+	bounds := []float64{10, 100}
+	counts := []uint64{50, 40, 10} // (-inf,10] (10,100] (100,+inf)
+
+	tests := []struct {
+		q    float64
+		want float64
+	}{
+		{q: 0.5, want: 10},
+		{q: 0.7, want: 55},
+		{q: 0.95, want: 150},
+		{q: 1, want: 200},
+		{q: 0, want: 2},
+	}
+	for _, tt := range tests {
+		if got := estimateQuantile(tt.q, bounds, counts, 2, 200); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("estimateQuantile(%v) = %v; want %v", tt.q, got, tt.want)
+		}
+	}
+
+	if got := estimateQuantile(0.5, bounds, make([]uint64, 3), 0, 0); got != 0 {
+		t.Errorf("estimateQuantile() on empty histogram = %v; want 0", got)
+	}
+}
+
+// TestMetricsShutdownWaitsForFinalExport verifies shutdown returns only after
+// the final flush is exported, or when its context expires
+func TestMetricsShutdownWaitsForFinalExport(t *testing.T) {
+	var exported atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond) // Slow network
+		exported.Add(1)
+	}))
+	defer server.Close()
+
+	b := newMetricsBuffer(server.URL, "test-key", "test-service", 0, time.Hour)
+	b.logger = newDiagLogger()
+	b.start()
+	b.add(metricDataPoint{name: "requests", value: 1, timestamp: time.Now(), typ: "counter"})
+
+	if err := b.shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+	if exported.Load() != 1 {
+		t.Errorf("final flush exported %d times before shutdown returned; want 1", exported.Load())
+	}
+
+	// A context that expires first bounds the wait
+	b = newMetricsBuffer(server.URL, "test-key", "test-service", 0, time.Hour)
+	b.logger = newDiagLogger()
+	b.start()
+	b.add(metricDataPoint{name: "requests", value: 1, timestamp: time.Now(), typ: "counter"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("shutdown() with expired context = %v; want context.DeadlineExceeded", err)
+	}
+}
+
+// TestStartRuntimeMetrics verifies runtime gauges are recorded and the
+// collector can be stopped
+func TestStartRuntimeMetrics(t *testing.T) {
+	mr := &metricsRegistry{
+		gauges: make(map[string]*gauge),
+		buffer: newMetricsBuffer("http://localhost", "test-key", "test-service", 0, 0),
+	}
+	sdk := &SDK{metricsRegistry: mr}
+
+	stop := sdk.StartRuntimeMetrics(time.Hour) // Collects once at start
+	stop()
+	stop() // Idempotent
+	sdk.stopCollectors()
+
+	for _, name := range []string{"runtime.go.goroutines", "runtime.go.mem.heap_alloc", "runtime.go.mem.sys"} {
+		g, ok := mr.gauges[metricKey(name, nil)]
+		if !ok || g.value <= 0 {
+			t.Errorf("gauge %s not recorded", name)
+		}
+	}
+}
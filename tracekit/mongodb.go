@@ -1,24 +1,123 @@
 package tracekit
 
 import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
+// mongoNoiseCommands are the handshake, auth and liveness commands dropped
+// by DefaultMongoCommandFilter
+var mongoNoiseCommands = map[string]bool{
+	"hello":        true,
+	"isMaster":     true,
+	"ismaster":     true,
+	"ping":         true,
+	"buildInfo":    true,
+	"saslStart":    true,
+	"saslContinue": true,
+	"endSessions":  true,
+}
+
+// DefaultMongoCommandFilter traces every command except handshake, auth and
+// liveness commands (hello/isMaster, ping, buildInfo, saslStart/saslContinue,
+// endSessions), which otherwise flood traces with one span per check.
+func DefaultMongoCommandFilter(cmdName string) bool {
+	return !mongoNoiseCommands[cmdName]
+}
+
 // MongoClientOptions returns MongoDB client options with OpenTelemetry instrumentation
 func (s *SDK) MongoClientOptions() *options.ClientOptions {
 	opts := options.Client()
-	opts.Monitor = otelmongo.NewMonitor(
+	opts.Monitor = s.mongoMonitor()
+	return opts
+}
+
+// mongoMonitor returns an otelmongo command monitor that skips commands
+// rejected by Config.MongoCommandFilter. otelmongo names spans
+// "<collection>.<command>" and records db.mongodb.collection and
+// db.operation itself.
+func (s *SDK) mongoMonitor() *event.CommandMonitor {
+	monitor := otelmongo.NewMonitor(
 		otelmongo.WithTracerProvider(s.tracerProvider),
 	)
+
+	filter := DefaultMongoCommandFilter
+	if s.config != nil && s.config.MongoCommandFilter != nil {
+		filter = s.config.MongoCommandFilter
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			if filter(evt.CommandName) {
+				monitor.Started(ctx, evt)
+			}
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			if filter(evt.CommandName) {
+				monitor.Succeeded(ctx, evt)
+			}
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			if filter(evt.CommandName) {
+				monitor.Failed(ctx, evt)
+			}
+		},
+	}
+}
+
+// InstrumentMongoClientOptions adds tracing to client options built
+// elsewhere. A monitor already set on opts keeps receiving every event after
+// the tracing monitor. Returns opts (a new options object if opts is nil).
+//
+//	opts := sdk.InstrumentMongoClientOptions(options.Client().ApplyURI(uri))
+//	client, err := mongo.Connect(ctx, opts)
+func (s *SDK) InstrumentMongoClientOptions(opts *options.ClientOptions) *options.ClientOptions {
+	if opts == nil {
+		opts = options.Client()
+	}
+	opts.Monitor = chainCommandMonitors(s.mongoMonitor(), opts.Monitor)
 	return opts
 }
 
-// WrapMongoClient wraps an existing MongoDB client with OpenTelemetry (not recommended, use MongoClientOptions instead)
-// Note: This should be called before any operations on the client
+// chainCommandMonitors returns a monitor forwarding each event to first,
+// then to second (if non-nil)
+func chainCommandMonitors(first, second *event.CommandMonitor) *event.CommandMonitor {
+	if second == nil {
+		return first
+	}
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			first.Started(ctx, evt)
+			if second.Started != nil {
+				second.Started(ctx, evt)
+			}
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			first.Succeeded(ctx, evt)
+			if second.Succeeded != nil {
+				second.Succeeded(ctx, evt)
+			}
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			first.Failed(ctx, evt)
+			if second.Failed != nil {
+				second.Failed(ctx, evt)
+			}
+		},
+	}
+}
+
+// WrapMongoClient cannot add tracing: the driver fixes a client's command
+// monitor when the client is constructed. It logs a warning and returns
+// client unchanged.
+//
+// Deprecated: Create the client with MongoClientOptions, or pass existing
+// options through InstrumentMongoClientOptions before mongo.Connect.
 func (s *SDK) WrapMongoClient(client *mongo.Client) *mongo.Client {
-	// MongoDB doesn't support wrapping existing clients well
-	// Users should use MongoClientOptions() when creating the client
+	s.logger.warnf("TraceKit: WrapMongoClient cannot instrument an existing MongoDB client; use InstrumentMongoClientOptions before mongo.Connect")
 	return client
 }
@@ -0,0 +1,91 @@
+package tracekit
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestMongoCommandFilter verifies filtered commands produce no spans and
+// traced commands keep their collection attribute
+func TestMongoCommandFilter(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+
+	sdk := &SDK{config: &Config{}, tracerProvider: tp}
+	monitor := sdk.mongoMonitor()
+
+	for i, cmd := range []bson.D{{{Key: "ping", Value: 1}}, {{Key: "find", Value: "orders"}}} {
+		raw, err := bson.Marshal(cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		started := &event.CommandStartedEvent{
+			Command:      raw,
+			DatabaseName: "shop",
+			CommandName:  cmd[0].Key,
+			RequestID:    int64(i),
+			ConnectionID: "localhost:27017",
+		}
+		monitor.Started(context.Background(), started)
+		monitor.Succeeded(context.Background(), &event.CommandSucceededEvent{
+			CommandFinishedEvent: event.CommandFinishedEvent{
+				CommandName:  started.CommandName,
+				RequestID:    started.RequestID,
+				ConnectionID: started.ConnectionID,
+			},
+		})
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "orders.find" {
+		names := make([]string, len(spans))
+		for i, span := range spans {
+			names[i] = span.Name()
+		}
+		t.Fatalf("spans = %v; want only orders.find", names)
+	}
+	var collection string
+	for _, attr := range spans[0].Attributes() {
+		if attr.Key == "db.mongodb.collection" {
+			collection = attr.Value.AsString()
+		}
+	}
+	if collection != "orders" {
+		t.Errorf("db.mongodb.collection = %q; want orders", collection)
+	}
+}
+
+// TestInstrumentMongoClientOptions verifies tracing is added while an
+// existing monitor still receives events
+func TestInstrumentMongoClientOptions(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{config: &Config{}, tracerProvider: tp}
+
+	var userEvents int
+	opts := options.Client().SetMonitor(&event.CommandMonitor{
+		Started: func(context.Context, *event.CommandStartedEvent) { userEvents++ },
+	})
+	opts = sdk.InstrumentMongoClientOptions(opts)
+
+	raw, _ := bson.Marshal(bson.D{{Key: "insert", Value: "orders"}})
+	opts.Monitor.Started(context.Background(), &event.CommandStartedEvent{Command: raw, CommandName: "insert", ConnectionID: "db:27017"})
+	opts.Monitor.Succeeded(context.Background(), &event.CommandSucceededEvent{
+		CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "insert", ConnectionID: "db:27017"},
+	})
+
+	if userEvents != 1 {
+		t.Errorf("user monitor received %d events; want 1", userEvents)
+	}
+	if got := len(recorder.Ended()); got != 1 {
+		t.Errorf("recorded %d spans; want 1", got)
+	}
+}
@@ -0,0 +1,54 @@
+package tracekit
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MuxMiddleware returns a gorilla/mux middleware with OpenTelemetry
+// instrumentation. Spans are named "<METHOD> <route template>" (e.g.
+// "GET /users/{id}") so each endpoint shows up separately, and carry the
+// template as http.route.
+//
+//	r := mux.NewRouter()
+//	r.Use(sdk.MuxMiddleware())
+func (s *SDK) MuxMiddleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		// mux runs middleware after matching, so the route is known here
+		withRoute := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if template, ok := muxRouteTemplate(r); ok {
+				trace.SpanFromContext(r.Context()).SetAttributes(semconv.HTTPRoute(template))
+			}
+			next.ServeHTTP(w, r)
+		})
+
+		otelHandler := otelhttp.NewHandler(s.wrapIdentityHandler(withRoute), "http.request",
+			otelhttp.WithTracerProvider(s.tracerProvider),
+			otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
+				if template, ok := muxRouteTemplate(r); ok {
+					return r.Method + " " + template
+				}
+				return operation
+			}),
+		)
+
+		return s.withIgnorePaths(next, &clientIPMiddleware{handler: s.withRequestSampling(otelHandler)})
+	}
+}
+
+// muxRouteTemplate returns the path template of the route mux matched for r
+func muxRouteTemplate(r *http.Request) (string, bool) {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return "", false
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return "", false
+	}
+	return template, true
+}
@@ -0,0 +1,43 @@
+package tracekit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestMuxMiddlewareRouteName verifies spans are named after the matched route template
+func TestMuxMiddlewareRouteName(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{tracer: tp.Tracer("test"), tracerProvider: tp}
+
+	router := mux.NewRouter()
+	router.Use(sdk.MuxMiddleware())
+	router.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans; want 1", len(spans))
+	}
+	if got, want := spans[0].Name(), "GET /users/{id}"; got != want {
+		t.Errorf("span name = %q; want %q", got, want)
+	}
+	var route string
+	for _, attr := range spans[0].Attributes() {
+		if attr.Key == "http.route" {
+			route = attr.Value.AsString()
+		}
+	}
+	if route != "/users/{id}" {
+		t.Errorf("http.route = %q; want %q", route, "/users/{id}")
+	}
+}
@@ -0,0 +1,162 @@
+package tracekit
+
+import (
+	"context"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WrapNATSConn wraps a NATS connection so published messages get a PRODUCER
+// span whose context is injected into the message headers, and subscription
+// handlers run inside a CONSUMER span continuing that trace.
+//
+//	tnc := sdk.WrapNATSConn(nc)
+//	tnc.Publish(ctx, "orders.created", payload)
+//	tnc.Subscribe("orders.*", func(ctx context.Context, msg *nats.Msg) { ... })
+func (s *SDK) WrapNATSConn(nc *nats.Conn) *TracedNATSConn {
+	return &TracedNATSConn{Conn: nc, tracer: s.tracer}
+}
+
+// TracedNATSConn is a nats.Conn with traced publish, request and subscribe.
+// Methods not overridden here are the untraced nats.Conn ones.
+type TracedNATSConn struct {
+	*nats.Conn
+	tracer trace.Tracer
+}
+
+// Publish publishes data to subj inside a PRODUCER span
+func (c *TracedNATSConn) Publish(ctx context.Context, subj string, data []byte) error {
+	return c.PublishMsg(ctx, &nats.Msg{Subject: subj, Data: data})
+}
+
+// PublishMsg publishes msg inside a PRODUCER span, adding trace headers.
+// The caller's header map is not modified.
+func (c *TracedNATSConn) PublishMsg(ctx context.Context, msg *nats.Msg) error {
+	ctx, span := c.startPublishSpan(ctx, msg)
+	defer span.End()
+
+	msg = withNATSTraceHeaders(ctx, msg)
+	err := c.Conn.PublishMsg(msg)
+	setNATSSpanStatus(span, err)
+	return err
+}
+
+// Request sends data to subj and waits for a reply, tracing the round trip
+// as a PRODUCER span so the responder's CONSUMER span joins the same trace
+func (c *TracedNATSConn) Request(ctx context.Context, subj string, data []byte) (*nats.Msg, error) {
+	return c.RequestMsg(ctx, &nats.Msg{Subject: subj, Data: data})
+}
+
+// RequestMsg sends msg and waits for a reply until ctx is done
+func (c *TracedNATSConn) RequestMsg(ctx context.Context, msg *nats.Msg) (*nats.Msg, error) {
+	ctx, span := c.startPublishSpan(ctx, msg)
+	defer span.End()
+
+	msg = withNATSTraceHeaders(ctx, msg)
+	reply, err := c.Conn.RequestMsgWithContext(ctx, msg)
+	setNATSSpanStatus(span, err)
+	return reply, err
+}
+
+// NATSHandler processes a message with the context of its CONSUMER span
+type NATSHandler func(ctx context.Context, msg *nats.Msg)
+
+// Subscribe subscribes to subj, running handler inside a CONSUMER span per
+// delivered message
+func (c *TracedNATSConn) Subscribe(subj string, handler NATSHandler) (*nats.Subscription, error) {
+	return c.Conn.Subscribe(subj, c.traceHandler(handler))
+}
+
+// QueueSubscribe is Subscribe for a queue group
+func (c *TracedNATSConn) QueueSubscribe(subj, queue string, handler NATSHandler) (*nats.Subscription, error) {
+	return c.Conn.QueueSubscribe(subj, queue, c.traceHandler(handler))
+}
+
+// traceHandler adapts handler to nats.MsgHandler with a CONSUMER span
+func (c *TracedNATSConn) traceHandler(handler NATSHandler) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		ctx := context.Background()
+		if msg.Header != nil {
+			ctx = otel.GetTextMapPropagator().Extract(ctx, natsHeaderCarrier(msg.Header))
+		}
+
+		attrs := []attribute.KeyValue{
+			attribute.String("messaging.system", "nats"),
+			attribute.String("messaging.destination.name", msg.Subject),
+			attribute.String("messaging.operation", "process"),
+			attribute.Int("messaging.message.body.size", len(msg.Data)),
+		}
+		if msg.Sub != nil && msg.Sub.Queue != "" {
+			attrs = append(attrs, attribute.String("messaging.consumer.group.name", msg.Sub.Queue))
+		}
+
+		ctx, span := c.tracer.Start(ctx, msg.Subject+" process",
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(attrs...),
+		)
+		defer span.End()
+
+		handler(ctx, msg)
+	}
+}
+
+// startPublishSpan starts the PRODUCER span for an outgoing message
+func (c *TracedNATSConn) startPublishSpan(ctx context.Context, msg *nats.Msg) (context.Context, trace.Span) {
+	return c.tracer.Start(ctx, msg.Subject+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "nats"),
+			attribute.String("messaging.destination.name", msg.Subject),
+			attribute.String("messaging.operation", "publish"),
+			attribute.Int("messaging.message.body.size", len(msg.Data)),
+		),
+	)
+}
+
+// withNATSTraceHeaders returns a copy of msg with the span context from ctx
+// injected into a copy of its headers
+func withNATSTraceHeaders(ctx context.Context, msg *nats.Msg) *nats.Msg {
+	header := nats.Header{}
+	for k, v := range msg.Header {
+		header[k] = append([]string(nil), v...)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, natsHeaderCarrier(header))
+
+	traced := *msg
+	traced.Header = header
+	return &traced
+}
+
+// setNATSSpanStatus sets the span status from a publish or request error
+func setNATSSpanStatus(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetStatus(codes.Ok, "")
+	}
+}
+
+// natsHeaderCarrier adapts NATS message headers to propagation.TextMapCarrier.
+// Keys are used as-is; nats.Header does not canonicalize them.
+type natsHeaderCarrier nats.Header
+
+func (c natsHeaderCarrier) Get(key string) string {
+	return nats.Header(c).Get(key)
+}
+
+func (c natsHeaderCarrier) Set(key, value string) {
+	nats.Header(c).Set(key, value)
+}
+
+func (c natsHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,45 @@
+package tracekit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestNATSHandlerContinuesTrace verifies subscription handlers run in a
+// CONSUMER span continuing the trace injected by the publisher
+func TestNATSHandlerContinuesTrace(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+
+	tp := sdktrace.NewTracerProvider()
+	defer tp.Shutdown(context.Background())
+	tnc := &TracedNATSConn{tracer: tp.Tracer("test")}
+
+	original := &nats.Msg{Subject: "orders.created", Data: []byte("{}"), Header: nats.Header{"X-Id": []string{"1"}}}
+	producerCtx, producer := tnc.startPublishSpan(context.Background(), original)
+	msg := withNATSTraceHeaders(producerCtx, original)
+	producer.End()
+
+	if msg.Header.Get("traceparent") == "" {
+		t.Fatal("traceparent header not injected")
+	}
+	if original.Header.Get("traceparent") != "" {
+		t.Error("caller's headers were modified")
+	}
+
+	var consumer trace.SpanContext
+	tnc.traceHandler(func(ctx context.Context, msg *nats.Msg) {
+		consumer = trace.SpanContextFromContext(ctx)
+	})(msg)
+
+	if consumer.TraceID() != producer.SpanContext().TraceID() {
+		t.Error("consumer span does not continue the producer trace")
+	}
+}
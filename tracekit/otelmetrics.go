@@ -0,0 +1,264 @@
+package tracekit
+
+import (
+	"context"
+	"crypto/tls"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// otelMetrics backs the SDK metric instruments with the OpenTelemetry
+// metrics API when Config.UseOTelMetrics is set. Counters are cumulative and
+// histograms use native explicit-bucket aggregation.
+type otelMetrics struct {
+	provider *sdkmetric.MeterProvider
+	meter    metric.Meter
+	paused   atomic.Bool
+
+	histogramBuckets []float64 // default bucket bounds for new histograms
+	prefix           string    // prepended to every metric name
+
+	// Gauges keep their last value so Inc/Dec work on a synchronous gauge
+	gauges map[string]*otelGauge
+	mu     sync.Mutex
+}
+
+// initOTelMetrics creates a MeterProvider exporting OTLP metrics to the
+// TraceKit metrics endpoint with the same credentials as traces
+func (s *SDK) initOTelMetrics(metricsEndpoint string) error {
+	useSSL := !strings.HasPrefix(metricsEndpoint, "http://")
+	metricsEndpoint = strings.TrimPrefix(strings.TrimPrefix(metricsEndpoint, "https://"), "http://")
+
+	// Split host and path
+	parts := strings.SplitN(metricsEndpoint, "/", 2)
+	urlPath := "/v1/metrics"
+	if len(parts) > 1 {
+		urlPath = "/" + parts[1]
+	}
+
+	opts := []otlpmetrichttp.Option{
+		otlpmetrichttp.WithEndpoint(parts[0]),
+		otlpmetrichttp.WithURLPath(urlPath),
+		otlpmetrichttp.WithTimeout(30 * time.Second),
+		otlpmetrichttp.WithHeaders(map[string]string{
+			"X-API-Key": s.config.APIKey,
+		}),
+	}
+	if useSSL {
+		opts = append(opts, otlpmetrichttp.WithTLSClientConfig(&tls.Config{}))
+	} else {
+		opts = append(opts, otlpmetrichttp.WithInsecure())
+	}
+
+	exporter, err := otlpmetrichttp.New(context.Background(), opts...)
+	if err != nil {
+		return err
+	}
+
+	var readerOpts []sdkmetric.PeriodicReaderOption
+	if s.config.MetricsFlushInterval > 0 {
+		readerOpts = append(readerOpts, sdkmetric.WithInterval(s.config.MetricsFlushInterval))
+	}
+	providerOpts := []sdkmetric.Option{
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, readerOpts...)),
+	}
+	if s.resource != nil {
+		providerOpts = append(providerOpts, sdkmetric.WithResource(s.resource))
+	}
+
+	s.otelMetrics = newOTelMetrics(sdkmetric.NewMeterProvider(providerOpts...), s.config)
+	s.logger.infof("TraceKit: OpenTelemetry metrics enabled (%s)", urlPath)
+	return nil
+}
+
+func newOTelMetrics(provider *sdkmetric.MeterProvider, config *Config) *otelMetrics {
+	om := &otelMetrics{
+		provider:         provider,
+		meter:            provider.Meter("tracekit"),
+		histogramBuckets: config.HistogramBuckets,
+		prefix:           config.MetricsPrefix,
+		gauges:           make(map[string]*otelGauge),
+	}
+	if len(om.histogramBuckets) == 0 {
+		om.histogramBuckets = DefaultHistogramBuckets
+	}
+	return om
+}
+
+func (om *otelMetrics) counter(name string, tags map[string]string) Counter {
+	c, err := om.meter.Float64Counter(om.prefix + name)
+	if err != nil {
+		return &noopCounter{}
+	}
+	return &otelCounter{counter: c, attrs: tagsOption(tags), paused: &om.paused}
+}
+
+func (om *otelMetrics) upDownCounter(name string, tags map[string]string) UpDownCounter {
+	c, err := om.meter.Float64UpDownCounter(om.prefix + name)
+	if err != nil {
+		return &noopUpDownCounter{}
+	}
+	return &otelUpDownCounter{counter: c, attrs: tagsOption(tags), paused: &om.paused}
+}
+
+func (om *otelMetrics) gauge(name string, tags map[string]string) Gauge {
+	name = om.prefix + name
+	key := metricKey(name, tags)
+
+	om.mu.Lock()
+	defer om.mu.Unlock()
+	if g, exists := om.gauges[key]; exists {
+		return g
+	}
+
+	instrument, err := om.meter.Float64Gauge(name)
+	if err != nil {
+		return &noopGauge{}
+	}
+	g := &otelGauge{gauge: instrument, attrs: tagsOption(tags), paused: &om.paused}
+	om.gauges[key] = g
+	return g
+}
+
+func (om *otelMetrics) histogram(name string, tags map[string]string, opts ...HistogramOption) Histogram {
+	options := histogramOptions{buckets: om.histogramBuckets}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	h, err := om.meter.Float64Histogram(om.prefix+name, metric.WithExplicitBucketBoundaries(options.buckets...))
+	if err != nil {
+		return &noopHistogram{}
+	}
+	return &otelHistogram{histogram: h, attrs: tagsOption(tags), paused: &om.paused}
+}
+
+func (om *otelMetrics) setEnabled(enabled bool) {
+	om.paused.Store(!enabled)
+}
+
+func (om *otelMetrics) flush(ctx context.Context) error {
+	return om.provider.ForceFlush(ctx)
+}
+
+func (om *otelMetrics) shutdown(ctx context.Context) error {
+	return om.provider.Shutdown(ctx)
+}
+
+// tagsOption converts metric tags into a measurement attribute option
+func tagsOption(tags map[string]string) metric.MeasurementOption {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]attribute.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, attribute.String(k, tags[k]))
+	}
+	return metric.WithAttributeSet(attribute.NewSet(attrs...))
+}
+
+type otelCounter struct {
+	counter metric.Float64Counter
+	attrs   metric.MeasurementOption
+	paused  *atomic.Bool
+}
+
+func (c *otelCounter) Inc() {
+	c.Add(1)
+}
+
+func (c *otelCounter) Add(value float64) {
+	if value < 0 || c.paused.Load() {
+		return // Counters must be monotonic
+	}
+	c.counter.Add(context.Background(), value, c.attrs)
+}
+
+type otelUpDownCounter struct {
+	counter metric.Float64UpDownCounter
+	attrs   metric.MeasurementOption
+	paused  *atomic.Bool
+}
+
+func (c *otelUpDownCounter) Inc() {
+	c.Add(1)
+}
+
+func (c *otelUpDownCounter) Dec() {
+	c.Add(-1)
+}
+
+func (c *otelUpDownCounter) Add(delta float64) {
+	if c.paused.Load() {
+		return
+	}
+	c.counter.Add(context.Background(), delta, c.attrs)
+}
+
+type otelGauge struct {
+	gauge  metric.Float64Gauge
+	attrs  metric.MeasurementOption
+	paused *atomic.Bool
+	value  float64
+	mu     sync.Mutex
+}
+
+func (g *otelGauge) Set(value float64) {
+	g.mu.Lock()
+	g.value = value
+	g.mu.Unlock()
+	g.record(value)
+}
+
+func (g *otelGauge) Inc() {
+	g.mu.Lock()
+	g.value++
+	val := g.value
+	g.mu.Unlock()
+	g.record(val)
+}
+
+func (g *otelGauge) Dec() {
+	g.mu.Lock()
+	g.value--
+	val := g.value
+	g.mu.Unlock()
+	g.record(val)
+}
+
+func (g *otelGauge) record(value float64) {
+	if g.paused.Load() {
+		return
+	}
+	g.gauge.Record(context.Background(), value, g.attrs)
+}
+
+type otelHistogram struct {
+	histogram metric.Float64Histogram
+	attrs     metric.MeasurementOption
+	paused    *atomic.Bool
+}
+
+func (h *otelHistogram) Record(value float64) {
+	h.RecordCtx(context.Background(), value)
+}
+
+// RecordCtx passes ctx to the OTel SDK, whose default trace-based exemplar
+// filter attaches sampled traces as exemplars
+func (h *otelHistogram) RecordCtx(ctx context.Context, value float64) {
+	if h.paused.Load() {
+		return
+	}
+	h.histogram.Record(ctx, value, h.attrs)
+}
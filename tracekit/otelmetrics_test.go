@@ -0,0 +1,61 @@
+package tracekit
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// TestOTelMetrics verifies SDK metrics are recorded through the OpenTelemetry
+// metrics SDK when Config.UseOTelMetrics is set
+func TestOTelMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer provider.Shutdown(context.Background())
+
+	sdk := &SDK{otelMetrics: newOTelMetrics(provider, &Config{
+		MetricsPrefix:    "api.",
+		HistogramBuckets: []float64{10, 100},
+	})}
+
+	tags := map[string]string{"route": "/orders"}
+	sdk.Counter("requests", tags).Inc()
+	sdk.Counter("requests", tags).Add(2)
+	sdk.Gauge("in_flight", tags).Inc()
+	sdk.Gauge("in_flight", tags).Inc()
+	sdk.Histogram("latency_ms", tags).Record(42)
+
+	sdk.SetEnabled(false)
+	sdk.otelMetrics.counter("requests", tags).Inc() // Dropped while disabled
+	sdk.SetEnabled(true)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	got := map[string]metricdata.Aggregation{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		got[m.Name] = m.Data
+	}
+
+	sum := got["api.requests"].(metricdata.Sum[float64])
+	if !sum.IsMonotonic || sum.Temporality != metricdata.CumulativeTemporality || sum.DataPoints[0].Value != 3 {
+		t.Errorf("api.requests = %+v; want cumulative monotonic 3", sum)
+	}
+	if v, _ := sum.DataPoints[0].Attributes.Value(attribute.Key("route")); v.AsString() != "/orders" {
+		t.Errorf("route attribute = %q; want /orders", v.AsString())
+	}
+
+	if g := got["api.in_flight"].(metricdata.Gauge[float64]); g.DataPoints[0].Value != 2 {
+		t.Errorf("api.in_flight = %v; want 2", g.DataPoints[0].Value)
+	}
+
+	h := got["api.latency_ms"].(metricdata.Histogram[float64])
+	if dp := h.DataPoints[0]; dp.Count != 1 || len(dp.Bounds) != 2 || dp.BucketCounts[1] != 1 {
+		t.Errorf("api.latency_ms = %+v; want one value in the (10,100] bucket", dp)
+	}
+}
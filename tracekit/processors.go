@@ -0,0 +1,347 @@
+package tracekit
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// minDurationSpanProcessor drops spans shorter than a minimum duration
+// before they reach the wrapped processor (and therefore the exporter).
+// Spans with an error status are kept when keepErrors is true.
+type minDurationSpanProcessor struct {
+	next        sdktrace.SpanProcessor
+	minDuration time.Duration
+	keepErrors  bool
+}
+
+// newMinDurationSpanProcessor wraps next with a minimum-duration filter
+func newMinDurationSpanProcessor(next sdktrace.SpanProcessor, minDuration time.Duration, keepErrors bool) *minDurationSpanProcessor {
+	return &minDurationSpanProcessor{
+		next:        next,
+		minDuration: minDuration,
+		keepErrors:  keepErrors,
+	}
+}
+
+// OnStart forwards to the wrapped processor
+func (p *minDurationSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+// OnEnd forwards the span only if it is long enough (or is an error span)
+func (p *minDurationSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	if s.EndTime().Sub(s.StartTime()) < p.minDuration {
+		if !p.keepErrors || s.Status().Code != codes.Error {
+			return
+		}
+	}
+	p.next.OnEnd(s)
+}
+
+// Shutdown shuts down the wrapped processor
+func (p *minDurationSpanProcessor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+// ForceFlush flushes the wrapped processor
+func (p *minDurationSpanProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
+
+// Bounds for tailSamplingSpanProcessor: traces whose local root never ends
+// are dropped after maxTailTraceAge, and at most maxTailTraces traces and
+// maxTailTraceSpans spans per trace are buffered
+const (
+	maxTailTraceAge   = time.Minute
+	maxTailTraces     = 10000
+	maxTailTraceSpans = 1000
+)
+
+// tailSamplingSpanProcessor buffers each trace's spans until its local root
+// span ends, then forwards them to next only if the root took at least
+// threshold, the trace contains an error span (keepErrors) or the fallback
+// sampler selects the root span. Spans ending after the decision follow it.
+type tailSamplingSpanProcessor struct {
+	next       sdktrace.SpanProcessor
+	threshold  time.Duration
+	keepErrors bool
+	fallback   sdktrace.Sampler
+
+	mu      sync.Mutex
+	traces  map[trace.TraceID]*tailTrace
+	decided map[trace.TraceID]tailDecision
+}
+
+// tailTrace is a trace whose local root hasn't ended yet
+type tailTrace struct {
+	spans    []sdktrace.ReadOnlySpan
+	hasError bool
+	started  time.Time
+}
+
+// tailDecision remembers whether a finished trace was kept
+type tailDecision struct {
+	keep bool
+	at   time.Time
+}
+
+func newTailSamplingSpanProcessor(next sdktrace.SpanProcessor, threshold time.Duration, keepErrors bool, fallback sdktrace.Sampler) *tailSamplingSpanProcessor {
+	return &tailSamplingSpanProcessor{
+		next:       next,
+		threshold:  threshold,
+		keepErrors: keepErrors,
+		fallback:   fallback,
+		traces:     make(map[trace.TraceID]*tailTrace),
+		decided:    make(map[trace.TraceID]tailDecision),
+	}
+}
+
+// OnStart forwards to the wrapped processor
+func (p *tailSamplingSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+// OnEnd buffers the span, or decides the trace when its local root ends
+func (p *tailSamplingSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	traceID := s.SpanContext().TraceID()
+	now := time.Now()
+	isRoot := !s.Parent().IsValid() || s.Parent().IsRemote()
+	isError := s.Status().Code == codes.Error
+
+	p.mu.Lock()
+	if d, ok := p.decided[traceID]; ok {
+		p.mu.Unlock()
+		if d.keep {
+			p.next.OnEnd(s)
+		}
+		return
+	}
+
+	t := p.traces[traceID]
+	if t == nil {
+		if len(p.traces) >= maxTailTraces {
+			p.evictLocked(now)
+		}
+		if len(p.traces) >= maxTailTraces && !isRoot {
+			p.mu.Unlock()
+			return // Buffer full
+		}
+		t = &tailTrace{started: now}
+		p.traces[traceID] = t
+	}
+	t.hasError = t.hasError || isError
+	if len(t.spans) < maxTailTraceSpans {
+		t.spans = append(t.spans, s)
+	}
+
+	if !isRoot {
+		p.mu.Unlock()
+		return
+	}
+
+	keep := (p.threshold > 0 && s.EndTime().Sub(s.StartTime()) >= p.threshold) ||
+		(p.keepErrors && t.hasError) ||
+		p.fallback.ShouldSample(sdktrace.SamplingParameters{
+			TraceID:    traceID,
+			Name:       s.Name(),
+			Kind:       s.SpanKind(),
+			Attributes: s.Attributes(),
+		}).Decision == sdktrace.RecordAndSample
+	delete(p.traces, traceID)
+	if len(p.decided) >= maxTailTraces {
+		p.evictLocked(now)
+		if len(p.decided) >= maxTailTraces {
+			p.decided = make(map[trace.TraceID]tailDecision) // Late spans of these traces are dropped
+		}
+	}
+	p.decided[traceID] = tailDecision{keep: keep, at: now}
+	p.mu.Unlock()
+
+	if keep {
+		for _, span := range t.spans {
+			p.next.OnEnd(span)
+		}
+	}
+}
+
+// evictLocked drops traces and decisions older than maxTailTraceAge
+func (p *tailSamplingSpanProcessor) evictLocked(now time.Time) {
+	for id, t := range p.traces {
+		if now.Sub(t.started) > maxTailTraceAge {
+			delete(p.traces, id)
+		}
+	}
+	for id, d := range p.decided {
+		if now.Sub(d.at) > maxTailTraceAge {
+			delete(p.decided, id)
+		}
+	}
+}
+
+// Shutdown shuts down the wrapped processor. Undecided traces are dropped.
+func (p *tailSamplingSpanProcessor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+// ForceFlush flushes the wrapped processor
+func (p *tailSamplingSpanProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
+
+// transactionSpanProcessor stamps the business transaction ID carried in
+// baggage onto every span as it starts
+type transactionSpanProcessor struct{}
+
+// OnStart copies transaction.id from the parent context's baggage
+func (p *transactionSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	if id := transactionIDFromContext(parent); id != "" {
+		s.SetAttributes(attribute.String(transactionIDKey, id))
+	}
+}
+
+// OnEnd is a no-op
+func (p *transactionSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {}
+
+// Shutdown is a no-op
+func (p *transactionSpanProcessor) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// ForceFlush is a no-op
+func (p *transactionSpanProcessor) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+// serviceNameSpanProcessor stamps the service name override set with
+// WithServiceName onto every span as it starts
+type serviceNameSpanProcessor struct{}
+
+// OnStart copies the override from the parent context
+func (p *serviceNameSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	if name := serviceNameFromContext(parent); name != "" {
+		s.SetAttributes(attribute.String(serviceNameAttribute, name))
+	}
+}
+
+// OnEnd is a no-op
+func (p *serviceNameSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {}
+
+// Shutdown is a no-op
+func (p *serviceNameSpanProcessor) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// ForceFlush is a no-op
+func (p *serviceNameSpanProcessor) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+// baggageSpanProcessor copies selected baggage entries from the parent
+// context onto every span as attributes
+type baggageSpanProcessor struct {
+	keys []string
+}
+
+// OnStart sets an attribute for each configured key present in baggage
+func (p *baggageSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	bag := baggage.FromContext(parent)
+	for _, key := range p.keys {
+		if member := bag.Member(key); member.Key() != "" {
+			s.SetAttributes(attribute.String(key, member.Value()))
+		}
+	}
+}
+
+// OnEnd is a no-op
+func (p *baggageSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {}
+
+// Shutdown is a no-op
+func (p *baggageSpanProcessor) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// ForceFlush is a no-op
+func (p *baggageSpanProcessor) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+// spanMetricsProcessor derives RED metrics from every ended span: the
+// span.calls counter and the span.duration histogram (milliseconds), tagged
+// with span.name, span.kind and status.code
+type spanMetricsProcessor struct {
+	counter   func(name string, tags map[string]string) Counter
+	histogram func(name string, tags map[string]string, opts ...HistogramOption) Histogram
+}
+
+// OnStart is a no-op
+func (p *spanMetricsProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {}
+
+// OnEnd records the span's call and duration
+func (p *spanMetricsProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	tags := map[string]string{
+		"span.name":   s.Name(),
+		"span.kind":   s.SpanKind().String(),
+		"status.code": s.Status().Code.String(),
+	}
+	p.counter("span.calls", tags).Inc()
+	// The span's own trace becomes the exemplar for its duration bucket
+	ctx := trace.ContextWithSpanContext(context.Background(), s.SpanContext())
+	p.histogram("span.duration", tags).RecordCtx(ctx, float64(s.EndTime().Sub(s.StartTime()).Microseconds())/1000)
+}
+
+// Shutdown is a no-op
+func (p *spanMetricsProcessor) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// ForceFlush is a no-op
+func (p *spanMetricsProcessor) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+// goroutineIDSpanProcessor records the starting goroutine's ID as thread.id
+type goroutineIDSpanProcessor struct{}
+
+// OnStart stamps thread.id on the span
+func (p *goroutineIDSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	if id, ok := goroutineID(); ok {
+		s.SetAttributes(attribute.Int64("thread.id", id))
+	}
+}
+
+// OnEnd is a no-op
+func (p *goroutineIDSpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {}
+
+// Shutdown is a no-op
+func (p *goroutineIDSpanProcessor) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// ForceFlush is a no-op
+func (p *goroutineIDSpanProcessor) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+// goroutineID parses the current goroutine's ID from the first line of its
+// stack trace ("goroutine 123 [running]:"). The runtime doesn't expose it
+// directly, so this is for diagnostics only.
+func goroutineID() (int64, bool) {
+	var buf [64]byte
+	line := buf[:runtime.Stack(buf[:], false)]
+	line = bytes.TrimPrefix(line, []byte("goroutine "))
+	if i := bytes.IndexByte(line, ' '); i > 0 {
+		line = line[:i]
+	}
+	id, err := strconv.ParseInt(string(line), 10, 64)
+	return id, err == nil
+}
@@ -0,0 +1,309 @@
+package tracekit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestMinDurationSpanProcessor verifies short spans are dropped unless they carry an error
+func TestMinDurationSpanProcessor(t *testing.T) {
+	tests := []struct {
+		name       string
+		duration   time.Duration
+		isError    bool
+		keepErrors bool
+		wantKept   bool
+	}{
+		{name: "long span kept", duration: 20 * time.Millisecond, wantKept: true},
+		{name: "short span dropped", duration: time.Microsecond, wantKept: false},
+		{name: "short error span kept", duration: time.Microsecond, isError: true, keepErrors: true, wantKept: true},
+		{name: "short error span dropped", duration: time.Microsecond, isError: true, keepErrors: false, wantKept: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(
+				newMinDurationSpanProcessor(recorder, 10*time.Millisecond, tt.keepErrors),
+			))
+			defer tp.Shutdown(context.Background())
+
+			start := time.Now()
+			_, span := tp.Tracer("test").Start(context.Background(), "op", trace.WithTimestamp(start))
+			if tt.isError {
+				span.SetStatus(codes.Error, "boom")
+			}
+			span.End(trace.WithTimestamp(start.Add(tt.duration)))
+
+			if kept := len(recorder.Ended()) == 1; kept != tt.wantKept {
+				t.Errorf("span kept = %v; want %v", kept, tt.wantKept)
+			}
+		})
+	}
+}
+
+// TestTransactionSpanProcessor verifies transaction.id from baggage is stamped on child spans
+func TestTransactionSpanProcessor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(&transactionSpanProcessor{}),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{tracer: tp.Tracer("test")}
+
+	ctx := sdk.WithTransactionID(context.Background(), "checkout-42")
+	if got := sdk.TransactionID(ctx); got != "checkout-42" {
+		t.Fatalf("TransactionID = %q; want checkout-42", got)
+	}
+
+	_, span := sdk.StartSpan(ctx, "step")
+	span.End()
+
+	ended := recorder.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(ended))
+	}
+	found := false
+	for _, attr := range ended[0].Attributes() {
+		if string(attr.Key) == "transaction.id" && attr.Value.AsString() == "checkout-42" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected transaction.id attribute on span")
+	}
+}
+
+// TestGoroutineIDSpanProcessor verifies spans started on different goroutines
+// get distinct thread.id attributes
+func TestGoroutineIDSpanProcessor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(&goroutineIDSpanProcessor{}),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	defer tp.Shutdown(context.Background())
+	tracer := tp.Tracer("test")
+
+	_, span := tracer.Start(context.Background(), "main")
+	span.End()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, span := tracer.Start(context.Background(), "worker")
+		span.End()
+	}()
+	<-done
+
+	ids := map[int64]bool{}
+	for _, s := range recorder.Ended() {
+		for _, attr := range s.Attributes() {
+			if attr.Key == "thread.id" {
+				ids[attr.Value.AsInt64()] = true
+			}
+		}
+	}
+	if len(ids) != 2 {
+		t.Errorf("got thread.id values %v; want two distinct IDs", ids)
+	}
+}
+
+// TestBaggageSpanProcessor verifies configured baggage keys are copied onto
+// spans and other keys are not
+func TestBaggageSpanProcessor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(&baggageSpanProcessor{keys: []string{"tenant.id", "request.priority"}}),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	defer tp.Shutdown(context.Background())
+
+	sdk := &SDK{}
+	ctx := sdk.SetBaggage(context.Background(), "tenant.id", "acme")
+	ctx = sdk.SetBaggage(ctx, "session.id", "s-1")
+
+	_, span := tp.Tracer("test").Start(ctx, "op")
+	span.End()
+
+	attrs := map[string]string{}
+	for _, attr := range recorder.Ended()[0].Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs["tenant.id"] != "acme" {
+		t.Errorf("tenant.id = %q; want %q", attrs["tenant.id"], "acme")
+	}
+	if _, ok := attrs["request.priority"]; ok {
+		t.Errorf("request.priority recorded without baggage entry")
+	}
+	if _, ok := attrs["session.id"]; ok {
+		t.Errorf("session.id recorded; want only configured keys")
+	}
+}
+
+// metricRecorder records counter and histogram calls by metric key
+type metricRecorder struct {
+	values map[string][]float64
+}
+
+func (m *metricRecorder) counter(name string, tags map[string]string) Counter {
+	return &recordedMetric{m: m, key: metricKey(name, tags)}
+}
+
+func (m *metricRecorder) histogram(name string, tags map[string]string, opts ...HistogramOption) Histogram {
+	return &recordedMetric{m: m, key: metricKey(name, tags)}
+}
+
+type recordedMetric struct {
+	m   *metricRecorder
+	key string
+}
+
+func (r *recordedMetric) Inc()                                         { r.Add(1) }
+func (r *recordedMetric) Add(value float64)                            { r.m.values[r.key] = append(r.m.values[r.key], value) }
+func (r *recordedMetric) Record(value float64)                         { r.Add(value) }
+func (r *recordedMetric) RecordCtx(ctx context.Context, value float64) { r.Add(value) }
+
+// TestSpanMetricsProcessor verifies each ended span records a call and its
+// duration tagged by name, kind and status
+func TestSpanMetricsProcessor(t *testing.T) {
+	metrics := &metricRecorder{values: map[string][]float64{}}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(&spanMetricsProcessor{
+		counter:   metrics.counter,
+		histogram: metrics.histogram,
+	}))
+	defer tp.Shutdown(context.Background())
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		_, span := tp.Tracer("test").Start(context.Background(), "redis.get",
+			trace.WithSpanKind(trace.SpanKindClient), trace.WithTimestamp(start))
+		span.End(trace.WithTimestamp(start.Add(25 * time.Millisecond)))
+	}
+	_, failed := tp.Tracer("test").Start(context.Background(), "redis.get", trace.WithSpanKind(trace.SpanKindClient))
+	failed.SetStatus(codes.Error, "timeout")
+	failed.End()
+
+	tags := map[string]string{"span.name": "redis.get", "span.kind": "client", "status.code": "Unset"}
+	if got := metrics.values[metricKey("span.calls", tags)]; len(got) != 2 {
+		t.Errorf("span.calls{status.code=Unset} = %v; want 2 calls", got)
+	}
+	if got := metrics.values[metricKey("span.duration", tags)]; len(got) != 2 || got[0] != 25 {
+		t.Errorf("span.duration = %v; want [25 25]", got)
+	}
+
+	tags["status.code"] = "Error"
+	if got := metrics.values[metricKey("span.calls", tags)]; len(got) != 1 {
+		t.Errorf("span.calls{status.code=Error} = %v; want 1 call", got)
+	}
+}
+
+// TestServiceNameSpanProcessor verifies spans under WithServiceName carry the
+// override and other spans don't
+func TestServiceNameSpanProcessor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(&serviceNameSpanProcessor{}),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	defer tp.Shutdown(context.Background())
+	tracer := tp.Tracer("test")
+
+	ctx := (&SDK{}).WithServiceName(context.Background(), "billing-plugin")
+	_, plugin := tracer.Start(ctx, "plugin.handle")
+	plugin.End()
+	_, host := tracer.Start(context.Background(), "host.handle")
+	host.End()
+
+	for _, span := range recorder.Ended() {
+		var got string
+		for _, attr := range span.Attributes() {
+			if attr.Key == serviceNameAttribute {
+				got = attr.Value.AsString()
+			}
+		}
+		want := ""
+		if span.Name() == "plugin.handle" {
+			want = "billing-plugin"
+		}
+		if got != want {
+			t.Errorf("%s service.name = %q; want %q", span.Name(), got, want)
+		}
+	}
+}
+
+// TestTailSamplingSpanProcessor verifies a trace's spans are exported only
+// when its root is slow or the trace contains an error
+func TestTailSamplingSpanProcessor(t *testing.T) {
+	tests := []struct {
+		name       string
+		duration   time.Duration
+		childError bool
+		wantKept   bool
+	}{
+		{name: "fast trace dropped", duration: time.Millisecond, wantKept: false},
+		{name: "slow trace kept", duration: 200 * time.Millisecond, wantKept: true},
+		{name: "fast trace with child error kept", duration: time.Millisecond, childError: true, wantKept: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(
+				newTailSamplingSpanProcessor(recorder, 100*time.Millisecond, true, sdktrace.NeverSample()),
+			))
+			defer tp.Shutdown(context.Background())
+
+			start := time.Now()
+			ctx, root := tp.Tracer("test").Start(context.Background(), "request", trace.WithTimestamp(start))
+			_, child := tp.Tracer("test").Start(ctx, "query", trace.WithTimestamp(start))
+			if tt.childError {
+				child.SetStatus(codes.Error, "boom")
+			}
+			child.End(trace.WithTimestamp(start.Add(time.Millisecond)))
+			if len(recorder.Ended()) != 0 {
+				t.Fatal("child exported before the root span ended")
+			}
+			root.End(trace.WithTimestamp(start.Add(tt.duration)))
+
+			want := 0
+			if tt.wantKept {
+				want = 2
+			}
+			if got := len(recorder.Ended()); got != want {
+				t.Errorf("exported %d spans; want %d", got, want)
+			}
+		})
+	}
+}
+
+// TestTailSamplingWithRules verifies traces the tail sampler doesn't keep
+// still follow SamplingRules rather than the plain sampling rate
+func TestTailSamplingWithRules(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	rules := newRuleSampler([]SamplingRule{{Name: "POST /checkout*", Rate: 1}}, sdktrace.NeverSample())
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(
+		newTailSamplingSpanProcessor(recorder, 100*time.Millisecond, true, rules),
+	))
+	defer tp.Shutdown(context.Background())
+
+	for _, name := range []string{"POST /checkout/confirm", "GET /products"} {
+		_, span := tp.Tracer("test").Start(context.Background(), name)
+		span.End()
+	}
+
+	ended := recorder.Ended()
+	if len(ended) != 1 || ended[0].Name() != "POST /checkout/confirm" {
+		var names []string
+		for _, s := range ended {
+			names = append(names, s.Name())
+		}
+		t.Errorf("exported %v; want only the checkout trace", names)
+	}
+}
@@ -0,0 +1,199 @@
+package tracekit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PrometheusHandler returns an http.Handler serving the SDK's counters,
+// up/down counters, gauges and histograms in the Prometheus text exposition
+// format, e.g. mounted at /metrics on an admin server. Counters report
+// cumulative totals and gauges their current value; tags become labels and
+// characters Prometheus doesn't allow in names become underscores.
+// Metrics recorded through Config.UseOTelMetrics are not included.
+func (s *SDK) PrometheusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		if s.metricsRegistry != nil {
+			s.metricsRegistry.writePrometheus(&buf)
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(buf.Bytes())
+	})
+}
+
+// promFamily holds the series of one Prometheus metric name
+type promFamily struct {
+	typ    string
+	series []promSeries
+}
+
+// promSeries is the sample lines of one tag set, ordered by key on output
+type promSeries struct {
+	key   string
+	lines []string
+}
+
+// writePrometheus writes all registered metrics in the Prometheus text format
+func (mr *metricsRegistry) writePrometheus(w io.Writer) {
+	families := make(map[string]*promFamily)
+	add := func(name, typ string, tags map[string]string, lines ...string) {
+		f, ok := families[name]
+		if !ok {
+			f = &promFamily{typ: typ}
+			families[name] = f
+		}
+		f.series = append(f.series, promSeries{key: metricKey(name, tags), lines: lines})
+	}
+
+	mr.mu.RLock()
+	for _, c := range mr.counters {
+		c.mu.Lock()
+		total := c.total
+		c.mu.Unlock()
+		name := promName(c.name)
+		add(name, "counter", c.tags, promSample(name, c.tags, total))
+	}
+	for _, c := range mr.upDownCounters {
+		c.mu.Lock()
+		value := c.value
+		c.mu.Unlock()
+		name := promName(c.name)
+		add(name, "gauge", c.tags, promSample(name, c.tags, value))
+	}
+	for _, g := range mr.gauges {
+		g.mu.Lock()
+		value := g.value
+		g.mu.Unlock()
+		name := promName(g.name)
+		add(name, "gauge", g.tags, promSample(name, g.tags, value))
+	}
+	for _, h := range mr.histograms {
+		name := promName(h.name)
+		add(name, "histogram", h.tags, h.promLines(name)...)
+		if len(mr.histogramQuantiles) > 0 {
+			add(name+"_quantile", "gauge", h.tags, h.promQuantileLines(name+"_quantile", mr.histogramQuantiles)...)
+		}
+	}
+	mr.mu.RUnlock()
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := families[name]
+		sort.Slice(f.series, func(i, j int) bool { return f.series[i].key < f.series[j].key })
+		fmt.Fprintf(w, "# TYPE %s %s\n", name, f.typ)
+		for _, series := range f.series {
+			for _, line := range series.lines {
+				fmt.Fprintln(w, line)
+			}
+		}
+	}
+}
+
+// promLines renders the histogram's cumulative buckets, sum and count
+func (h *histogram) promLines(name string) []string {
+	h.mu.Lock()
+	counts := append([]uint64(nil), h.totalBucketCounts...)
+	count, sum := h.totalCount, h.totalSum
+	h.mu.Unlock()
+
+	lines := make([]string, 0, len(counts)+2)
+	var cumulative uint64
+	for i, c := range counts {
+		cumulative += c
+		le := "+Inf"
+		if i < len(h.bounds) {
+			le = promFloat(h.bounds[i])
+		}
+		tags := copyTags(h.tags)
+		if tags == nil {
+			tags = make(map[string]string, 1)
+		}
+		tags["le"] = le
+		lines = append(lines, promSample(name+"_bucket", tags, float64(cumulative)))
+	}
+	lines = append(lines,
+		promSample(name+"_sum", h.tags, sum),
+		promSample(name+"_count", h.tags, float64(count)),
+	)
+	return lines
+}
+
+// promQuantileLines renders quantiles estimated from the cumulative buckets
+func (h *histogram) promQuantileLines(name string, quantiles []float64) []string {
+	h.mu.Lock()
+	counts := append([]uint64(nil), h.totalBucketCounts...)
+	min, max := h.totalMin, h.totalMax
+	h.mu.Unlock()
+
+	lines := make([]string, 0, len(quantiles))
+	for _, q := range quantiles {
+		tags := copyTags(h.tags)
+		if tags == nil {
+			tags = make(map[string]string, 1)
+		}
+		tags["quantile"] = promFloat(q)
+		lines = append(lines, promSample(name, tags, estimateQuantile(q, h.bounds, counts, min, max)))
+	}
+	return lines
+}
+
+// promSample formats one sample line: name{label="value",...} value
+func promSample(name string, tags map[string]string, value float64) string {
+	if len(tags) == 0 {
+		return name + " " + promFloat(value)
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(name)
+	sb.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(promName(k))
+		sb.WriteString(`="`)
+		sb.WriteString(promLabelEscaper.Replace(tags[k]))
+		sb.WriteByte('"')
+	}
+	sb.WriteString("} ")
+	sb.WriteString(promFloat(value))
+	return sb.String()
+}
+
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// promName replaces characters outside [a-zA-Z0-9_:] (e.g. the dots in
+// "http.requests") with underscores
+func promName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		valid := c == '_' || c == ':' ||
+			(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(c >= '0' && c <= '9' && i > 0)
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+func promFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
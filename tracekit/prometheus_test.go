@@ -0,0 +1,51 @@
+package tracekit
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestPrometheusHandler verifies registered metrics are exposed in the
+// Prometheus text format with cumulative totals and current values
+func TestPrometheusHandler(t *testing.T) {
+	mr := &metricsRegistry{
+		counters:         make(map[string]*counter),
+		upDownCounters:   make(map[string]*upDownCounter),
+		gauges:           make(map[string]*gauge),
+		histograms:       make(map[string]*histogram),
+		buffer:           newMetricsBuffer("http://localhost", "test-key", "test-service", 0, 0),
+		histogramBuckets: []float64{10, 100},
+	}
+	sdk := &SDK{metricsRegistry: mr}
+
+	tags := map[string]string{"route": `/orders "v2"`}
+	sdk.Counter("http.requests", tags).Add(2)
+	sdk.Counter("http.requests", tags).Inc()
+	sdk.Gauge("queue.depth", nil).Set(7)
+	sdk.UpDownCounter("http.in_flight", nil).Inc()
+	sdk.Histogram("latency_ms", nil).Record(42)
+	mr.collectHistograms() // Collecting deltas for export must not reset scrape values
+
+	rec := httptest.NewRecorder()
+	sdk.PrometheusHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	want := []string{
+		"# TYPE http_requests counter\nhttp_requests{route=\"/orders \\\"v2\\\"\"} 3\n",
+		"# TYPE http_in_flight gauge\nhttp_in_flight 1\n",
+		"# TYPE queue_depth gauge\nqueue_depth 7\n",
+		"# TYPE latency_ms histogram\n" +
+			"latency_ms_bucket{le=\"10\"} 0\n" +
+			"latency_ms_bucket{le=\"100\"} 1\n" +
+			"latency_ms_bucket{le=\"+Inf\"} 1\n" +
+			"latency_ms_sum 42\n" +
+			"latency_ms_count 1\n",
+	}
+	for _, w := range want {
+		if !strings.Contains(string(body), w) {
+			t.Errorf("body missing %q\ngot:\n%s", w, body)
+		}
+	}
+}
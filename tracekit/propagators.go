@@ -0,0 +1,40 @@
+package tracekit
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// defaultPropagators are used when Config.Propagators is empty
+var defaultPropagators = []string{"tracecontext", "baggage"}
+
+// newPropagator builds the composite propagator for the named formats.
+// Unknown names are logged and skipped.
+func newPropagator(names []string, logger *diagLogger) propagation.TextMapPropagator {
+	if len(names) == 0 {
+		names = defaultPropagators
+	}
+
+	var propagators []propagation.TextMapPropagator
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "tracecontext":
+			propagators = append(propagators, propagation.TraceContext{})
+		case "baggage":
+			propagators = append(propagators, propagation.Baggage{})
+		case "b3":
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3SingleHeader)))
+		case "b3multi":
+			propagators = append(propagators, b3.New(b3.WithInjectEncoding(b3.B3MultipleHeader)))
+		case "jaeger":
+			propagators = append(propagators, jaeger.Jaeger{})
+		default:
+			logger.warnf("TraceKit: unknown propagator %q ignored", name)
+		}
+	}
+
+	return propagation.NewCompositeTextMapPropagator(propagators...)
+}
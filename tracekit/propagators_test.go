@@ -0,0 +1,48 @@
+package tracekit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestNewPropagator verifies each configured format injects its headers and
+// incoming B3/Jaeger contexts are extracted
+func TestNewPropagator(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	tests := []struct {
+		names      []string
+		wantHeader string
+	}{
+		{names: nil, wantHeader: "Traceparent"},
+		{names: []string{"b3"}, wantHeader: "B3"},
+		{names: []string{"b3multi"}, wantHeader: "X-B3-Traceid"},
+		{names: []string{"jaeger"}, wantHeader: "Uber-Trace-Id"},
+		{names: []string{"bogus", "tracecontext"}, wantHeader: "Traceparent"},
+	}
+
+	for _, tt := range tests {
+		p := newPropagator(tt.names, nil)
+
+		header := http.Header{}
+		p.Inject(ctx, propagation.HeaderCarrier(header))
+		if header.Get(tt.wantHeader) == "" {
+			t.Errorf("%v: %s header not injected (got %v)", tt.names, tt.wantHeader, header)
+			continue
+		}
+
+		extracted := trace.SpanContextFromContext(p.Extract(context.Background(), propagation.HeaderCarrier(header)))
+		if extracted.TraceID() != sc.TraceID() {
+			t.Errorf("%v: extracted trace ID = %s; want %s", tt.names, extracted.TraceID(), sc.TraceID())
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package tracekit
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RedactionConfig controls how sensitive data is handled across all
+// instrumentation (request context, SQL statements, span attributes and
+// snapshots). Every instrumentation point consults the same redactor so
+// redaction rules can be audited in one place.
+type RedactionConfig struct {
+	// DenyKeys lists attribute, variable or header names whose values are
+	// always replaced with Marker (case-insensitive exact match).
+	DenyKeys []string
+
+	// ValuePatterns are applied to every captured string value; matches
+	// are replaced with the pattern's Marker.
+	ValuePatterns []PIIPattern
+
+	// RedactHeaders lists HTTP headers whose values are always replaced with
+	// Marker (case-insensitive). nil = Authorization, Cookie, X-Api-Key.
+	RedactHeaders []string
+
+	// AllowHeaders lists HTTP headers that are never redacted, even if they
+	// appear in DenyKeys.
+	AllowHeaders []string
+
+	// RedactSQLLiterals replaces string and numeric literals in captured SQL
+	// statements with "?" (default: false).
+	RedactSQLLiterals bool
+
+	// Marker replaces redacted values (default: "[REDACTED]").
+	Marker string
+}
+
+// defaultRedactHeaders are the headers redacted when RedactHeaders is nil
+var defaultRedactHeaders = []string{"Authorization", "Cookie", "X-Api-Key"}
+
+// sqlStringLiteral and sqlNumericLiteral match literals in SQL statements
+var (
+	sqlStringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlNumericLiteral = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+)
+
+// redactor applies a RedactionConfig. A nil *redactor is valid and leaves
+// all values unchanged.
+type redactor struct {
+	denyKeys      map[string]bool
+	redactHeaders map[string]bool
+	allowHeaders  map[string]bool
+	valuePatterns []PIIPattern
+	sqlLiterals   bool
+	marker        string
+}
+
+// newRedactor builds a redactor from config (nil = defaults)
+func newRedactor(cfg *RedactionConfig) *redactor {
+	if cfg == nil {
+		cfg = &RedactionConfig{}
+	}
+
+	r := &redactor{
+		denyKeys:      lowerSet(cfg.DenyKeys),
+		allowHeaders:  lowerSet(cfg.AllowHeaders),
+		valuePatterns: cfg.ValuePatterns,
+		sqlLiterals:   cfg.RedactSQLLiterals,
+		marker:        cfg.Marker,
+	}
+
+	if cfg.RedactHeaders == nil {
+		r.redactHeaders = lowerSet(defaultRedactHeaders)
+	} else {
+		r.redactHeaders = lowerSet(cfg.RedactHeaders)
+	}
+
+	if r.marker == "" {
+		r.marker = "[REDACTED]"
+	}
+
+	return r
+}
+
+// lowerSet builds a case-insensitive lookup set
+func lowerSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[strings.ToLower(v)] = true
+	}
+	return set
+}
+
+// isDenied returns true if the key's value must always be redacted
+func (r *redactor) isDenied(key string) bool {
+	if r == nil {
+		return false
+	}
+	return r.denyKeys[strings.ToLower(key)]
+}
+
+// redact returns the value to record for key, applying the key denylist
+// and value patterns
+func (r *redactor) redact(key, value string) string {
+	if r == nil {
+		return value
+	}
+	if r.isDenied(key) {
+		return r.marker
+	}
+	for _, p := range r.valuePatterns {
+		value = p.Pattern.ReplaceAllString(value, p.Marker)
+	}
+	return value
+}
+
+// redactHeader applies header rules first, then the generic redact rules
+func (r *redactor) redactHeader(name, value string) string {
+	if r == nil {
+		return value
+	}
+	lower := strings.ToLower(name)
+	if r.allowHeaders[lower] {
+		return value
+	}
+	if r.redactHeaders[lower] {
+		return r.marker
+	}
+	return r.redact(name, value)
+}
+
+// redactSQL strips literals (if configured) and applies value patterns to a statement
+func (r *redactor) redactSQL(query string) string {
+	if r == nil {
+		return query
+	}
+	if r.sqlLiterals {
+		query = sqlStringLiteral.ReplaceAllString(query, "?")
+		query = sqlNumericLiteral.ReplaceAllString(query, "?")
+	}
+	return r.redact("db.statement", query)
+}
@@ -0,0 +1,39 @@
+package tracekit
+
+import (
+	"regexp"
+	"testing"
+)
+
+// TestRedactor verifies key, value, header and SQL redaction rules
+func TestRedactor(t *testing.T) {
+	r := newRedactor(&RedactionConfig{
+		DenyKeys: []string{"ssn"},
+		ValuePatterns: []PIIPattern{
+			{Pattern: regexp.MustCompile(`\d{4}-\d{4}`), Marker: "[REDACTED:card]"},
+		},
+		AllowHeaders:      []string{"Cookie"},
+		RedactSQLLiterals: true,
+	})
+
+	if got := r.redact("SSN", "123-45-6789"); got != "[REDACTED]" {
+		t.Errorf("denylisted key: got %q", got)
+	}
+	if got := r.redact("note", "card 1234-5678 on file"); got != "card [REDACTED:card] on file" {
+		t.Errorf("value pattern: got %q", got)
+	}
+	if got := r.redactHeader("authorization", "Bearer abc"); got != "[REDACTED]" {
+		t.Errorf("default header rule: got %q", got)
+	}
+	if got := r.redactHeader("Cookie", "session=1"); got != "session=1" {
+		t.Errorf("allowlisted header: got %q", got)
+	}
+	if got := r.redactSQL("SELECT * FROM users WHERE email = 'a@b.c' AND id = 42"); got != "SELECT * FROM users WHERE email = ? AND id = ?" {
+		t.Errorf("SQL literals: got %q", got)
+	}
+
+	var nilRedactor *redactor
+	if got := nilRedactor.redact("ssn", "value"); got != "value" {
+		t.Errorf("nil redactor should pass values through, got %q", got)
+	}
+}
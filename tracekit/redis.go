@@ -2,34 +2,193 @@ package tracekit
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"net"
+	"strings"
+	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 )
 
 // WrapRedis adds OpenTelemetry instrumentation to a Redis client using hooks
 func (s *SDK) WrapRedis(client *redis.Client) error {
 	// Add before and after hooks for tracing
-	client.AddHook(&redisHook{
-		tracer: s.tracer,
-	})
+	client.AddHook(s.newRedisHook(client.Options().Addr))
 	return nil
 }
 
 // WrapRedisCluster adds OpenTelemetry instrumentation to a Redis cluster client
 func (s *SDK) WrapRedisCluster(client *redis.ClusterClient) error {
-	client.AddHook(&redisHook{
-		tracer: s.tracer,
-	})
+	// Cluster nodes serve one logical datastore; name it after the first seed
+	var addr string
+	if addrs := client.Options().Addrs; len(addrs) > 0 {
+		addr = addrs[0]
+	}
+	client.AddHook(s.newRedisHook(addr))
 	return nil
 }
 
+// TracedRedisMessage is a Redis Pub/Sub message delivered together with the
+// context of its CONSUMER span. Use Context as the parent for any processing
+// so the work is linked to the publisher's trace.
+type TracedRedisMessage struct {
+	*redis.Message
+	Context context.Context
+	// Span is the message's CONSUMER span. It is ended when the next message
+	// is handed off or the channel closes; call End once processing finishes
+	// to record the exact duration.
+	Span trace.Span
+}
+
+// WrapRedisPubSub returns a channel of traced messages for a subscription.
+// Each received message gets a CONSUMER span with messaging.* attributes.
+// If the payload is a JSON object carrying W3C context fields (traceparent,
+// tracestate, baggage), the span continues the publisher's trace.
+// The returned channel is closed when the underlying subscription closes or
+// ctx is done; cancel ctx when you stop reading so the goroutine exits.
+func (s *SDK) WrapRedisPubSub(ctx context.Context, ps *redis.PubSub) <-chan *TracedRedisMessage {
+	return s.traceRedisMessages(ctx, ps.Channel())
+}
+
+// traceRedisMessages forwards msgs to the returned channel with a CONSUMER
+// span per message
+func (s *SDK) traceRedisMessages(ctx context.Context, msgs <-chan *redis.Message) <-chan *TracedRedisMessage {
+	out := make(chan *TracedRedisMessage)
+
+	go func() {
+		defer close(out)
+
+		// The previous message's span stays open until the consumer takes the
+		// next one, covering its processing
+		var prev trace.Span
+		defer func() {
+			if prev != nil {
+				prev.End()
+			}
+		}()
+
+		for {
+			var msg *redis.Message
+			select {
+			case <-ctx.Done():
+				return
+			case m, ok := <-msgs:
+				if !ok {
+					return
+				}
+				msg = m
+			}
+
+			msgCtx, span := s.startRedisReceiveSpan(msg)
+
+			select {
+			case out <- &TracedRedisMessage{Message: msg, Context: msgCtx, Span: span}:
+			case <-ctx.Done():
+				span.End()
+				return
+			}
+
+			if prev != nil {
+				prev.End()
+			}
+			prev = span
+		}
+	}()
+
+	return out
+}
+
+// startRedisReceiveSpan starts the CONSUMER span for a Pub/Sub message
+func (s *SDK) startRedisReceiveSpan(msg *redis.Message) (context.Context, trace.Span) {
+	ctx := extractRedisPayloadContext(context.Background(), msg.Payload)
+
+	attrs := []attribute.KeyValue{
+		attribute.String("messaging.system", "redis"),
+		attribute.String("messaging.destination.name", msg.Channel),
+		attribute.String("messaging.operation", "receive"),
+		attribute.Int("messaging.message.body.size", len(msg.Payload)),
+	}
+	if msg.Pattern != "" {
+		attrs = append(attrs, attribute.String("messaging.redis.pattern", msg.Pattern))
+	}
+
+	return s.tracer.Start(ctx, msg.Channel+" receive",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// extractRedisPayloadContext extracts trace context embedded in a JSON payload
+func extractRedisPayloadContext(ctx context.Context, payload string) context.Context {
+	if !strings.HasPrefix(strings.TrimSpace(payload), "{") || !strings.Contains(payload, "traceparent") {
+		return ctx
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &fields); err != nil {
+		return ctx
+	}
+
+	carrier := propagation.MapCarrier{}
+	for _, key := range []string{"traceparent", "tracestate", "baggage"} {
+		if v, ok := fields[key].(string); ok {
+			carrier[key] = v
+		}
+	}
+
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// newRedisHook creates a redisHook configured from the SDK config, with
+// peer attributes for the server at addr
+func (s *SDK) newRedisHook(addr string) *redisHook {
+	h := &redisHook{
+		tracer:             s.tracer,
+		redactor:           s.redactor,
+		maxStatementLength: 256,
+	}
+
+	var mappings map[string]string
+	if s.config != nil {
+		h.recordResponseSize = s.config.RedisRecordResponseSize
+		h.largeResponseBytes = s.config.RedisLargeResponseBytes
+		h.captureStatement = s.config.RedisCaptureStatement
+		h.pipelineChildSpans = s.config.RedisPipelineChildSpans
+		if s.config.RedisStatementMaxLength > 0 {
+			h.maxStatementLength = s.config.RedisStatementMaxLength
+		}
+		mappings = s.config.ServiceNameMappings
+	}
+	h.peerAttrs = peerAttributes(addr, mappings)
+
+	return h
+}
+
 // redisHook implements redis.Hook interface for OpenTelemetry tracing
 type redisHook struct {
-	tracer trace.Tracer
+	tracer   trace.Tracer
+	redactor *redactor
+
+	// recordResponseSize records db.redis.response_bytes on each command span
+	recordResponseSize bool
+	// largeResponseBytes adds a redis.large_response event above this size (0 = disabled)
+	largeResponseBytes int
+
+	// captureStatement records the sanitized command and arguments as db.statement
+	captureStatement   bool
+	maxStatementLength int
+
+	// pipelineChildSpans creates a child span per pipeline command instead of events
+	pipelineChildSpans bool
+
+	// peerAttrs are net.peer.name and peer.service for the server
+	peerAttrs []attribute.KeyValue
 }
 
 func (h *redisHook) DialHook(next redis.DialHook) redis.DialHook {
@@ -40,21 +199,31 @@ func (h *redisHook) DialHook(next redis.DialHook) redis.DialHook {
 
 func (h *redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
 	return func(ctx context.Context, cmd redis.Cmder) error {
-		ctx, span := h.tracer.Start(ctx, "redis."+cmd.Name())
+		ctx, span := h.tracer.Start(ctx, "redis."+cmd.Name(), trace.WithAttributes(h.peerAttrs...))
 		defer span.End()
 
 		span.SetAttributes(
 			attribute.String("db.system", "redis"),
 			attribute.String("db.operation", cmd.Name()),
 		)
+		if h.captureStatement {
+			span.SetAttributes(attribute.String("db.statement", h.statement(cmd)))
+		}
 
+		start := time.Now()
 		err := next(ctx, cmd)
+		span.SetAttributes(attribute.Float64("db.redis.duration_ms", float64(time.Since(start).Microseconds())/1000))
+
 		// redis.Nil is not an error - it just means "key not found" or "no data"
 		if err != nil && err != redis.Nil {
 			span.RecordError(err)
 			span.SetStatus(codes.Error, err.Error())
 		} else {
 			span.SetStatus(codes.Ok, "")
+			if size, ok := redisResponseSize(cmd); ok {
+				span.SetAttributes(attribute.Int("db.redis.response_size", size))
+			}
+			h.recordResponseBytes(span, cmd)
 		}
 
 		return err
@@ -63,7 +232,7 @@ func (h *redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
 
 func (h *redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
 	return func(ctx context.Context, cmds []redis.Cmder) error {
-		ctx, span := h.tracer.Start(ctx, "redis.pipeline")
+		ctx, span := h.tracer.Start(ctx, "redis.pipeline", trace.WithAttributes(h.peerAttrs...))
 		defer span.End()
 
 		span.SetAttributes(
@@ -71,15 +240,191 @@ func (h *redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.Pr
 			attribute.Int("db.redis.pipeline_length", len(cmds)),
 		)
 
+		// Optionally start a child span per command (they share the pipeline's timing)
+		var childSpans []trace.Span
+		if h.pipelineChildSpans {
+			childSpans = make([]trace.Span, len(cmds))
+			for i, cmd := range cmds {
+				_, childSpans[i] = h.tracer.Start(ctx, "redis."+cmd.Name(), trace.WithAttributes(h.peerAttrs...))
+				childSpans[i].SetAttributes(
+					attribute.String("db.system", "redis"),
+					attribute.String("db.operation", cmd.Name()),
+				)
+			}
+		}
+
 		err := next(ctx, cmds)
-		// redis.Nil is not an error - it just means "key not found" or "no data"
-		if err != nil && err != redis.Nil {
+
+		// Aggregate per-command errors; redis.Nil is a miss, not a failure
+		var failed []string
+		for i, cmd := range cmds {
+			cmdErr := cmd.Err()
+			isError := cmdErr != nil && cmdErr != redis.Nil
+			if isError {
+				failed = append(failed, cmd.Name())
+			}
+
+			if childSpans != nil {
+				if isError {
+					childSpans[i].RecordError(cmdErr)
+					childSpans[i].SetStatus(codes.Error, cmdErr.Error())
+				} else {
+					childSpans[i].SetStatus(codes.Ok, "")
+				}
+				childSpans[i].End()
+				continue
+			}
+
+			span.AddEvent("redis.command", trace.WithAttributes(
+				attribute.String("db.operation", cmd.Name()),
+				attribute.Bool("error", isError),
+			))
+		}
+
+		if len(failed) > 0 {
+			span.SetAttributes(attribute.StringSlice("db.redis.failed_commands", failed))
+		}
+
+		switch {
+		case err != nil && err != redis.Nil:
 			span.RecordError(err)
 			span.SetStatus(codes.Error, err.Error())
-		} else {
+		case len(failed) > 0:
+			span.SetStatus(codes.Error, fmt.Sprintf("%d of %d pipeline commands failed", len(failed), len(cmds)))
+		default:
 			span.SetStatus(codes.Ok, "")
 		}
 
 		return err
 	}
 }
+
+// redisMaskAllArgs lists commands whose arguments are always masked (credentials)
+var redisMaskAllArgs = map[string]bool{
+	"AUTH":  true,
+	"HELLO": true,
+}
+
+// statement builds a db.statement such as "GET user:123" from cmd.Args().
+// AUTH credentials are masked, as is any value following a key or hash field
+// whose name looks sensitive (e.g. "SET api_token xyz" -> "SET api_token ?").
+// The result passes through the SDK redaction rules and is truncated to
+// maxStatementLength.
+func (h *redisHook) statement(cmd redis.Cmder) string {
+	args := cmd.Args()
+	if len(args) == 0 {
+		return ""
+	}
+
+	name := strings.ToUpper(fmt.Sprint(args[0]))
+	parts := make([]string, 0, len(args))
+	parts = append(parts, name)
+
+	for i := 1; i < len(args); i++ {
+		switch {
+		case redisMaskAllArgs[name]:
+			parts = append(parts, "?")
+		case i > 1 && h.isSensitiveName(fmt.Sprint(args[i-1])):
+			parts = append(parts, "?")
+		default:
+			parts = append(parts, fmt.Sprint(args[i]))
+		}
+	}
+
+	stmt := h.redactor.redact("db.statement", strings.Join(parts, " "))
+	if len(stmt) > h.maxStatementLength {
+		stmt = stmt[:h.maxStatementLength] + "... (truncated)"
+	}
+	return stmt
+}
+
+// isSensitiveName returns true if a key or field name suggests a secret value
+func (h *redisHook) isSensitiveName(name string) bool {
+	return h.redactor.isDenied(name) || piiScrubber.sensitiveNameExpr.MatchString(name)
+}
+
+// recordResponseBytes records the reply size of cmd and flags unusually large replies
+func (h *redisHook) recordResponseBytes(span trace.Span, cmd redis.Cmder) {
+	if !h.recordResponseSize && h.largeResponseBytes <= 0 {
+		return
+	}
+
+	size, ok := redisResponseBytes(cmd)
+	if !ok {
+		return
+	}
+
+	if h.recordResponseSize {
+		span.SetAttributes(attribute.Int("db.redis.response_bytes", size))
+	}
+
+	if h.largeResponseBytes > 0 && size > h.largeResponseBytes {
+		span.AddEvent("redis.large_response", trace.WithAttributes(
+			attribute.String("db.operation", cmd.Name()),
+			attribute.Int("db.redis.response_bytes", size),
+			attribute.Int("db.redis.large_response_threshold", h.largeResponseBytes),
+		))
+	}
+}
+
+// redisResponseSize returns the length in bytes of a bulk string reply, or
+// the element count of an array or map reply. Returns false for scalar
+// replies (integers, status, etc).
+func redisResponseSize(cmd redis.Cmder) (int, bool) {
+	switch c := cmd.(type) {
+	case *redis.StringCmd:
+		return len(c.Val()), true
+	case *redis.SliceCmd:
+		return len(c.Val()), true
+	case *redis.StringSliceCmd:
+		return len(c.Val()), true
+	case *redis.IntSliceCmd:
+		return len(c.Val()), true
+	case *redis.MapStringStringCmd:
+		return len(c.Val()), true
+	case *redis.ZSliceCmd:
+		return len(c.Val()), true
+	case *redis.Cmd:
+		switch v := c.Val().(type) {
+		case string:
+			return len(v), true
+		case []interface{}:
+			return len(v), true
+		}
+	}
+	return 0, false
+}
+
+// redisResponseBytes returns the approximate size in bytes of a command's
+// string/bulk reply. Returns false for reply types whose size isn't meaningful.
+func redisResponseBytes(cmd redis.Cmder) (int, bool) {
+	switch c := cmd.(type) {
+	case *redis.StringCmd:
+		return len(c.Val()), true
+	case *redis.StringSliceCmd:
+		size := 0
+		for _, v := range c.Val() {
+			size += len(v)
+		}
+		return size, true
+	case *redis.MapStringStringCmd:
+		size := 0
+		for k, v := range c.Val() {
+			size += len(k) + len(v)
+		}
+		return size, true
+	case *redis.SliceCmd:
+		size := 0
+		for _, v := range c.Val() {
+			if str, ok := v.(string); ok {
+				size += len(str)
+			}
+		}
+		return size, true
+	case *redis.Cmd:
+		if str, ok := c.Val().(string); ok {
+			return len(str), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,213 @@
+package tracekit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestRedisStatement verifies command arguments are captured with sensitive values masked
+func TestRedisStatement(t *testing.T) {
+	h := &redisHook{captureStatement: true, maxStatementLength: 40}
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		cmd  redis.Cmder
+		want string
+	}{
+		{name: "plain get", cmd: redis.NewStringCmd(ctx, "get", "user:123"), want: "GET user:123"},
+		{name: "auth masked", cmd: redis.NewStatusCmd(ctx, "auth", "admin", "hunter2"), want: "AUTH ? ?"},
+		{name: "sensitive key value masked", cmd: redis.NewStatusCmd(ctx, "set", "api_token", "abc"), want: "SET api_token ?"},
+		{name: "sensitive hash field masked", cmd: redis.NewIntCmd(ctx, "hset", "user:1", "password", "pw", "name", "bob"), want: "HSET user:1 password ? name bob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.statement(tt.cmd); got != tt.want {
+				t.Errorf("statement = %q; want %q", got, tt.want)
+			}
+		})
+	}
+
+	long := h.statement(redis.NewStringCmd(ctx, "get", strings.Repeat("k", 100)))
+	if !strings.HasSuffix(long, "... (truncated)") {
+		t.Errorf("expected long statement to be truncated, got %q", long)
+	}
+}
+
+// TestRedisPipelineErrors verifies the pipeline span is only an error when a
+// command fails with something other than redis.Nil
+func TestRedisPipelineErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		errs       []error
+		wantStatus codes.Code
+		wantFailed []string
+	}{
+		{name: "all ok", errs: []error{nil, nil}, wantStatus: codes.Ok},
+		{name: "miss is not a failure", errs: []error{redis.Nil, nil}, wantStatus: codes.Ok},
+		{name: "failed command", errs: []error{nil, errors.New("WRONGTYPE")}, wantStatus: codes.Error, wantFailed: []string{"hget"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			defer tp.Shutdown(context.Background())
+			h := &redisHook{tracer: tp.Tracer("test")}
+
+			ctx := context.Background()
+			cmds := []redis.Cmder{redis.NewStringCmd(ctx, "get", "a"), redis.NewStringCmd(ctx, "hget", "b", "f")}
+			process := h.ProcessPipelineHook(func(ctx context.Context, cmds []redis.Cmder) error {
+				for i, err := range tt.errs {
+					cmds[i].SetErr(err)
+				}
+				return nil
+			})
+			if err := process(ctx, cmds); err != nil {
+				t.Fatalf("pipeline error = %v", err)
+			}
+
+			span := recorder.Ended()[0]
+			if span.Status().Code != tt.wantStatus {
+				t.Errorf("status = %v; want %v", span.Status().Code, tt.wantStatus)
+			}
+			var failed []string
+			for _, attr := range span.Attributes() {
+				if attr.Key == "db.redis.failed_commands" {
+					failed = attr.Value.AsStringSlice()
+				}
+			}
+			if strings.Join(failed, ",") != strings.Join(tt.wantFailed, ",") {
+				t.Errorf("db.redis.failed_commands = %v; want %v", failed, tt.wantFailed)
+			}
+			if got := len(span.Events()); got != len(cmds) {
+				t.Errorf("got %d redis.command events; want %d", got, len(cmds))
+			}
+		})
+	}
+}
+
+// TestWrapRedisWithoutConfig verifies the hook falls back to defaults when
+// the SDK has no config
+func TestWrapRedisWithoutConfig(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	defer client.Close()
+
+	if err := (&SDK{}).WrapRedis(client); err != nil {
+		t.Fatalf("WrapRedis() error = %v", err)
+	}
+	if h := (&SDK{}).newRedisHook("localhost:6379"); h.maxStatementLength != 256 {
+		t.Errorf("maxStatementLength = %d; want 256", h.maxStatementLength)
+	}
+}
+
+// TestRedisResponseSize verifies db.redis.response_size is recorded for bulk
+// and array replies, without enabling RedisRecordResponseSize
+func TestRedisResponseSize(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name  string
+		cmd   redis.Cmder
+		reply func(cmd redis.Cmder)
+		want  int64
+		has   bool
+	}{
+		{name: "bulk string bytes", cmd: redis.NewStringCmd(ctx, "get", "k"), reply: func(c redis.Cmder) {
+			c.(*redis.StringCmd).SetVal("hello")
+		}, want: 5, has: true},
+		{name: "array elements", cmd: redis.NewStringSliceCmd(ctx, "lrange", "l", 0, -1), reply: func(c redis.Cmder) {
+			c.(*redis.StringSliceCmd).SetVal([]string{"a", "bb", "ccc"})
+		}, want: 3, has: true},
+		{name: "integer reply", cmd: redis.NewIntCmd(ctx, "incr", "n"), reply: func(c redis.Cmder) {
+			c.(*redis.IntCmd).SetVal(7)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			defer tp.Shutdown(context.Background())
+			h := &redisHook{tracer: tp.Tracer("test")}
+
+			process := h.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+				tt.reply(cmd)
+				return nil
+			})
+			if err := process(ctx, tt.cmd); err != nil {
+				t.Fatalf("process error = %v", err)
+			}
+
+			var got int64
+			var has bool
+			for _, attr := range recorder.Ended()[0].Attributes() {
+				if attr.Key == "db.redis.response_size" {
+					got, has = attr.Value.AsInt64(), true
+				}
+			}
+			if has != tt.has || got != tt.want {
+				t.Errorf("db.redis.response_size = %d (set %v); want %d (set %v)", got, has, tt.want, tt.has)
+			}
+		})
+	}
+}
+
+// TestTraceRedisMessages verifies consumer spans stay open until the next
+// handoff and the forwarding goroutine exits when the context is cancelled
+func TestTraceRedisMessages(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{tracer: tp.Tracer("test")}
+
+	msgs := make(chan *redis.Message, 1)
+	out := sdk.traceRedisMessages(context.Background(), msgs)
+
+	msgs <- &redis.Message{Channel: "orders", Payload: "hello"}
+	msg := <-out
+	if !msg.Span.SpanContext().IsValid() {
+		t.Fatal("message has no consumer span")
+	}
+	if got := len(recorder.Ended()); got != 0 {
+		t.Errorf("%d spans ended before processing finished; want 0", got)
+	}
+
+	close(msgs)
+	waitClosed(t, out)
+	if got := len(recorder.Ended()); got != 1 {
+		t.Errorf("%d spans ended after the subscription closed; want 1", got)
+	}
+
+	// A consumer that stops reading must not leak the goroutine
+	ctx, cancel := context.WithCancel(context.Background())
+	blocked := make(chan *redis.Message, 1)
+	blocked <- &redis.Message{Channel: "orders", Payload: "unread"}
+	out = sdk.traceRedisMessages(ctx, blocked)
+	cancel()
+	waitClosed(t, out)
+}
+
+// waitClosed drains out and fails if it isn't closed within a second
+func waitClosed(t *testing.T, out <-chan *TracedRedisMessage) {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-out:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("traced message channel was not closed")
+		}
+	}
+}
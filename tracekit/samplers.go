@@ -0,0 +1,244 @@
+package tracekit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// killSwitchSampler drops every span while the SDK is disabled via
+// SetEnabled(false). Because it sits in the tracer provider, it covers all
+// integrations (HTTP, gin, echo, gRPC, database, Redis, Kafka, LLM), not
+// just spans started through SDK.StartSpan.
+type killSwitchSampler struct {
+	base     sdktrace.Sampler
+	disabled *atomic.Bool
+}
+
+// ShouldSample drops the span when disabled, otherwise defers to base
+func (s *killSwitchSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if s.disabled.Load() {
+		return sdktrace.SamplingResult{Decision: sdktrace.Drop}
+	}
+	return s.base.ShouldSample(p)
+}
+
+// Description returns the sampler description
+func (s *killSwitchSampler) Description() string {
+	return "KillSwitch{" + s.base.Description() + "}"
+}
+
+// recordAllSampler turns base's Drop decisions into RecordOnly, so every span
+// reaches the span processors (e.g. span metrics) while only sampled spans
+// are exported. The sampled flag, and therefore propagation downstream, is
+// unchanged.
+type recordAllSampler struct {
+	base sdktrace.Sampler
+}
+
+// ShouldSample records spans base would drop, without sampling them
+func (s *recordAllSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := s.base.ShouldSample(p)
+	if result.Decision == sdktrace.Drop {
+		result.Decision = sdktrace.RecordOnly
+	}
+	return result
+}
+
+// Description returns the sampler description
+func (s *recordAllSampler) Description() string {
+	return "RecordAll{" + s.base.Description() + "}"
+}
+
+// maxSampledOperations caps the operations tracked by firstNPerOperationSampler
+// so high-cardinality span names can't grow the counter map without bound
+const maxSampledOperations = 10000
+
+// firstNPerOperationSampler samples the first n traces of each span name,
+// then defers to base. Intended as the root sampler under ParentBased.
+type firstNPerOperationSampler struct {
+	base sdktrace.Sampler
+	n    int64
+
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func newFirstNPerOperationSampler(base sdktrace.Sampler, n int) *firstNPerOperationSampler {
+	return &firstNPerOperationSampler{
+		base:   base,
+		n:      int64(n),
+		counts: make(map[string]int64),
+	}
+}
+
+// ShouldSample records and samples the span while its operation is under n
+func (s *firstNPerOperationSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if s.take(p.Name) {
+		return sdktrace.SamplingResult{
+			Decision:   sdktrace.RecordAndSample,
+			Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+		}
+	}
+	return s.base.ShouldSample(p)
+}
+
+// take consumes one of name's guaranteed samples, reporting whether one was left
+func (s *firstNPerOperationSampler) take(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count, tracked := s.counts[name]
+	if count >= s.n || (!tracked && len(s.counts) >= maxSampledOperations) {
+		return false
+	}
+	s.counts[name] = count + 1
+	return true
+}
+
+// Description returns the sampler description
+func (s *firstNPerOperationSampler) Description() string {
+	return fmt.Sprintf("FirstNPerOperation{%d,%s}", s.n, s.base.Description())
+}
+
+// SamplingRule sets the sampling rate of root spans matching Name and
+// Attributes. Both are optional; a rule with neither matches every span.
+type SamplingRule struct {
+	// Name is a glob on the span name where * matches any characters,
+	// e.g. "GET /checkout*"
+	Name string
+
+	// Attributes must all be present on the span at start with matching
+	// values (globs as for Name), e.g. {"url.path": "/healthz"}
+	Attributes map[string]string
+
+	// Rate is the fraction of matching traces sampled (0.0 to 1.0)
+	Rate float64
+}
+
+// ruleSampler samples with the rate of the first matching SamplingRule,
+// falling back to base. Intended as the root sampler under ParentBased.
+type ruleSampler struct {
+	rules    []SamplingRule
+	samplers []sdktrace.Sampler // TraceIDRatioBased per rule
+	base     sdktrace.Sampler
+}
+
+func newRuleSampler(rules []SamplingRule, base sdktrace.Sampler) *ruleSampler {
+	s := &ruleSampler{rules: rules, base: base}
+	for _, rule := range rules {
+		s.samplers = append(s.samplers, sdktrace.TraceIDRatioBased(rule.Rate))
+	}
+	return s
+}
+
+// ShouldSample applies the first matching rule's rate
+func (s *ruleSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	for i, rule := range s.rules {
+		if rule.matches(p) {
+			return s.samplers[i].ShouldSample(p)
+		}
+	}
+	return s.base.ShouldSample(p)
+}
+
+// matches reports whether the span being started matches the rule
+func (r SamplingRule) matches(p sdktrace.SamplingParameters) bool {
+	if r.Name != "" && !globMatch(r.Name, p.Name) {
+		return false
+	}
+	for key, pattern := range r.Attributes {
+		matched := false
+		for _, attr := range p.Attributes {
+			if string(attr.Key) == key {
+				matched = globMatch(pattern, attr.Value.Emit())
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Description returns the sampler description
+func (s *ruleSampler) Description() string {
+	return fmt.Sprintf("Rules{%d,%s}", len(s.rules), s.base.Description())
+}
+
+// globMatch matches s against pattern, where * matches any (possibly
+// empty) run of characters, including slashes
+func globMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == s
+	}
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
+
+// SamplingOverride is a per-request sampling decision returned by
+// Config.RequestSampler
+type SamplingOverride int
+
+const (
+	// SamplingDefault leaves the decision to the configured samplers
+	SamplingDefault SamplingOverride = iota
+	// SamplingAlways records and samples the request's root span
+	SamplingAlways
+	// SamplingNever drops the request's root span
+	SamplingNever
+)
+
+// samplingOverrideKey is the context key for a request's SamplingOverride
+type samplingOverrideKey struct{}
+
+// withSamplingOverride returns ctx carrying the override for the next root span
+func withSamplingOverride(ctx context.Context, override SamplingOverride) context.Context {
+	return context.WithValue(ctx, samplingOverrideKey{}, override)
+}
+
+// requestOverrideSampler applies a SamplingOverride set by the HTTP
+// middleware to the service's root span for the request. Spans with a local
+// parent, and requests without an override, defer to base.
+type requestOverrideSampler struct {
+	base sdktrace.Sampler
+}
+
+// ShouldSample forces or drops local root spans carrying an override
+func (s *requestOverrideSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	parent := trace.SpanContextFromContext(p.ParentContext)
+	if parent.IsValid() && !parent.IsRemote() {
+		return s.base.ShouldSample(p)
+	}
+
+	override, _ := p.ParentContext.Value(samplingOverrideKey{}).(SamplingOverride)
+	switch override {
+	case SamplingAlways:
+		return sdktrace.SamplingResult{Decision: sdktrace.RecordAndSample, Tracestate: parent.TraceState()}
+	case SamplingNever:
+		return sdktrace.SamplingResult{Decision: sdktrace.Drop, Tracestate: parent.TraceState()}
+	}
+	return s.base.ShouldSample(p)
+}
+
+// Description returns the sampler description
+func (s *requestOverrideSampler) Description() string {
+	return "RequestOverride{" + s.base.Description() + "}"
+}
@@ -0,0 +1,150 @@
+package tracekit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestFirstNPerOperationSampler verifies each operation's first N traces are
+// sampled before falling back to the base sampler
+func TestFirstNPerOperationSampler(t *testing.T) {
+	sampler := newFirstNPerOperationSampler(sdktrace.NeverSample(), 2)
+
+	tests := []struct {
+		name string
+		want sdktrace.SamplingDecision
+	}{
+		{"GET /orders", sdktrace.RecordAndSample},
+		{"GET /orders", sdktrace.RecordAndSample},
+		{"GET /orders", sdktrace.Drop},
+		{"GET /health", sdktrace.RecordAndSample},
+		{"GET /health", sdktrace.RecordAndSample},
+		{"GET /health", sdktrace.Drop},
+	}
+
+	for i, tt := range tests {
+		got := sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.Background(), Name: tt.name})
+		if got.Decision != tt.want {
+			t.Errorf("call %d (%s) decision = %v; want %v", i, tt.name, got.Decision, tt.want)
+		}
+	}
+}
+
+// TestRequestSampling verifies Config.RequestSampler forces or drops the
+// server span regardless of the base sampler
+func TestRequestSampling(t *testing.T) {
+	tests := []struct {
+		name      string
+		base      sdktrace.Sampler
+		override  SamplingOverride
+		wantSpans int
+	}{
+		{name: "forced", base: sdktrace.NeverSample(), override: SamplingAlways, wantSpans: 2},
+		{name: "dropped", base: sdktrace.AlwaysSample(), override: SamplingNever, wantSpans: 0},
+		{name: "default sampled", base: sdktrace.AlwaysSample(), override: SamplingDefault, wantSpans: 2},
+		{name: "default dropped", base: sdktrace.NeverSample(), override: SamplingDefault, wantSpans: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(
+				sdktrace.WithSpanProcessor(recorder),
+				sdktrace.WithSampler(&requestOverrideSampler{base: sdktrace.ParentBased(tt.base)}),
+			)
+			defer tp.Shutdown(context.Background())
+
+			sdk := &SDK{
+				config: &Config{RequestSampler: func(r *http.Request) SamplingOverride {
+					return tt.override
+				}},
+				tracer:         tp.Tracer("test"),
+				tracerProvider: tp,
+			}
+			handler := sdk.HTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Children follow the root span's decision
+				_, child := tp.Tracer("test").Start(r.Context(), "child")
+				child.End()
+			}), "http.request")
+
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if got := len(recorder.Ended()); got != tt.wantSpans {
+				t.Errorf("recorded %d spans; want %d", got, tt.wantSpans)
+			}
+		})
+	}
+}
+
+// TestRecordAllSampler verifies spans the base sampler drops still reach span
+// processors, unsampled, so span metrics count them without exporting them
+func TestRecordAllSampler(t *testing.T) {
+	metrics := &metricRecorder{values: map[string][]float64{}}
+	exported := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(&recordAllSampler{base: sdktrace.ParentBased(sdktrace.TraceIDRatioBased(0))}),
+		sdktrace.WithSpanProcessor(&spanMetricsProcessor{counter: metrics.counter, histogram: metrics.histogram}),
+		sdktrace.WithSyncer(exported),
+	)
+	defer tp.Shutdown(context.Background())
+
+	for i := 0; i < 10; i++ {
+		ctx, parent := tp.Tracer("test").Start(context.Background(), "GET /orders")
+		_, child := tp.Tracer("test").Start(ctx, "sql.query")
+		child.End()
+		parent.End()
+	}
+
+	tags := map[string]string{"span.name": "GET /orders", "span.kind": "internal", "status.code": "Unset"}
+	if got := len(metrics.values[metricKey("span.calls", tags)]); got != 10 {
+		t.Errorf("span.calls = %d; want 10 despite 0%% sampling", got)
+	}
+	tags["span.name"] = "sql.query"
+	if got := len(metrics.values[metricKey("span.calls", tags)]); got != 10 {
+		t.Errorf("child span.calls = %d; want 10", got)
+	}
+	if got := len(exported.GetSpans()); got != 0 {
+		t.Errorf("exported %d spans; want 0", got)
+	}
+}
+
+// TestRuleSampler verifies the first matching rule sets the rate and
+// unmatched spans fall back to the base sampler
+func TestRuleSampler(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	sampler := newRuleSampler([]SamplingRule{
+		{Name: "GET /healthz", Rate: 0},
+		{Attributes: map[string]string{"url.path": "/internal/*"}, Rate: 0},
+		{Name: "*checkout*", Rate: 1},
+	}, sdktrace.NeverSample())
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	defer tp.Shutdown(context.Background())
+
+	tracer := tp.Tracer("test")
+	for _, name := range []string{"GET /healthz", "POST /checkout", "GET /orders"} {
+		_, span := tracer.Start(context.Background(), name)
+		span.End()
+	}
+	_, span := tracer.Start(context.Background(), "POST /checkout/internal",
+		trace.WithAttributes(attribute.String("url.path", "/internal/checkout")))
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "POST /checkout" {
+		names := make([]string, len(spans))
+		for i, s := range spans {
+			names[i] = s.Name()
+		}
+		t.Errorf("sampled spans = %v; want only POST /checkout", names)
+	}
+}
@@ -0,0 +1,239 @@
+package tracekit
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"unicode/utf8"
+)
+
+const (
+	// defaultSanitizeDepth bounds variable nesting when no capture depth is set
+	defaultSanitizeDepth = 20
+	// defaultMaxStringLength caps captured strings when CaptureConfig.MaxStringLength is unset
+	defaultMaxStringLength = 4096
+	// defaultMaxCollectionLength caps captured slices/maps when CaptureConfig.MaxCollectionLength is unset
+	defaultMaxCollectionLength = 100
+)
+
+// variableSanitizer converts captured variables into JSON-safe values:
+// structs become maps of their exported fields, cycles and over-deep values
+// are cut off, long strings and collections are truncated, and kinds JSON
+// can't encode (channels, funcs, complex numbers, ...) become type descriptors
+// such as "[chan int]". Struct fields tagged `tracekit:"redact"` are replaced
+// with the redaction marker.
+type variableSanitizer struct {
+	maxDepth      int
+	maxString     int
+	maxCollection int
+	marker        string
+
+	// visiting holds the pointers/maps/slices on the current path
+	visiting map[visitKey]bool
+}
+
+type visitKey struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// sanitizeVariables returns a JSON-safe copy of variables, nesting at most
+// maxDepth levels (0 = defaultSanitizeDepth)
+func (c *SnapshotClient) sanitizeVariables(variables map[string]interface{}, maxDepth int) map[string]interface{} {
+	if variables == nil {
+		return nil
+	}
+	if maxDepth <= 0 {
+		maxDepth = defaultSanitizeDepth
+	}
+
+	s := &variableSanitizer{
+		maxDepth:      maxDepth,
+		maxString:     c.config.MaxStringLength,
+		maxCollection: c.config.MaxCollectionLength,
+		marker:        "[REDACTED]",
+		visiting:      make(map[visitKey]bool),
+	}
+	if s.maxString <= 0 {
+		s.maxString = defaultMaxStringLength
+	}
+	if s.maxCollection <= 0 {
+		s.maxCollection = defaultMaxCollectionLength
+	}
+	if c.redactor != nil {
+		s.marker = c.redactor.marker
+	}
+
+	result := make(map[string]interface{}, len(variables))
+	for name, value := range variables {
+		result[name] = s.sanitize(reflect.ValueOf(value), 1)
+	}
+	return result
+}
+
+func (s *variableSanitizer) sanitize(v reflect.Value, depth int) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	// Types that know how to encode themselves (time.Time, json.RawMessage, ...)
+	if v.CanInterface() && v.Kind() != reflect.Interface {
+		switch val := v.Interface().(type) {
+		case json.Marshaler, encoding.TextMarshaler:
+			if v.Kind() == reflect.Pointer && v.IsNil() {
+				return nil
+			}
+			return val
+		case error:
+			if v.Kind() == reflect.Pointer && v.IsNil() {
+				return nil
+			}
+			return s.truncateString(val.Error())
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Interface()
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Sprint(f) // Not representable in JSON
+		}
+		return v.Interface()
+	case reflect.String:
+		if str := v.String(); len(str) > s.maxString {
+			return s.truncateString(str)
+		}
+		return v.Interface()
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprint(v.Complex())
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return s.sanitize(v.Elem(), depth)
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return s.visit(v, func() interface{} { return s.sanitize(v.Elem(), depth) })
+	}
+
+	if depth >= s.maxDepth {
+		return map[string]interface{}{
+			"_truncated": true,
+			"_depth":     depth,
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return s.sanitizeStruct(v, depth)
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		return s.visit(v, func() interface{} { return s.sanitizeMap(v, depth) })
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 && utf8.Valid(v.Bytes()) {
+			return s.truncateString(string(v.Bytes()))
+		}
+		return s.visit(v, func() interface{} { return s.sanitizeList(v, depth) })
+	case reflect.Array:
+		return s.sanitizeList(v, depth)
+	default:
+		// Channels, funcs and unsafe pointers have no JSON form
+		return fmt.Sprintf("[%s]", v.Type())
+	}
+}
+
+// visit runs fn unless v is already on the current path, in which case the
+// cycle is reported instead of followed
+func (s *variableSanitizer) visit(v reflect.Value, fn func() interface{}) interface{} {
+	key := visitKey{ptr: v.Pointer(), typ: v.Type()}
+	if s.visiting[key] {
+		return fmt.Sprintf("[cycle: %s]", v.Type())
+	}
+	s.visiting[key] = true
+	defer delete(s.visiting, key)
+	return fn()
+}
+
+func (s *variableSanitizer) sanitizeStruct(v reflect.Value, depth int) interface{} {
+	t := v.Type()
+	fields := make(map[string]interface{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Tag.Get("tracekit") == "redact" {
+			fields[field.Name] = s.marker
+			continue
+		}
+		fields[field.Name] = s.sanitize(v.Field(i), depth+1)
+	}
+	if len(fields) == 0 && t.NumField() > 0 {
+		// Only unexported fields: name the type rather than send {}
+		return fmt.Sprintf("[%s]", t)
+	}
+	return fields
+}
+
+func (s *variableSanitizer) sanitizeMap(v reflect.Value, depth int) interface{} {
+	keys := make([]string, 0, v.Len())
+	values := make(map[string]reflect.Value, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key := fmt.Sprint(iter.Key())
+		keys = append(keys, key)
+		values[key] = iter.Value()
+	}
+	sort.Strings(keys) // Deterministic subset when truncating
+
+	result := make(map[string]interface{}, len(keys))
+	for i, key := range keys {
+		if i >= s.maxCollection {
+			result["_truncated"] = true
+			result["_length"] = len(keys)
+			break
+		}
+		result[key] = s.sanitize(values[key], depth+1)
+	}
+	return result
+}
+
+func (s *variableSanitizer) sanitizeList(v reflect.Value, depth int) interface{} {
+	n := v.Len()
+	if n > s.maxCollection {
+		n = s.maxCollection
+	}
+
+	result := make([]interface{}, n, n+1)
+	for i := 0; i < n; i++ {
+		result[i] = s.sanitize(v.Index(i), depth+1)
+	}
+	if v.Len() > n {
+		result = append(result, fmt.Sprintf("[... %d more]", v.Len()-n))
+	}
+	return result
+}
+
+func (s *variableSanitizer) truncateString(str string) string {
+	if len(str) <= s.maxString {
+		return str
+	}
+	cut := s.maxString
+	for cut > 0 && !utf8.RuneStart(str[cut]) {
+		cut--
+	}
+	return str[:cut] + "... (truncated)"
+}
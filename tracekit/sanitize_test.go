@@ -0,0 +1,73 @@
+package tracekit
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sanitizeNode struct {
+	Name     string
+	Next     *sanitizeNode
+	Password string `tracekit:"redact"`
+	internal int
+}
+
+// TestSanitizeVariables verifies captured variables always marshal, with
+// cycles, unsupported kinds and oversized values rendered safely
+func TestSanitizeVariables(t *testing.T) {
+	client := NewSnapshotClientWithConfig("test-key", "http://localhost", "test-service", CaptureConfig{
+		MaxStringLength:     8,
+		MaxCollectionLength: 2,
+	})
+
+	loop := &sanitizeNode{Name: "a", Password: "hunter2"}
+	loop.Next = loop
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	got := client.sanitizeVariables(map[string]interface{}{
+		"node":   loop,
+		"ch":     make(chan int),
+		"fn":     func() {},
+		"long":   "0123456789",
+		"items":  []int{1, 2, 3},
+		"at":     at,
+		"hidden": struct{ secret string }{"x"},
+		"count":  7,
+	}, 0)
+
+	if _, err := json.Marshal(got); err != nil {
+		t.Fatalf("sanitized variables don't marshal: %v", err)
+	}
+
+	node := got["node"].(map[string]interface{})
+	if node["Password"] != "[REDACTED]" {
+		t.Errorf("Password = %v; want [REDACTED]", node["Password"])
+	}
+	if next, _ := node["Next"].(string); !strings.HasPrefix(next, "[cycle: ") {
+		t.Errorf("Next = %v; want cycle marker", node["Next"])
+	}
+	if _, ok := node["internal"]; ok {
+		t.Error("unexported field captured")
+	}
+
+	want := map[string]interface{}{
+		"ch":     "[chan int]",
+		"fn":     "[func()]",
+		"long":   "01234567... (truncated)",
+		"hidden": "[struct { secret string }]",
+		"count":  7,
+		"at":     at,
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %#v; want %#v", k, got[k], v)
+		}
+	}
+
+	items := got["items"].([]interface{})
+	if len(items) != 3 || items[2] != "[... 1 more]" {
+		t.Errorf("items = %v; want 2 items and a truncation marker", items)
+	}
+}
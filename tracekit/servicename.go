@@ -0,0 +1,38 @@
+package tracekit
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// serviceNameKey is the context key for a per-request service name override
+type serviceNameKey struct{}
+
+// WithServiceName attributes all spans started from the returned context to
+// the logical service name instead of Config.ServiceName, for processes that
+// host several logical services (e.g. a plugin host). The name is recorded as
+// a span-level service.name attribute, which takes precedence over the
+// process-wide resource attribute. Unlike WithTransactionID it is not
+// propagated to downstream services, which report their own name.
+//
+//	ctx = sdk.WithServiceName(r.Context(), plugin.Name)
+func (s *SDK) WithServiceName(ctx context.Context, serviceName string) context.Context {
+	// Also stamp the span that is already active
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		span.SetAttributes(attribute.String(serviceNameAttribute, serviceName))
+	}
+
+	return context.WithValue(ctx, serviceNameKey{}, serviceName)
+}
+
+// serviceNameAttribute is the span attribute carrying the override
+const serviceNameAttribute = "service.name"
+
+// serviceNameFromContext returns the service name override in ctx, if any
+func serviceNameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(serviceNameKey{}).(string)
+	return name
+}
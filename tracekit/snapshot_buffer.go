@@ -0,0 +1,205 @@
+package tracekit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// snapshotBuffer collects snapshots and uploads them in batches to
+// /sdk/snapshots/capture/batch once maxSize are pending or every
+// flushInterval, whichever comes first. If the backend doesn't support the
+// batch endpoint, pending and later snapshots are sent one request each.
+type snapshotBuffer struct {
+	client  *SnapshotClient
+	pending []Snapshot
+	mu      sync.Mutex
+
+	// flushMu serializes uploads; flushSignal asks the flush loop for an
+	// early flush when the buffer fills up
+	flushMu     sync.Mutex
+	flushSignal chan struct{}
+	stop        chan struct{}
+	done        chan struct{}
+
+	// unsupported is set once the backend rejects the batch endpoint
+	unsupported atomic.Bool
+
+	maxSize       int
+	flushInterval time.Duration
+}
+
+// newSnapshotBuffer creates a buffer for c (zero values = 20 snapshots / 2s)
+func newSnapshotBuffer(c *SnapshotClient, maxSize int, flushInterval time.Duration) *snapshotBuffer {
+	if maxSize <= 0 {
+		maxSize = 20
+	}
+	if flushInterval <= 0 {
+		flushInterval = 2 * time.Second
+	}
+
+	return &snapshotBuffer{
+		client:        c,
+		flushSignal:   make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+		maxSize:       maxSize,
+		flushInterval: flushInterval,
+	}
+}
+
+// add queues snapshot for the next batch, reporting false when batching is
+// unsupported and the caller should send it individually
+func (b *snapshotBuffer) add(snapshot Snapshot) bool {
+	if b.unsupported.Load() {
+		return false
+	}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, snapshot)
+	full := len(b.pending) >= b.maxSize
+	b.mu.Unlock()
+
+	if full {
+		// Non-blocking: a pending signal already covers this overflow
+		select {
+		case b.flushSignal <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+func (b *snapshotBuffer) start() {
+	go b.flushLoop()
+}
+
+func (b *snapshotBuffer) flushLoop() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			b.flush() // Final flush
+			return
+		case <-ticker.C:
+			b.flush()
+		case <-b.flushSignal:
+			b.flush()
+		}
+	}
+}
+
+// flush uploads all pending snapshots
+func (b *snapshotBuffer) flush() {
+	// Crash isolation for the flush goroutine
+	defer func() {
+		if r := recover(); r != nil {
+			b.client.logger.errorf("TraceKit: recovered from panic in snapshot flush: %v", r)
+		}
+	}()
+
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	if !b.client.sendSnapshotBatch(batch) {
+		b.unsupported.Store(true)
+		b.client.logger.infof("TraceKit: snapshot batch endpoint unavailable, sending snapshots individually")
+		for _, snapshot := range batch {
+			b.client.sendSnapshot(snapshot)
+		}
+	}
+}
+
+// shutdown stops the flush loop after a final flush, waiting briefly for it
+func (b *snapshotBuffer) shutdown() {
+	close(b.stop)
+	select {
+	case <-b.done:
+	case <-time.After(5 * time.Second):
+	}
+}
+
+// sendSnapshotBatch posts snapshots in one request. It reports false only
+// when the backend doesn't support the batch endpoint; other failures drop
+// the batch like single uploads do.
+func (c *SnapshotClient) sendSnapshotBatch(snapshots []Snapshot) bool {
+	// Circuit breaker check: skip if circuit is open
+	if !c.cb.ShouldAllow() {
+		return true
+	}
+
+	encoded := make([]json.RawMessage, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		body, err := c.encodeSnapshot(snapshot)
+		if err != nil {
+			c.logger.errorf("TraceKit: %v", err)
+			continue
+		}
+		encoded = append(encoded, body)
+	}
+	if len(encoded) == 0 {
+		return true
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"snapshots": encoded})
+	if err != nil {
+		c.logger.errorf("TraceKit: failed to marshal snapshot batch: %v", err)
+		return true
+	}
+
+	url := fmt.Sprintf("%s/sdk/snapshots/capture/batch", c.baseURL)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		c.logger.warnf("⚠️  Failed to create snapshot batch request: %v", err)
+		return true
+	}
+
+	req.Header.Set("X-API-Key", c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// Network/connection error -- count as HTTP failure for circuit breaker
+		c.logger.warnf("⚠️  Failed to send snapshot batch: %v", err)
+		if tripped := c.cb.RecordFailure(); tripped {
+			c.queueCircuitBreakerEvent()
+		}
+		return true
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusMethodNotAllowed,
+		resp.StatusCode == http.StatusNotImplemented:
+		return false
+	case resp.StatusCode >= 500:
+		// Server error -- count as HTTP failure for circuit breaker
+		c.logger.warnf("⚠️  Failed to capture snapshot batch: status %d", resp.StatusCode)
+		if tripped := c.cb.RecordFailure(); tripped {
+			c.queueCircuitBreakerEvent()
+		}
+	case resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated:
+		// Client error (4xx) -- do NOT count as circuit breaker failure
+		c.logger.warnf("⚠️  Failed to capture snapshot batch: status %d", resp.StatusCode)
+	default:
+		c.logger.debugf("📸 Snapshot batch captured: %d snapshots", len(encoded))
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package tracekit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// TestSnapshotBuffer verifies snapshots are uploaded in one batch, and sent
+// individually when the backend lacks the batch endpoint
+func TestSnapshotBuffer(t *testing.T) {
+	tests := []struct {
+		name            string
+		batchSupported  bool
+		wantBatches     int
+		wantSingleCalls int
+	}{
+		{name: "batch", batchSupported: true, wantBatches: 1, wantSingleCalls: 0},
+		{name: "fallback", batchSupported: false, wantBatches: 0, wantSingleCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var batches, singles int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				switch r.URL.Path {
+				case "/sdk/snapshots/capture/batch":
+					if !tt.batchSupported {
+						http.NotFound(w, r)
+						return
+					}
+					var body struct {
+						Snapshots []Snapshot `json:"snapshots"`
+					}
+					json.NewDecoder(r.Body).Decode(&body)
+					if len(body.Snapshots) != 3 {
+						t.Errorf("batch has %d snapshots; want 3", len(body.Snapshots))
+					}
+					batches++
+				case "/sdk/snapshots/capture":
+					singles++
+				}
+			}))
+			defer server.Close()
+
+			client := NewSnapshotClient("test-key", server.URL, "test-service")
+			buffer := newSnapshotBuffer(client, 10, 0)
+			for i := 1; i <= 3; i++ {
+				if !buffer.add(Snapshot{FilePath: "orders.go", LineNumber: i}) {
+					t.Fatal("add() rejected a snapshot before any flush")
+				}
+			}
+			buffer.flush()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if batches != tt.wantBatches || singles != tt.wantSingleCalls {
+				t.Errorf("got %d batch and %d single uploads; want %d and %d", batches, singles, tt.wantBatches, tt.wantSingleCalls)
+			}
+			if got := buffer.add(Snapshot{}); got != tt.batchSupported {
+				t.Errorf("add() after flush = %v; want %v", got, tt.batchSupported)
+			}
+		})
+	}
+}
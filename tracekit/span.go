@@ -2,23 +2,155 @@ package tracekit
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
 )
 
-// StartSpan starts a new span with the given name
+// StartSpan starts a new span with the given name.
+// When tracing is disabled via SetEnabled(false), it returns the incoming
+// context and a no-op span. Attributes from Config.ContextAttributeExtractors
+// are attached to the new span.
 func (s *SDK) StartSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if !s.IsEnabled() {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	if s.config != nil && len(s.config.ContextAttributeExtractors) > 0 {
+		// Cap capacity so the caller's backing array is never written to
+		opts = append(opts[:len(opts):len(opts)], trace.WithAttributes(s.contextAttributes(ctx)...))
+	}
 	return s.tracer.Start(ctx, name, opts...)
 }
 
-// AddAttribute adds a string attribute to a span
+// contextAttributes collects attributes from all configured context extractors
+func (s *SDK) contextAttributes(ctx context.Context) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, extract := range s.config.ContextAttributeExtractors {
+		attrs = append(attrs, extract(ctx)...)
+	}
+	return attrs
+}
+
+// PipelineStage starts a span for one stage of a channel-based pipeline.
+// Pass the returned context downstream alongside the channel item so the
+// next stage continues the same trace:
+//
+//	ctx, span := sdk.PipelineStage(item.Ctx, "transform")
+//	defer span.End()
+//	out <- Item{Ctx: ctx, Value: transform(item.Value)}
+func (s *SDK) PipelineStage(ctx context.Context, name string) (context.Context, trace.Span) {
+	return s.StartSpan(ctx, "pipeline."+name,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attribute.String("pipeline.stage", name)),
+	)
+}
+
+// recursionKey is the context key carrying the recursion state for a span name
+type recursionKey string
+
+// recursionState tracks the current depth of a recursive call chain
+type recursionState struct {
+	depth int
+	root  *recursionRoot
+}
+
+// recursionRoot is shared by every call of one top-level recursion and owns
+// the single summary span aggregating all calls deeper than the depth limit
+type recursionRoot struct {
+	rootCtx context.Context
+
+	mu       sync.Mutex
+	summary  trace.Span
+	calls    int64
+	maxDepth int
+}
+
+// StartRecursiveSpan starts a span for one level of a recursive call chain.
+// The depth is carried in the context: levels below maxDepth (at least 1)
+// get their own span, while all deeper calls of the top-level recursion
+// share a single "<name>.aggregated" summary span, a child of the root span
+// recording recursion.calls and the deepest recursion.depth reached.
+//
+//	func walk(ctx context.Context, n *Node) {
+//		ctx, span := sdk.StartRecursiveSpan(ctx, "walk", 3)
+//		defer span.End()
+//		for _, child := range n.Children {
+//			walk(ctx, child)
+//		}
+//	}
+func (s *SDK) StartRecursiveSpan(ctx context.Context, name string, maxDepth int) (context.Context, trace.Span) {
+	key := recursionKey(name)
+	state, _ := ctx.Value(key).(recursionState)
+
+	if state.root == nil {
+		ctx, span := s.StartSpan(ctx, name, trace.WithAttributes(attribute.Int("recursion.depth", 0)))
+		root := &recursionRoot{rootCtx: ctx}
+		return context.WithValue(ctx, key, recursionState{depth: 1, root: root}), &recursionRootSpan{Span: span, root: root}
+	}
+
+	if state.depth < maxDepth {
+		ctx, span := s.StartSpan(ctx, name, trace.WithAttributes(attribute.Int("recursion.depth", state.depth)))
+		return context.WithValue(ctx, key, recursionState{depth: state.depth + 1, root: state.root}), span
+	}
+
+	root := state.root
+	root.mu.Lock()
+	if root.summary == nil {
+		_, root.summary = s.StartSpan(root.rootCtx, name+".aggregated", trace.WithAttributes(
+			attribute.Bool("recursion.aggregated", true),
+		))
+	}
+	root.calls++
+	if state.depth > root.maxDepth {
+		root.maxDepth = state.depth
+	}
+	summary := root.summary
+	root.mu.Unlock()
+
+	ctx = trace.ContextWithSpan(ctx, summary)
+	ctx = context.WithValue(ctx, key, recursionState{depth: state.depth + 1, root: root})
+	return ctx, aggregatedCallSpan{Span: summary}
+}
+
+// recursionRootSpan ends the summary span, with its aggregate counts, when
+// the top-level call ends
+type recursionRootSpan struct {
+	trace.Span
+	root *recursionRoot
+}
+
+func (s *recursionRootSpan) End(options ...trace.SpanEndOption) {
+	s.root.mu.Lock()
+	if s.root.summary != nil {
+		s.root.summary.SetAttributes(
+			attribute.Int64("recursion.calls", s.root.calls),
+			attribute.Int("recursion.depth", s.root.maxDepth),
+		)
+		s.root.summary.End()
+	}
+	s.root.mu.Unlock()
+	s.Span.End(options...)
+}
+
+// aggregatedCallSpan forwards attributes and errors to the summary span but
+// leaves ending it to the top-level call
+type aggregatedCallSpan struct {
+	trace.Span
+}
+
+func (aggregatedCallSpan) End(...trace.SpanEndOption) {}
+
+// AddAttribute adds a string attribute to a span (subject to redaction rules)
 func (s *SDK) AddAttribute(span trace.Span, key, value string) {
-	span.SetAttributes(attribute.String(key, value))
+	span.SetAttributes(attribute.String(key, s.redactor.redact(key, value)))
 }
 
 // AddAttributes adds multiple attributes to a span
@@ -46,11 +178,57 @@ func (s *SDK) AddEvent(span trace.Span, name string, attrs ...attribute.KeyValue
 	span.AddEvent(name, trace.WithAttributes(attrs...))
 }
 
+// EventLevel is the severity of a span event, recorded as its "level"
+// attribute so the backend can distinguish warnings from routine events
+type EventLevel string
+
+const (
+	EventLevelInfo  EventLevel = "info"
+	EventLevelWarn  EventLevel = "warn"
+	EventLevelError EventLevel = "error"
+)
+
+// AddEventWithLevel adds an event to a span with a severity level, e.g. to
+// surface retries and fallbacks without marking the span as an error
+func (s *SDK) AddEventWithLevel(span trace.Span, level EventLevel, name string, attrs ...attribute.KeyValue) {
+	attrs = append([]attribute.KeyValue{attribute.String("level", string(level))}, attrs...)
+	span.AddEvent(name, trace.WithAttributes(attrs...))
+}
+
+// RecordProgress adds a "progress" event to a long-running span (e.g. a
+// migration processing millions of rows) with the completion percentage and
+// the throughput since the span started, so a stalled operation shows where
+// it halted.
+func (s *SDK) RecordProgress(span trace.Span, current, total int64) {
+	attrs := []attribute.KeyValue{
+		attribute.Int64("progress.current", current),
+		attribute.Int64("progress.total", total),
+	}
+
+	if total > 0 {
+		attrs = append(attrs, attribute.Float64("progress.percent", float64(current)*100/float64(total)))
+	}
+
+	// Throughput needs the span start time, only available on SDK spans
+	if ro, ok := span.(sdktrace.ReadOnlySpan); ok {
+		if elapsed := time.Since(ro.StartTime()).Seconds(); elapsed > 0 {
+			attrs = append(attrs, attribute.Float64("progress.items_per_second", float64(current)/elapsed))
+		}
+	}
+
+	span.AddEvent("progress", trace.WithAttributes(attrs...))
+}
+
 // RecordError records an error on a span with stack trace and marks it as error
 func (s *SDK) RecordError(span trace.Span, err error) {
+	s.recordError(span, err, 4) // skip 4 frames: runtime.Callers, captureStackTrace, recordError, RecordError
+}
+
+// recordError implements RecordError, skipping skip frames of the stack trace
+func (s *SDK) recordError(span trace.Span, err error, skip int) {
 	if err != nil {
 		// Capture stack trace
-		stacktrace := captureStackTrace(3) // skip 3 frames: runtime.Callers, captureStackTrace, RecordError
+		stacktrace := captureStackTrace(skip)
 
 		// Record error with stack trace as an event
 		span.AddEvent("exception", trace.WithAttributes(
@@ -116,6 +294,33 @@ func (s *SDK) SetError(span trace.Span, message string) {
 	span.SetStatus(codes.Error, message)
 }
 
+// ErrorEnvelope is the standard JSON body returned by ErrorResponse
+type ErrorEnvelope struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes an API error and the trace it belongs to
+type ErrorDetail struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+// ErrorResponse records message as an error on the active span in ctx and
+// returns code with an ErrorEnvelope that embeds the trace ID, ready to be
+// written as JSON (e.g. c.JSON(sdk.ErrorResponse(ctx, 404, "order not found"))).
+func (s *SDK) ErrorResponse(ctx context.Context, code int, message string) (int, interface{}) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(errors.New(message), trace.WithAttributes(attribute.Int("http.status_code", code)))
+	span.SetStatus(codes.Error, message)
+
+	detail := ErrorDetail{Code: code, Message: message}
+	if sc := span.SpanContext(); sc.HasTraceID() {
+		detail.TraceID = sc.TraceID().String()
+	}
+	return code, ErrorEnvelope{Error: detail}
+}
+
 // Helper functions for common attribute patterns
 
 // AddHTTPAttributes adds common HTTP attributes to a span
@@ -137,6 +342,57 @@ func (s *SDK) AddDatabaseAttributes(span trace.Span, dbSystem, dbName, operation
 	)
 }
 
+// DatabaseAttributes describes a datastore operation for AddDatabaseSpanAttributes.
+// Empty fields are not recorded.
+type DatabaseAttributes struct {
+	System    string // db.system, e.g. "postgresql" or a custom store name
+	Name      string // db.name
+	Operation string // db.operation, e.g. "SELECT" or "GET"
+	Table     string // db.sql.table
+	Statement string // db.statement; redacted and truncated like wrapped queries
+
+	// Tags are extra attributes, recorded like AddBusinessAttributes
+	Tags map[string]interface{}
+}
+
+// AddDatabaseSpanAttributes adds database attributes to a span in one call,
+// for hand-instrumented datastores. The statement gets the same redaction,
+// Config.MaxSQLStatementLength truncation and Config.DisableSQLCapture
+// handling as the built-in database wrappers.
+//
+//	sdk.AddDatabaseSpanAttributes(span, tracekit.DatabaseAttributes{
+//		System:    "ledgerdb",
+//		Operation: "GET",
+//		Statement: cmd.String(),
+//		Tags:      map[string]interface{}{"ledgerdb.shard": shard},
+//	})
+func (s *SDK) AddDatabaseSpanAttributes(span trace.Span, db DatabaseAttributes) {
+	var attrs []attribute.KeyValue
+	for _, a := range []struct{ key, value string }{
+		{"db.system", db.System},
+		{"db.name", db.Name},
+		{"db.operation", db.Operation},
+		{"db.sql.table", db.Table},
+	} {
+		if a.value != "" {
+			attrs = append(attrs, attribute.String(a.key, a.value))
+		}
+	}
+
+	if db.Statement != "" && (s.config == nil || !s.config.DisableSQLCapture) {
+		maxLen := 0
+		if s.config != nil {
+			maxLen = s.config.MaxSQLStatementLength
+		}
+		attrs = append(attrs, attribute.String("db.statement", formatSQL(s.redactor.redactSQL(db.Statement), maxLen)))
+	}
+
+	s.AddAttributes(span, attrs...)
+	if len(db.Tags) > 0 {
+		s.AddBusinessAttributes(span, db.Tags)
+	}
+}
+
 // AddUserAttributes adds user-related attributes to a span
 func (s *SDK) AddUserAttributes(span trace.Span, userID, email string) {
 	attrs := []attribute.KeyValue{}
@@ -152,13 +408,19 @@ func (s *SDK) AddUserAttributes(span trace.Span, userID, email string) {
 }
 
 // AddBusinessAttributes adds business-specific attributes (order ID, transaction ID, etc.)
+// Keys on the redaction denylist are recorded as the redaction marker.
 func (s *SDK) AddBusinessAttributes(span trace.Span, attrs map[string]interface{}) {
 	var otelAttrs []attribute.KeyValue
 
 	for k, v := range attrs {
+		if s.redactor.isDenied(k) {
+			otelAttrs = append(otelAttrs, attribute.String(k, s.redactor.marker))
+			continue
+		}
+
 		switch val := v.(type) {
 		case string:
-			otelAttrs = append(otelAttrs, attribute.String(k, val))
+			otelAttrs = append(otelAttrs, attribute.String(k, s.redactor.redact(k, val)))
 		case int:
 			otelAttrs = append(otelAttrs, attribute.Int64(k, int64(val)))
 		case int64:
@@ -168,7 +430,7 @@ func (s *SDK) AddBusinessAttributes(span trace.Span, attrs map[string]interface{
 		case bool:
 			otelAttrs = append(otelAttrs, attribute.Bool(k, val))
 		default:
-			otelAttrs = append(otelAttrs, attribute.String(k, fmt.Sprintf("%v", val)))
+			otelAttrs = append(otelAttrs, attribute.String(k, s.redactor.redact(k, fmt.Sprintf("%v", val))))
 		}
 	}
 
@@ -0,0 +1,137 @@
+package tracekit
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestStartRecursiveSpan verifies all calls beyond the depth limit share one summary span
+func TestStartRecursiveSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{tracer: tp.Tracer("test")}
+
+	// Binary tree of depth 5: 1 + 2 + 4 + 8 + 16 = 31 calls
+	var walk func(ctx context.Context, depth int)
+	walk = func(ctx context.Context, depth int) {
+		ctx, span := sdk.StartRecursiveSpan(ctx, "walk", 2)
+		defer span.End()
+		if depth < 4 {
+			walk(ctx, depth+1)
+			walk(ctx, depth+1)
+		}
+	}
+	walk(context.Background(), 0)
+
+	var regular, aggregated int
+	for _, span := range recorder.Ended() {
+		switch span.Name() {
+		case "walk":
+			regular++
+		case "walk.aggregated":
+			aggregated++
+			for _, attr := range span.Attributes() {
+				if attr.Key == "recursion.calls" && attr.Value.AsInt64() != 28 {
+					t.Errorf("recursion.calls = %d; want 28", attr.Value.AsInt64())
+				}
+				if attr.Key == "recursion.depth" && attr.Value.AsInt64() != 4 {
+					t.Errorf("recursion.depth = %d; want 4", attr.Value.AsInt64())
+				}
+			}
+		}
+	}
+
+	// Depths 0-1 get their own spans; the 4 depth-2 subtrees (7 calls each) share one summary
+	if regular != 3 {
+		t.Errorf("got %d regular spans; want 3", regular)
+	}
+	if aggregated != 1 {
+		t.Errorf("got %d aggregated spans; want 1", aggregated)
+	}
+}
+
+// TestAddDatabaseSpanAttributes verifies the statement is redacted and
+// truncated, empty fields are skipped and denied tags are masked
+func TestAddDatabaseSpanAttributes(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+
+	sdk := &SDK{
+		config: &Config{MaxSQLStatementLength: 20},
+		redactor: newRedactor(&RedactionConfig{
+			DenyKeys:          []string{"ledgerdb.token"},
+			RedactSQLLiterals: true,
+		}),
+	}
+
+	_, span := tp.Tracer("test").Start(context.Background(), "ledgerdb.get")
+	sdk.AddDatabaseSpanAttributes(span, DatabaseAttributes{
+		System:    "ledgerdb",
+		Operation: "GET",
+		Statement: "GET 'acct-42' FROM accounts LIMIT 1",
+		Tags:      map[string]interface{}{"ledgerdb.shard": 3, "ledgerdb.token": "secret"},
+	})
+	span.End()
+
+	attrs := map[string]string{}
+	for _, attr := range recorder.Ended()[0].Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+
+	want := map[string]string{
+		"db.system":      "ledgerdb",
+		"db.operation":   "GET",
+		"db.statement":   "GET ? FROM accounts ... (truncated)",
+		"ledgerdb.shard": "3",
+		"ledgerdb.token": "[REDACTED]",
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("%s = %q; want %q", k, attrs[k], v)
+		}
+	}
+	if _, ok := attrs["db.name"]; ok {
+		t.Errorf("db.name recorded for empty Name")
+	}
+}
+
+// TestErrorResponse verifies the envelope carries the trace ID and the span is marked as error
+func TestErrorResponse(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{}
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "GET /orders/:id")
+	status, body := sdk.ErrorResponse(ctx, 404, "order not found")
+	span.End()
+
+	want := ErrorEnvelope{Error: ErrorDetail{
+		Code:    404,
+		Message: "order not found",
+		TraceID: span.SpanContext().TraceID().String(),
+	}}
+	if status != 404 || body != want {
+		t.Errorf("ErrorResponse() = %d, %+v; want 404, %+v", status, body, want)
+	}
+
+	ended := recorder.Ended()[0]
+	if ended.Status().Code != codes.Error || ended.Status().Description != "order not found" {
+		t.Errorf("span status = %+v; want Error \"order not found\"", ended.Status())
+	}
+	if len(ended.Events()) != 1 || ended.Events()[0].Name != "exception" {
+		t.Errorf("span events = %+v; want one exception event", ended.Events())
+	}
+
+	// Without an active span the envelope omits the trace ID
+	_, body = sdk.ErrorResponse(context.Background(), 500, "internal error")
+	if got := body.(ErrorEnvelope).Error.TraceID; got != "" {
+		t.Errorf("TraceID without span = %q; want empty", got)
+	}
+}
@@ -0,0 +1,343 @@
+package tracekit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OpenDB opens a standard *sql.DB whose driver emits spans for every query,
+// exec, transaction begin, commit and rollback, so existing code using *sql.DB is traced without
+// call-site changes. driverName must already be registered (e.g. by
+// importing the driver package).
+//
+//	db, err := sdk.OpenDB("postgres", dsn, "postgresql")
+func (s *SDK) OpenDB(driverName, dsn, dbSystem string) (*sql.DB, error) {
+	// sql.Open only looks the driver up; no connection is made
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv := db.Driver()
+	db.Close()
+
+	var connector driver.Connector
+	if dc, ok := drv.(driver.DriverContext); ok {
+		connector, err = dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		connector = &dsnConnector{dsn: dsn, driver: drv}
+	}
+
+	tracer := &sqlDriverTracer{
+		tracer:   s.tracer,
+		dbSystem: dbSystem,
+		redactor: s.redactor,
+	}
+	if s.config != nil {
+		tracer.maxStatementLength = s.config.MaxSQLStatementLength
+		tracer.disableStatement = s.config.DisableSQLCapture
+	}
+
+	return sql.OpenDB(&tracedConnector{base: connector, tracer: tracer}), nil
+}
+
+// sqlDriverTracer records spans for driver-level operations
+type sqlDriverTracer struct {
+	tracer             trace.Tracer
+	dbSystem           string
+	redactor           *redactor
+	maxStatementLength int
+	disableStatement   bool
+}
+
+// record emits a span for an operation that started at start. Spans are
+// created after the call returns so operations the driver skips (returning
+// driver.ErrSkip to fall back to prepared statements) aren't recorded twice.
+func (t *sqlDriverTracer) record(ctx context.Context, name, query string, start time.Time, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		return
+	}
+
+	attrs := []attribute.KeyValue{attribute.String("db.system", t.dbSystem)}
+	if query != "" {
+		if !t.disableStatement {
+			attrs = append(attrs, attribute.String("db.statement", formatSQL(t.redactor.redactSQL(query), t.maxStatementLength)))
+		}
+		operation, table := parseSQLOperation(query)
+		if operation != "" {
+			attrs = append(attrs, attribute.String("db.operation", operation))
+		}
+		if table != "" {
+			attrs = append(attrs, attribute.String("db.sql.table", table))
+		}
+	} else if operation, ok := sqlTransactionOperations[name]; ok {
+		attrs = append(attrs, attribute.String("db.operation", operation))
+	}
+
+	_, span := t.tracer.Start(ctx, name, trace.WithTimestamp(start), trace.WithAttributes(attrs...))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetStatus(codes.Ok, "")
+	}
+	span.End()
+}
+
+// sqlTransactionOperations maps transaction span names to db.operation
+var sqlTransactionOperations = map[string]string{
+	"sql.begin_transaction": "BEGIN",
+	"sql.commit":            "COMMIT",
+	"sql.rollback":          "ROLLBACK",
+}
+
+// dsnConnector adapts a driver without driver.DriverContext to driver.Connector
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// tracedConnector wraps a driver.Connector so every connection is traced
+type tracedConnector struct {
+	base   driver.Connector
+	tracer *sqlDriverTracer
+}
+
+func (c *tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: conn, tracer: c.tracer}, nil
+}
+
+func (c *tracedConnector) Driver() driver.Driver {
+	return &tracedDriver{base: c.base.Driver(), tracer: c.tracer}
+}
+
+// tracedDriver wraps a driver.Driver so connections opened by name are traced
+type tracedDriver struct {
+	base   driver.Driver
+	tracer *sqlDriverTracer
+}
+
+func (d *tracedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.base.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: conn, tracer: d.tracer}, nil
+}
+
+// tracedConn wraps a driver.Conn, forwarding optional interfaces to the
+// underlying connection when it implements them
+type tracedConn struct {
+	driver.Conn
+	tracer *sqlDriverTracer
+}
+
+func (c *tracedConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := c.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedStmt{Stmt: stmt, conn: c.Conn, query: query, tracer: c.tracer}, nil
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	preparer, ok := c.Conn.(driver.ConnPrepareContext)
+	if !ok {
+		return c.Prepare(query)
+	}
+	stmt, err := preparer.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedStmt{Stmt: stmt, conn: c.Conn, query: query, tracer: c.tracer}, nil
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (tx driver.Tx, err error) {
+	start := time.Now()
+	defer func() { c.tracer.record(ctx, "sql.begin_transaction", "", start, err) }()
+
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) || opts.ReadOnly {
+		return nil, errors.New("tracekit: driver does not support non-default transaction options")
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tracedTx{Tx: tx, ctx: ctx, tracer: c.tracer}, nil
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (rows driver.Rows, err error) {
+	start := time.Now()
+	defer func() { c.tracer.record(ctx, "sql.query", query, start, err) }()
+
+	if queryer, ok := c.Conn.(driver.QueryerContext); ok {
+		return queryer.QueryContext(ctx, query, args)
+	}
+	if queryer, ok := c.Conn.(driver.Queryer); ok {
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return queryer.Query(query, values)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (result driver.Result, err error) {
+	start := time.Now()
+	defer func() { c.tracer.record(ctx, "sql.exec", query, start, err) }()
+
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, query, args)
+	}
+	if execer, ok := c.Conn.(driver.Execer); ok {
+		values, err := namedValuesToValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return execer.Exec(query, values)
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+func (c *tracedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// tracedStmt wraps a driver.Stmt so prepared executions are traced
+type tracedStmt struct {
+	driver.Stmt
+	conn   driver.Conn
+	query  string
+	tracer *sqlDriverTracer
+}
+
+// CheckNamedValue forwards to the statement's checker, falling back to the
+// connection's. database/sql only consults the connection when the statement
+// has no checker, so the fallback keeps driver argument handling unchanged.
+func (s *tracedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	if checker, ok := s.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// ColumnConverter forwards to the statement's converter, falling back to the
+// default conversion database/sql applies when a driver has none
+func (s *tracedStmt) ColumnConverter(idx int) driver.ValueConverter {
+	if converter, ok := s.Stmt.(driver.ColumnConverter); ok {
+		return converter.ColumnConverter(idx)
+	}
+	return driver.DefaultParameterConverter
+}
+
+func (s *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (result driver.Result, err error) {
+	start := time.Now()
+	defer func() { s.tracer.record(ctx, "sql.exec", s.query, start, err) }()
+
+	if execer, ok := s.Stmt.(driver.StmtExecContext); ok {
+		return execer.ExecContext(ctx, args)
+	}
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Exec(values)
+}
+
+func (s *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (rows driver.Rows, err error) {
+	start := time.Now()
+	defer func() { s.tracer.record(ctx, "sql.query", s.query, start, err) }()
+
+	if queryer, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		return queryer.QueryContext(ctx, args)
+	}
+	values, err := namedValuesToValues(args)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.Query(values)
+}
+
+// tracedTx wraps a driver.Tx so commit and rollback are traced as children
+// of the context the transaction was started with
+type tracedTx struct {
+	driver.Tx
+	ctx    context.Context
+	tracer *sqlDriverTracer
+}
+
+func (t *tracedTx) Commit() (err error) {
+	start := time.Now()
+	defer func() { t.tracer.record(t.ctx, "sql.commit", "", start, err) }()
+	return t.Tx.Commit()
+}
+
+func (t *tracedTx) Rollback() (err error) {
+	start := time.Now()
+	defer func() { t.tracer.record(t.ctx, "sql.rollback", "", start, err) }()
+	return t.Tx.Rollback()
+}
+
+// namedValuesToValues converts arguments for drivers without context support
+func namedValuesToValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("tracekit: driver does not support named parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
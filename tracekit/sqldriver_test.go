@@ -0,0 +1,130 @@
+package tracekit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// fakeDriver is a minimal driver supporting ExecerContext but not QueryerContext,
+// exercising both the direct and prepared-statement paths
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{}, nil }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+func (fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error                                    { return nil }
+func (fakeStmt) NumInput() int                                   { return -1 }
+func (fakeStmt) Exec(args []driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (fakeStmt) Query(args []driver.Value) (driver.Rows, error)  { return fakeRows{}, nil }
+
+type fakeRows struct{}
+
+func (fakeRows) Columns() []string              { return []string{"id"} }
+func (fakeRows) Close() error                   { return nil }
+func (fakeRows) Next(dest []driver.Value) error { return io.EOF }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func init() {
+	sql.Register("tracekit-fake", fakeDriver{})
+}
+
+// TestOpenDB verifies queries on a plain *sql.DB from OpenDB are traced
+func TestOpenDB(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+	sdk := &SDK{tracer: tp.Tracer("test")}
+
+	db, err := sdk.OpenDB("tracekit-fake", "", "fake")
+	if err != nil {
+		t.Fatalf("OpenDB() error = %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	if _, err := db.ExecContext(ctx, "UPDATE users SET name = ?", "a"); err != nil {
+		t.Fatalf("ExecContext() error = %v", err)
+	}
+	rows, err := db.QueryContext(ctx, "SELECT id FROM users")
+	if err != nil {
+		t.Fatalf("QueryContext() error = %v", err)
+	}
+	rows.Close()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	tx.Rollback()
+
+	spans := recorder.Ended()
+	want := []struct{ name, operation string }{
+		{"sql.exec", "UPDATE"},
+		{"sql.query", "SELECT"},
+		{"sql.begin_transaction", "BEGIN"},
+		{"sql.rollback", "ROLLBACK"},
+	}
+	if len(spans) != len(want) {
+		t.Fatalf("got %d spans; want %d", len(spans), len(want))
+	}
+	for i, w := range want {
+		if spans[i].Name() != w.name {
+			t.Errorf("span %d name = %q; want %q", i, spans[i].Name(), w.name)
+		}
+		var operation string
+		for _, attr := range spans[i].Attributes() {
+			if attr.Key == "db.operation" {
+				operation = attr.Value.AsString()
+			}
+		}
+		if operation != w.operation {
+			t.Errorf("span %d db.operation = %q; want %q", i, operation, w.operation)
+		}
+	}
+}
+
+// checkingConn is a fakeConn whose NamedValueChecker accepts any value
+type checkingConn struct{ fakeConn }
+
+func (checkingConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+// TestTracedStmtCheckNamedValue verifies argument checking falls back to the
+// connection's checker when the statement has none
+func TestTracedStmtCheckNamedValue(t *testing.T) {
+	tests := []struct {
+		name string
+		conn driver.Conn
+		want error
+	}{
+		{"connection checker", checkingConn{}, nil},
+		{"no checker", fakeConn{}, driver.ErrSkip},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := &tracedStmt{Stmt: fakeStmt{}, conn: tt.conn}
+			if got := stmt.CheckNamedValue(&driver.NamedValue{Ordinal: 1, Value: struct{}{}}); got != tt.want {
+				t.Errorf("CheckNamedValue() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
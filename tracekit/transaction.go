@@ -0,0 +1,48 @@
+package tracekit
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// transactionIDKey is the baggage key and span attribute used for business transactions
+const transactionIDKey = "transaction.id"
+
+// WithTransactionID groups all work under ctx into a logical business
+// transaction (e.g. the steps of a multi-request wizard). The ID travels
+// in W3C baggage, so it propagates to downstream services, and is stamped
+// as a transaction.id attribute on every span started from the returned
+// context. Unlike the trace ID, the same transaction ID can be reused
+// across many traces.
+func (s *SDK) WithTransactionID(ctx context.Context, transactionID string) context.Context {
+	member, err := baggage.NewMemberRaw(transactionIDKey, transactionID)
+	if err != nil {
+		return ctx
+	}
+
+	bag, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx
+	}
+
+	// Also stamp the span that is already active
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		span.SetAttributes(attribute.String(transactionIDKey, transactionID))
+	}
+
+	return baggage.ContextWithBaggage(ctx, bag)
+}
+
+// TransactionID returns the business transaction ID carried by ctx, if any
+func (s *SDK) TransactionID(ctx context.Context) string {
+	return transactionIDFromContext(ctx)
+}
+
+// transactionIDFromContext reads the transaction ID from baggage
+func transactionIDFromContext(ctx context.Context) string {
+	return baggage.FromContext(ctx).Member(transactionIDKey).Value()
+}